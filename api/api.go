@@ -6,7 +6,11 @@ import (
 	"github.com/go-playground/validator"
 	"github.com/gorilla/mux"
 	"github.com/saptaka/pos/api/handler"
+	"github.com/saptaka/pos/config"
+	"github.com/saptaka/pos/gateway"
+	"github.com/saptaka/pos/notify"
 	"github.com/saptaka/pos/repository"
+	"github.com/saptaka/pos/storage"
 )
 
 type Service interface {
@@ -17,9 +21,11 @@ type service struct {
 	routerHandler Router
 }
 
-func NewAPI(ctx context.Context, mux *mux.Router, repo repository.Repo) Service {
+func NewAPI(ctx context.Context, mux *mux.Router, repo repository.Repo,
+	cfg *config.Config, fileStorage storage.Storage, gateways gateway.Registry,
+	notifier notify.Notifier) Service {
 	validation := validator.New()
-	handlerService := handler.NewHandler(ctx, repo, validation)
+	handlerService := handler.NewHandler(ctx, repo, validation, cfg, fileStorage, gateways, notifier)
 	routerHandler := &router{handlerService, mux}
 	return &service{routerHandler}
 }
@@ -32,6 +38,21 @@ func (s *service) Route() {
 	s.routerHandler.RouteProductPath()
 	s.routerHandler.RouteReportPath()
 	s.routerHandler.RouteOrderPath()
+	s.routerHandler.RouteUploadPath()
+	s.routerHandler.RouteDiscountPath()
+	s.routerHandler.RoutePromotionPath()
+	s.routerHandler.RouteCouponPath()
+	s.routerHandler.RouteTaxPath()
+	s.routerHandler.RouteCurrencyPath()
+	s.routerHandler.RouteOrderPaymentPath()
+	s.routerHandler.RouteQRISPath()
+	s.routerHandler.RouteWebhookPath()
+	s.routerHandler.RouteShiftPath()
+	s.routerHandler.RouteSalesReportPath()
+	s.routerHandler.RouteCustomerPath()
+	s.routerHandler.RouteLoyaltyPath()
+	s.routerHandler.RouteGiftCardPath()
+	s.routerHandler.RouteReceiptPath()
 }
 
 type router struct {
@@ -48,6 +69,21 @@ type Router interface {
 	PaymentRouter
 	OrderRouter
 	ReportRouter
+	UploadRouter
+	DiscountRouter
+	PromotionRouter
+	CouponRouter
+	TaxRouter
+	CurrencyRouter
+	OrderPaymentRouter
+	QRISRouter
+	WebhookRouter
+	ShiftRouter
+	SalesReportRouter
+	CustomerRouter
+	LoyaltyRouter
+	GiftCardRouter
+	ReceiptRouter
 }
 
 func NewRouter() Router {
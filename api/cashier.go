@@ -16,6 +16,7 @@ type CashierRouter interface {
 	CreateCashier(res http.ResponseWriter, req *http.Request)
 	UpdateCashier(res http.ResponseWriter, req *http.Request)
 	DeleteCashier(res http.ResponseWriter, req *http.Request)
+	RestoreCashier(res http.ResponseWriter, req *http.Request)
 	RouteCashierPath()
 }
 
@@ -25,6 +26,7 @@ func (r *router) RouteCashierPath() {
 	r.mux.HandleFunc("/cashiers", r.CreateCashier).Methods("POST")
 	r.mux.HandleFunc("/cashiers/{cashierId}", r.UpdateCashier).Methods("PUT")
 	r.mux.HandleFunc("/cashiers/{cashierId}", r.DeleteCashier).Methods("DELETE")
+	r.mux.HandleFunc("/cashiers/{cashierId}/restore", r.RestoreCashier).Methods("POST")
 }
 
 func (r *router) ListCashier(res http.ResponseWriter, req *http.Request) {
@@ -134,3 +136,23 @@ func (r *router) DeleteCashier(res http.ResponseWriter, req *http.Request) {
 	res.Header().Set("Content-Type", "application/json")
 	res.Write(response)
 }
+
+func (r *router) RestoreCashier(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["cashierId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusNotFound, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.RestoreCashier(id)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
@@ -16,6 +16,7 @@ type CategoryRouter interface {
 	CreateCategory(res http.ResponseWriter, req *http.Request)
 	UpdateCategory(res http.ResponseWriter, req *http.Request)
 	DeleteCategory(res http.ResponseWriter, req *http.Request)
+	RestoreCategory(res http.ResponseWriter, req *http.Request)
 	RouteCategoryPath()
 }
 
@@ -25,6 +26,7 @@ func (r *router) RouteCategoryPath() {
 	r.mux.HandleFunc("/categories", r.CreateCategory).Methods("POST")
 	r.mux.HandleFunc("/categories/{categoryId}", r.UpdateCategory).Methods("PUT")
 	r.mux.HandleFunc("/categories/{categoryId}", r.DeleteCategory).Methods("DELETE")
+	r.mux.HandleFunc("/categories/{categoryId}/restore", r.RestoreCategory).Methods("POST")
 }
 
 func (r *router) ListCategory(res http.ResponseWriter, req *http.Request) {
@@ -133,3 +135,23 @@ func (r *router) DeleteCategory(res http.ResponseWriter, req *http.Request) {
 	res.Header().Set("Content-Type", "application/json")
 	res.Write(response)
 }
+
+func (r *router) RestoreCategory(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["categoryId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusNotFound, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.RestoreCategory(id)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
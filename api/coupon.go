@@ -0,0 +1,145 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/utils"
+)
+
+type CouponRouter interface {
+	ListCoupon(res http.ResponseWriter, req *http.Request)
+	DetailCoupon(res http.ResponseWriter, req *http.Request)
+	CreateCoupon(res http.ResponseWriter, req *http.Request)
+	UpdateCoupon(res http.ResponseWriter, req *http.Request)
+	DeleteCoupon(res http.ResponseWriter, req *http.Request)
+	CouponRedemptions(res http.ResponseWriter, req *http.Request)
+	RouteCouponPath()
+}
+
+func (r *router) RouteCouponPath() {
+	r.mux.HandleFunc("/coupons", middleware(r.ListCoupon)).Methods("GET")
+	r.mux.HandleFunc("/coupons/redemptions", middleware(r.CouponRedemptions)).Methods("GET")
+	r.mux.HandleFunc("/coupons/{couponId}", middleware(r.DetailCoupon)).Methods("GET")
+	r.mux.HandleFunc("/coupons", middleware(r.CreateCoupon)).Methods("POST")
+	r.mux.HandleFunc("/coupons/{couponId}", middleware(r.UpdateCoupon)).Methods("PUT")
+	r.mux.HandleFunc("/coupons/{couponId}", middleware(r.DeleteCoupon)).Methods("DELETE")
+}
+
+func (r *router) ListCoupon(res http.ResponseWriter, req *http.Request) {
+	limitQuery := req.URL.Query().Get("limit")
+	skipQuery := req.URL.Query().Get("skip")
+	limit, _ := strconv.Atoi(limitQuery)
+	skip, _ := strconv.Atoi(skipQuery)
+	response, statusCode := r.handlerService.ListCoupon(limit, skip)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) DetailCoupon(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["couponId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.DetailCoupon(id)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) CreateCoupon(res http.ResponseWriter, req *http.Request) {
+	var coupon model.Coupon
+	err := json.NewDecoder(req.Body).Decode(&coupon)
+	if err != nil {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.CreateCoupon(coupon)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) UpdateCoupon(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["couponId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusNotFound, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	var coupon model.Coupon
+	err := json.NewDecoder(req.Body).Decode(&coupon)
+	if err != nil {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	coupon.CouponId = id
+	response, statusCode := r.handlerService.UpdateCoupon(coupon)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) DeleteCoupon(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["couponId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusNotFound, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.DeleteCoupon(id)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) CouponRedemptions(res http.ResponseWriter, req *http.Request) {
+	couponId, _ := strconv.ParseInt(req.URL.Query().Get("couponId"), 10, 0)
+	response, statusCode := r.handlerService.CouponRedemptions(couponId)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
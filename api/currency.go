@@ -0,0 +1,243 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/utils"
+)
+
+type CurrencyRouter interface {
+	ListCurrency(res http.ResponseWriter, req *http.Request)
+	DetailCurrency(res http.ResponseWriter, req *http.Request)
+	CreateCurrency(res http.ResponseWriter, req *http.Request)
+	UpdateCurrency(res http.ResponseWriter, req *http.Request)
+	DeleteCurrency(res http.ResponseWriter, req *http.Request)
+	ListExchangeRate(res http.ResponseWriter, req *http.Request)
+	CreateExchangeRate(res http.ResponseWriter, req *http.Request)
+	EffectiveRate(res http.ResponseWriter, req *http.Request)
+	TenderSummary(res http.ResponseWriter, req *http.Request)
+	RouteCurrencyPath()
+}
+
+func (r *router) RouteCurrencyPath() {
+	r.mux.HandleFunc("/currencies", middleware(r.ListCurrency)).Methods("GET")
+	r.mux.HandleFunc("/currencies/{currencyId}", middleware(r.DetailCurrency)).Methods("GET")
+	r.mux.HandleFunc("/currencies", middleware(r.CreateCurrency)).Methods("POST")
+	r.mux.HandleFunc("/currencies/{currencyId}", middleware(r.UpdateCurrency)).Methods("PUT")
+	r.mux.HandleFunc("/currencies/{currencyId}", middleware(r.DeleteCurrency)).Methods("DELETE")
+	r.mux.HandleFunc("/currencies/{currencyId}/exchange-rates", middleware(r.ListExchangeRate)).Methods("GET")
+	r.mux.HandleFunc("/currencies/{currencyId}/exchange-rates", middleware(r.CreateExchangeRate)).Methods("POST")
+	r.mux.HandleFunc("/currencies/{currencyId}/exchange-rates/effective", middleware(r.EffectiveRate)).Methods("GET")
+	r.mux.HandleFunc("/tender-summary", middleware(r.TenderSummary)).Methods("GET")
+}
+
+func (r *router) ListCurrency(res http.ResponseWriter, req *http.Request) {
+	limitQuery := req.URL.Query().Get("limit")
+	skipQuery := req.URL.Query().Get("skip")
+	limit, _ := strconv.Atoi(limitQuery)
+	skip, _ := strconv.Atoi(skipQuery)
+	response, statusCode := r.handlerService.ListCurrency(limit, skip)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) DetailCurrency(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["currencyId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.DetailCurrency(id)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) CreateCurrency(res http.ResponseWriter, req *http.Request) {
+	var currency model.Currency
+	err := json.NewDecoder(req.Body).Decode(&currency)
+	if err != nil {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.CreateCurrency(currency)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) UpdateCurrency(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["currencyId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusNotFound, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	var currency model.Currency
+	err := json.NewDecoder(req.Body).Decode(&currency)
+	if err != nil {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	currency.CurrencyId = id
+	response, statusCode := r.handlerService.UpdateCurrency(currency)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) DeleteCurrency(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["currencyId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusNotFound, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.DeleteCurrency(id)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) ListExchangeRate(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["currencyId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusNotFound, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	limitQuery := req.URL.Query().Get("limit")
+	skipQuery := req.URL.Query().Get("skip")
+	limit, _ := strconv.Atoi(limitQuery)
+	skip, _ := strconv.Atoi(skipQuery)
+	response, statusCode := r.handlerService.ListExchangeRate(id, limit, skip)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) CreateExchangeRate(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["currencyId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusNotFound, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	var rate model.ExchangeRate
+	err := json.NewDecoder(req.Body).Decode(&rate)
+	if err != nil {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	rate.CurrencyId = id
+	response, statusCode := r.handlerService.CreateExchangeRate(rate)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) EffectiveRate(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["currencyId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusNotFound, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	at := time.Now()
+	if atQuery := req.URL.Query().Get("at"); atQuery != "" {
+		timestamp, err := model.ParseTimestamp(atQuery)
+		if err != nil {
+			response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+			res.WriteHeader(statusCode)
+			res.Write(response)
+			return
+		}
+		at = timestamp.Time
+	}
+	response, statusCode := r.handlerService.EffectiveRate(id, at)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) TenderSummary(res http.ResponseWriter, req *http.Request) {
+	from, okFrom := queryTime(req, "from")
+	to, okTo := queryTime(req, "to")
+	if !okFrom || !okTo {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.TenderSummary(from, to)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
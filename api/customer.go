@@ -0,0 +1,184 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/utils"
+)
+
+type CustomerRouter interface {
+	ListCustomer(res http.ResponseWriter, req *http.Request)
+	DetailCustomer(res http.ResponseWriter, req *http.Request)
+	CreateCustomer(res http.ResponseWriter, req *http.Request)
+	UpdateCustomer(res http.ResponseWriter, req *http.Request)
+	DeleteCustomer(res http.ResponseWriter, req *http.Request)
+	RestoreCustomer(res http.ResponseWriter, req *http.Request)
+	CustomerHistory(res http.ResponseWriter, req *http.Request)
+	RouteCustomerPath()
+}
+
+func (r *router) RouteCustomerPath() {
+	r.mux.HandleFunc("/customers", middleware(r.ListCustomer)).Methods("GET")
+	r.mux.HandleFunc("/customers/{customerId}", middleware(r.DetailCustomer)).Methods("GET")
+	r.mux.HandleFunc("/customers", middleware(r.CreateCustomer)).Methods("POST")
+	r.mux.HandleFunc("/customers/{customerId}", middleware(r.UpdateCustomer)).Methods("PUT")
+	r.mux.HandleFunc("/customers/{customerId}", middleware(r.DeleteCustomer)).Methods("DELETE")
+	r.mux.HandleFunc("/customers/{customerId}/restore", middleware(r.RestoreCustomer)).Methods("POST")
+	r.mux.HandleFunc("/customers/{customerId}/orders", middleware(r.CustomerHistory)).Methods("GET")
+}
+
+func (r *router) ListCustomer(res http.ResponseWriter, req *http.Request) {
+	limitQuery := req.URL.Query().Get("limit")
+	skipQuery := req.URL.Query().Get("skip")
+	limit, _ := strconv.Atoi(limitQuery)
+	skip, _ := strconv.Atoi(skipQuery)
+	filter := model.CustomerFilter{
+		Query: req.URL.Query().Get("q"),
+		Phone: req.URL.Query().Get("phone"),
+		Tag:   req.URL.Query().Get("tag"),
+	}
+	response, statusCode := r.handlerService.ListCustomer(limit, skip, filter)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) DetailCustomer(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["customerId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.DetailCustomer(id)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) CreateCustomer(res http.ResponseWriter, req *http.Request) {
+	var customer model.Customer
+	err := json.NewDecoder(req.Body).Decode(&customer)
+	if err != nil {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.CreateCustomer(customer)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) UpdateCustomer(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["customerId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusNotFound, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	var customer model.Customer
+	err := json.NewDecoder(req.Body).Decode(&customer)
+	if err != nil {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	customer.CustomerId = id
+	response, statusCode := r.handlerService.UpdateCustomer(customer)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) DeleteCustomer(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["customerId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusNotFound, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.DeleteCustomer(id)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) RestoreCustomer(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["customerId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusNotFound, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.RestoreCustomer(id)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) CustomerHistory(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["customerId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusNotFound, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	limitQuery := req.URL.Query().Get("limit")
+	skipQuery := req.URL.Query().Get("skip")
+	limit, _ := strconv.Atoi(limitQuery)
+	skip, _ := strconv.Atoi(skipQuery)
+	response, statusCode := r.handlerService.CustomerHistory(id, limit, skip)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
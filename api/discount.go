@@ -0,0 +1,183 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/utils"
+)
+
+type DiscountRouter interface {
+	ListDiscount(res http.ResponseWriter, req *http.Request)
+	DetailDiscount(res http.ResponseWriter, req *http.Request)
+	CreateDiscount(res http.ResponseWriter, req *http.Request)
+	UpdateDiscount(res http.ResponseWriter, req *http.Request)
+	DeleteDiscount(res http.ResponseWriter, req *http.Request)
+	AttachDiscount(res http.ResponseWriter, req *http.Request)
+	DetachDiscount(res http.ResponseWriter, req *http.Request)
+	RouteDiscountPath()
+}
+
+func (r *router) RouteDiscountPath() {
+	r.mux.HandleFunc("/discounts", middleware(r.ListDiscount)).Methods("GET")
+	r.mux.HandleFunc("/discounts/{discountId}", middleware(r.DetailDiscount)).Methods("GET")
+	r.mux.HandleFunc("/discounts", middleware(r.CreateDiscount)).Methods("POST")
+	r.mux.HandleFunc("/discounts/{discountId}", middleware(r.UpdateDiscount)).Methods("PUT")
+	r.mux.HandleFunc("/discounts/{discountId}", middleware(r.DeleteDiscount)).Methods("DELETE")
+	r.mux.HandleFunc("/products/{productId}/discount", middleware(r.AttachDiscount)).Methods("PUT")
+	r.mux.HandleFunc("/products/{productId}/discount", middleware(r.DetachDiscount)).Methods("DELETE")
+}
+
+func (r *router) ListDiscount(res http.ResponseWriter, req *http.Request) {
+	limitQuery := req.URL.Query().Get("limit")
+	skipQuery := req.URL.Query().Get("skip")
+	limit, _ := strconv.Atoi(limitQuery)
+	skip, _ := strconv.Atoi(skipQuery)
+	response, statusCode := r.handlerService.ListDiscount(limit, skip)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) DetailDiscount(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["discountId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.DetailDiscount(id)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) CreateDiscount(res http.ResponseWriter, req *http.Request) {
+	var discount model.Discount
+	err := json.NewDecoder(req.Body).Decode(&discount)
+	if err != nil {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.CreateDiscount(discount)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) UpdateDiscount(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["discountId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusNotFound, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	var discount model.Discount
+	err := json.NewDecoder(req.Body).Decode(&discount)
+	if err != nil {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	discount.DiscountID = id
+	response, statusCode := r.handlerService.UpdateDiscount(discount)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) DeleteDiscount(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["discountId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusNotFound, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.DeleteDiscount(id)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) AttachDiscount(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["productId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	var discountRequest model.ProductDiscountRequest
+	err := json.NewDecoder(req.Body).Decode(&discountRequest)
+	if err != nil || discountRequest.DiscountId == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.AttachDiscount(id, discountRequest.DiscountId)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) DetachDiscount(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["productId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.DetachDiscount(id)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/utils"
+)
+
+type GiftCardRouter interface {
+	ListGiftCard(res http.ResponseWriter, req *http.Request)
+	DetailGiftCard(res http.ResponseWriter, req *http.Request)
+	IssueGiftCard(res http.ResponseWriter, req *http.Request)
+	ReloadGiftCard(res http.ResponseWriter, req *http.Request)
+	GiftCardLiability(res http.ResponseWriter, req *http.Request)
+	RouteGiftCardPath()
+}
+
+func (r *router) RouteGiftCardPath() {
+	r.mux.HandleFunc("/gift-cards", middleware(r.ListGiftCard)).Methods("GET")
+	r.mux.HandleFunc("/gift-cards", middleware(r.IssueGiftCard)).Methods("POST")
+	r.mux.HandleFunc("/gift-cards/{code}", middleware(r.DetailGiftCard)).Methods("GET")
+	r.mux.HandleFunc("/gift-cards/{code}/reload", middleware(r.ReloadGiftCard)).Methods("POST")
+	r.mux.HandleFunc("/reports/gift-cards", middleware(r.GiftCardLiability)).Methods("GET")
+}
+
+func (r *router) ListGiftCard(res http.ResponseWriter, req *http.Request) {
+	limitQuery := req.URL.Query().Get("limit")
+	skipQuery := req.URL.Query().Get("skip")
+	limit, _ := strconv.Atoi(limitQuery)
+	skip, _ := strconv.Atoi(skipQuery)
+	var customerId *int64
+	if customerQuery := req.URL.Query().Get("customerId"); customerQuery != "" {
+		id, err := strconv.ParseInt(customerQuery, 10, 0)
+		if err != nil {
+			response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+			res.WriteHeader(statusCode)
+			res.Write(response)
+			return
+		}
+		customerId = &id
+	}
+	response, statusCode := r.handlerService.ListGiftCard(limit, skip, customerId)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) DetailGiftCard(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	limitQuery := req.URL.Query().Get("limit")
+	skipQuery := req.URL.Query().Get("skip")
+	limit, _ := strconv.Atoi(limitQuery)
+	skip, _ := strconv.Atoi(skipQuery)
+	response, statusCode := r.handlerService.DetailGiftCard(params["code"], limit, skip)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) IssueGiftCard(res http.ResponseWriter, req *http.Request) {
+	var request model.IssueGiftCardRequest
+	err := json.NewDecoder(req.Body).Decode(&request)
+	if err != nil {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.IssueGiftCard(request)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) ReloadGiftCard(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	var request model.ReloadGiftCardRequest
+	err := json.NewDecoder(req.Body).Decode(&request)
+	if err != nil {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.ReloadGiftCard(params["code"], request)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) GiftCardLiability(res http.ResponseWriter, req *http.Request) {
+	response, statusCode := r.handlerService.GiftCardLiability()
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
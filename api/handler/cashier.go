@@ -8,6 +8,7 @@ import (
 	"strconv"
 
 	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/repository"
 	"github.com/saptaka/pos/utils"
 )
 
@@ -17,6 +18,7 @@ type Cashier interface {
 	CreateCashier(cashier model.Cashier) ([]byte, int)
 	UpdateCashier(cashier model.Cashier) ([]byte, int)
 	DeleteCashier(id int64) ([]byte, int)
+	RestoreCashier(id int64) ([]byte, int)
 }
 
 func (s service) ListCashier(limit, skip int) ([]byte, int) {
@@ -59,7 +61,13 @@ func (s service) CreateCashier(cashierDetail model.Cashier) ([]byte, int) {
 	if err != nil {
 		return utils.ResponseWrapper(http.StatusBadRequest, nil)
 	}
-	cashier, err := s.db.CreateCashier(s.ctx, cashierDetail.Name, cashierDetail.Passcode)
+	if cashierDetail.Role == "" {
+		cashierDetail.Role = model.RoleCashier
+	}
+	if !model.CashierRole[cashierDetail.Role] {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	cashier, err := s.db.CreateCashier(s.ctx, cashierDetail.Name, cashierDetail.Passcode, cashierDetail.Role)
 	if err != nil {
 		log.Println(err)
 		return utils.ResponseWrapper(http.StatusBadRequest, cashier)
@@ -68,6 +76,9 @@ func (s service) CreateCashier(cashierDetail model.Cashier) ([]byte, int) {
 }
 
 func (s service) UpdateCashier(cashierDetail model.Cashier) ([]byte, int) {
+	if cashierDetail.Role != "" && !model.CashierRole[cashierDetail.Role] {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
 
 	err := s.db.UpdateCashier(s.ctx, cashierDetail)
 	if err == sql.ErrNoRows {
@@ -85,6 +96,24 @@ func (s service) DeleteCashier(id int64) ([]byte, int) {
 	if err == sql.ErrNoRows {
 		return utils.ResponseWrapper(http.StatusNotFound, nil)
 	}
+	if err == repository.ErrReferenced {
+		return utils.ResponseWrapper(http.StatusConflict, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, nil)
+}
+
+func (s service) RestoreCashier(id int64) ([]byte, int) {
+	err := s.db.RestoreCashier(s.ctx, id)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err == repository.ErrReferenced {
+		return utils.ResponseWrapper(http.StatusConflict, nil)
+	}
 	if err != nil {
 		log.Println(err)
 		return utils.ResponseWrapper(http.StatusBadRequest, nil)
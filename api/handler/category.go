@@ -7,6 +7,7 @@ import (
 	"net/http"
 
 	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/repository"
 	"github.com/saptaka/pos/utils"
 )
 
@@ -16,6 +17,7 @@ type Category interface {
 	CreateCategory(Category model.Category) ([]byte, int)
 	UpdateCategory(Category model.Category) ([]byte, int)
 	DeleteCategory(id int64) ([]byte, int)
+	RestoreCategory(id int64) ([]byte, int)
 }
 
 func (s service) ListCategory(limit, skip int) ([]byte, int) {
@@ -83,6 +85,24 @@ func (s service) DeleteCategory(id int64) ([]byte, int) {
 	if err == sql.ErrNoRows {
 		return utils.ResponseWrapper(http.StatusNotFound, nil)
 	}
+	if err == repository.ErrReferenced {
+		return utils.ResponseWrapper(http.StatusConflict, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, nil)
+}
+
+func (s service) RestoreCategory(id int64) ([]byte, int) {
+	err := s.db.RestoreCategory(s.ctx, id)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err == repository.ErrReferenced {
+		return utils.ResponseWrapper(http.StatusConflict, nil)
+	}
 	if err != nil {
 		log.Println(err)
 		return utils.ResponseWrapper(http.StatusBadRequest, nil)
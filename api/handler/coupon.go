@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/promotion"
+	"github.com/saptaka/pos/utils"
+)
+
+type Coupon interface {
+	ListCoupon(limit, skip int) ([]byte, int)
+	DetailCoupon(id int64) ([]byte, int)
+	CreateCoupon(coupon model.Coupon) ([]byte, int)
+	UpdateCoupon(coupon model.Coupon) ([]byte, int)
+	DeleteCoupon(id int64) ([]byte, int)
+	CouponRedemptions(couponId int64) ([]byte, int)
+}
+
+func (s service) ListCoupon(limit, skip int) ([]byte, int) {
+	coupons, err := s.db.GetCoupons(s.ctx, limit, skip)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	listCoupon := model.ListCoupon{
+		Coupons: coupons,
+		Meta: model.Meta{
+			Total: len(coupons),
+			Limit: limit,
+			Skip:  skip,
+		},
+	}
+	return utils.ResponseWrapper(http.StatusOK, listCoupon)
+}
+
+func (s service) DetailCoupon(id int64) ([]byte, int) {
+	coupon, err := s.db.GetCouponByID(s.ctx, id)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, coupon)
+}
+
+func (s service) CreateCoupon(coupon model.Coupon) ([]byte, int) {
+	coupon.Code = couponCode(coupon.Code)
+	if !s.validCoupon(coupon) {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	coupon, err := s.db.CreateCoupon(s.ctx, coupon)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, coupon)
+}
+
+func (s service) UpdateCoupon(coupon model.Coupon) ([]byte, int) {
+	coupon.Code = couponCode(coupon.Code)
+	if !s.validCoupon(coupon) {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	err := s.db.UpdateCoupon(s.ctx, coupon)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, nil)
+}
+
+func (s service) DeleteCoupon(id int64) ([]byte, int) {
+	err := s.db.DeleteCoupon(s.ctx, id)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, nil)
+}
+
+// CouponRedemptions reports every redemption, of one coupon when couponId
+// is set, with totals per coupon.
+func (s service) CouponRedemptions(couponId int64) ([]byte, int) {
+	redemptions, err := s.db.GetCouponRedemptions(s.ctx, couponId)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+
+	report := model.CouponRedemptions{
+		Coupons:     make([]model.CouponRedemptionSummary, 0),
+		Redemptions: redemptions,
+	}
+	summaryIndex := make(map[int64]int)
+	for _, redemption := range redemptions {
+		report.TotalRedemptions++
+		report.TotalAmount += redemption.Amount
+		index, ok := summaryIndex[redemption.CouponId]
+		if !ok {
+			index = len(report.Coupons)
+			summaryIndex[redemption.CouponId] = index
+			report.Coupons = append(report.Coupons, model.CouponRedemptionSummary{
+				CouponId: redemption.CouponId,
+				Code:     redemption.Code,
+			})
+		}
+		report.Coupons[index].Redemptions++
+		report.Coupons[index].Amount += redemption.Amount
+	}
+	return utils.ResponseWrapper(http.StatusOK, report)
+}
+
+func (s service) validCoupon(coupon model.Coupon) bool {
+	err := s.validation.Struct(coupon)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+	if coupon.UsageLimit < 0 || coupon.PerCustomerLimit < 0 {
+		return false
+	}
+	if coupon.StartedAt != nil && coupon.ExpiredAt != nil &&
+		!coupon.StartedAt.Before(coupon.ExpiredAt.Time) {
+		return false
+	}
+	promotion, err := s.db.GetPromotionByID(s.ctx, coupon.PromotionId)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+	return promotion.ArchivedAt == nil
+}
+
+func couponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// couponError tells which entered coupon code cannot be used.
+type couponError struct {
+	index int
+	code  string
+}
+
+func (e couponError) Error() string {
+	return fmt.Sprintf("coupon %q cannot be used", e.code)
+}
+
+func (e couponError) data() model.ErrorData {
+	return model.ErrorData{
+		Message: fmt.Sprintf("\"coupons[%d]\" is not a valid coupon", e.index),
+		Path:    []string{"coupons", fmt.Sprint(e.index)},
+		Type:    "any.invalid",
+		Context: model.ErrorContext{
+			Label: "coupons",
+			Value: e.code,
+		},
+	}
+}
+
+// resolveCoupons looks up the entered codes and the promotions they unlock.
+// Repeated codes are ignored; any code that cannot be used at now by the
+// customer fails with a couponError.
+func (s service) resolveCoupons(codes []string, customerId *int64,
+	now time.Time) ([]model.Coupon, []model.Promotion, error) {
+
+	var coupons []model.Coupon
+	var promotions []model.Promotion
+	seen := make(map[string]bool)
+	for index, code := range codes {
+		code = couponCode(code)
+		if seen[code] {
+			continue
+		}
+		seen[code] = true
+
+		coupon, err := s.db.GetCouponByCode(s.ctx, code)
+		if err == sql.ErrNoRows {
+			return nil, nil, couponError{index, code}
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if !coupon.ActiveAt(now) {
+			return nil, nil, couponError{index, code}
+		}
+		if coupon.PerCustomerLimit > 0 {
+			if customerId == nil {
+				return nil, nil, couponError{index, code}
+			}
+			redeemed, err := s.db.CountCouponRedemptions(s.ctx, coupon.CouponId, *customerId)
+			if err != nil {
+				return nil, nil, err
+			}
+			if redeemed >= coupon.PerCustomerLimit {
+				return nil, nil, couponError{index, code}
+			}
+		}
+
+		promotion, err := s.db.GetPromotionByID(s.ctx, coupon.PromotionId)
+		if err == sql.ErrNoRows || promotion.ArchivedAt != nil {
+			return nil, nil, couponError{index, code}
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		coupons = append(coupons, coupon)
+		promotions = append(promotions, promotion)
+	}
+	return coupons, promotions, nil
+}
+
+// couponRedemptions returns a redemption for every coupon whose promotion
+// gave a saving. A promotion unlocked by several coupons redeems only the
+// first of them.
+func couponRedemptions(coupons []model.Coupon, result promotion.Result,
+	customerId *int64) []model.CouponRedemption {
+
+	savings := make(map[int64]model.Money)
+	for _, applied := range result.Applied {
+		savings[applied.PromotionId] += applied.Amount
+	}
+	var redemptions []model.CouponRedemption
+	for _, coupon := range coupons {
+		amount := savings[coupon.PromotionId]
+		if amount <= 0 {
+			continue
+		}
+		delete(savings, coupon.PromotionId)
+		redemptions = append(redemptions, model.CouponRedemption{
+			CouponId:    coupon.CouponId,
+			Code:        coupon.Code,
+			PromotionId: coupon.PromotionId,
+			CustomerId:  customerId,
+			Amount:      amount,
+		})
+	}
+	return redemptions
+}
@@ -0,0 +1,255 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/utils"
+)
+
+type Currency interface {
+	ListCurrency(limit, skip int) ([]byte, int)
+	DetailCurrency(id int64) ([]byte, int)
+	CreateCurrency(currency model.Currency) ([]byte, int)
+	UpdateCurrency(currency model.Currency) ([]byte, int)
+	DeleteCurrency(id int64) ([]byte, int)
+	ListExchangeRate(currencyId int64, limit, skip int) ([]byte, int)
+	CreateExchangeRate(rate model.ExchangeRate) ([]byte, int)
+	EffectiveRate(currencyId int64, at time.Time) ([]byte, int)
+	TenderSummary(from, to *time.Time) ([]byte, int)
+}
+
+var errInvalidTender = errors.New("invalid foreign currency tender")
+
+func (s service) ListCurrency(limit, skip int) ([]byte, int) {
+	currencies, err := s.db.GetCurrencies(s.ctx, limit, skip)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	listCurrency := model.ListCurrency{
+		Currencies: currencies,
+		Meta: model.Meta{
+			Total: len(currencies),
+			Limit: limit,
+			Skip:  skip,
+		},
+	}
+	return utils.ResponseWrapper(http.StatusOK, listCurrency)
+}
+
+func (s service) DetailCurrency(id int64) ([]byte, int) {
+	currency, err := s.db.GetCurrencyByID(s.ctx, id)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, currency)
+}
+
+// CreateCurrency adds a foreign currency to accept as tender. The symbol
+// and minor units of a well known code are filled in when no symbol is
+// given.
+func (s service) CreateCurrency(currency model.Currency) ([]byte, int) {
+	if known, ok := model.Currencies[currency.Code]; ok && currency.Symbol == "" {
+		currency.Symbol = known.Symbol
+		currency.MinorUnits = known.MinorUnits
+	}
+	if !s.validCurrency(currency) {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	currency, err := s.db.CreateCurrency(s.ctx, currency)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, currency)
+}
+
+func (s service) UpdateCurrency(currency model.Currency) ([]byte, int) {
+	stored, err := s.db.GetCurrencyByID(s.ctx, currency.CurrencyId)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	currency.Code = stored.Code
+	if !s.validCurrency(currency) {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	err = s.db.UpdateCurrency(s.ctx, currency)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, nil)
+}
+
+func (s service) DeleteCurrency(id int64) ([]byte, int) {
+	err := s.db.DeleteCurrency(s.ctx, id)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, nil)
+}
+
+func (s service) ListExchangeRate(currencyId int64, limit, skip int) ([]byte, int) {
+	_, err := s.db.GetCurrencyByID(s.ctx, currencyId)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	rates, err := s.db.GetExchangeRates(s.ctx, currencyId, limit, skip)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	listExchangeRate := model.ListExchangeRate{
+		ExchangeRates: rates,
+		Meta: model.Meta{
+			Total: len(rates),
+			Limit: limit,
+			Skip:  skip,
+		},
+	}
+	return utils.ResponseWrapper(http.StatusOK, listExchangeRate)
+}
+
+// CreateExchangeRate records a manually entered rate. It takes effect at
+// EffectiveAt, or straight away when none is given.
+func (s service) CreateExchangeRate(rate model.ExchangeRate) ([]byte, int) {
+	err := s.validation.Struct(rate)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	currency, err := s.db.GetCurrencyByID(s.ctx, rate.CurrencyId)
+	if err == sql.ErrNoRows || currency.ArchivedAt != nil {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	if rate.EffectiveAt == nil {
+		rate.EffectiveAt = &model.Timestamp{Time: time.Now().UTC().Truncate(time.Second)}
+	}
+	rate.Code = currency.Code
+	rate, err = s.db.CreateExchangeRate(s.ctx, rate)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, rate)
+}
+
+func (s service) EffectiveRate(currencyId int64, at time.Time) ([]byte, int) {
+	rate, err := s.db.GetEffectiveRate(s.ctx, currencyId, at)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, rate)
+}
+
+// TenderSummary totals the foreign currency taken in the period, per
+// currency, for reconciling the cash drawer.
+func (s service) TenderSummary(from, to *time.Time) ([]byte, int) {
+	tenders, err := s.db.GetTenderSummary(s.ctx, from, to)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	s.normalizeTenders(tenders)
+	summary := model.TenderSummary{
+		From:    from,
+		To:      to,
+		Tenders: tenders,
+	}
+	return utils.ResponseWrapper(http.StatusOK, summary)
+}
+
+// normalizeTenders writes each tendered amount to its currency's minor
+// units.
+func (s service) normalizeTenders(tenders []model.TenderTotal) {
+	for index, tender := range tenders {
+		currency, err := s.db.GetCurrencyByCode(s.ctx, tender.Currency)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		amount, err := currency.Normalize(tender.Amount)
+		if err == nil {
+			tenders[index].Amount = amount
+		}
+	}
+}
+
+func (s service) validCurrency(currency model.Currency) bool {
+	err := s.validation.Struct(currency)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+	return currency.Code != model.StoreCurrency.Code
+}
+
+// foreignTender converts a tender in a foreign currency to the store
+// currency at the rate in effect at the given time, and records the rate
+// and the amount, to the currency's minor units, on the tender.
+func (s service) foreignTender(tender *model.Tender, at time.Time) (model.Money, error) {
+	err := s.validation.Struct(tender)
+	if err != nil {
+		return 0, err
+	}
+	currency, rate, err := s.exchangeRate(tender.Currency, at)
+	if err != nil {
+		return 0, err
+	}
+	paid, err := currency.ToStore(tender.Amount.String(), rate.Rate)
+	if err != nil || paid <= 0 {
+		return 0, errInvalidTender
+	}
+	amount, _ := currency.Normalize(tender.Amount.String())
+	tender.Amount = json.Number(amount)
+	tender.Rate = rate.Rate
+	return paid, nil
+}
+
+func (s service) exchangeRate(code string, at time.Time) (model.Currency, model.ExchangeRate, error) {
+	currency, err := s.db.GetCurrencyByCode(s.ctx, code)
+	if err == sql.ErrNoRows || currency.ArchivedAt != nil {
+		return currency, model.ExchangeRate{}, errInvalidTender
+	}
+	if err != nil {
+		return currency, model.ExchangeRate{}, err
+	}
+	rate, err := s.db.GetEffectiveRate(s.ctx, currency.CurrencyId, at)
+	if err == sql.ErrNoRows {
+		return currency, rate, errInvalidTender
+	}
+	return currency, rate, err
+}
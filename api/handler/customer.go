@@ -0,0 +1,229 @@
+package handler
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/repository"
+	"github.com/saptaka/pos/utils"
+)
+
+type Customer interface {
+	ListCustomer(limit, skip int, filter model.CustomerFilter) ([]byte, int)
+	DetailCustomer(id int64) ([]byte, int)
+	CreateCustomer(customer model.Customer) ([]byte, int)
+	UpdateCustomer(customer model.Customer) ([]byte, int)
+	DeleteCustomer(id int64) ([]byte, int)
+	RestoreCustomer(id int64) ([]byte, int)
+	CustomerHistory(id int64, limit, skip int) ([]byte, int)
+}
+
+// minPhoneSearch is how many digits a phone search needs, so a stray digit
+// at the till does not list every customer.
+const minPhoneSearch = 4
+
+func (s service) ListCustomer(limit, skip int, filter model.CustomerFilter) ([]byte, int) {
+	if filter.Phone != "" {
+		// Drop the trunk prefix so a local number matches one stored with
+		// its country code.
+		filter.Phone = strings.TrimLeft(model.NormalizePhone(filter.Phone), "0")
+		if len(filter.Phone) < minPhoneSearch {
+			return utils.ResponseWrapper(http.StatusBadRequest, nil)
+		}
+	}
+	customers, err := s.db.GetCustomers(s.ctx, limit, skip, filter)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	listCustomer := model.ListCustomer{
+		Customers: customers,
+		Meta: model.Meta{
+			Total: len(customers),
+			Limit: limit,
+			Skip:  skip,
+		},
+	}
+	return utils.ResponseWrapper(http.StatusOK, listCustomer)
+}
+
+func (s service) DetailCustomer(id int64) ([]byte, int) {
+	customer, err := s.db.GetCustomerByID(s.ctx, id)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, customer)
+}
+
+// CreateCustomer adds a customer. Two active customers cannot share a
+// phone number, which is how they are found at the till.
+func (s service) CreateCustomer(customer model.Customer) ([]byte, int) {
+	customer = normalizeCustomer(customer)
+	err := s.validation.Struct(customer)
+	if err != nil {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	taken, err := s.phoneTaken(customer)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	if taken {
+		return utils.ResponseWrapper(http.StatusConflict, nil)
+	}
+	customer, err = s.db.CreateCustomer(s.ctx, customer)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, customer)
+}
+
+func (s service) UpdateCustomer(customer model.Customer) ([]byte, int) {
+	customer = normalizeCustomer(customer)
+	err := s.validation.Struct(customer)
+	if err != nil {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	taken, err := s.phoneTaken(customer)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	if taken {
+		return utils.ResponseWrapper(http.StatusConflict, nil)
+	}
+	err = s.db.UpdateCustomer(s.ctx, customer)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return s.DetailCustomer(customer.CustomerId)
+}
+
+func (s service) DeleteCustomer(id int64) ([]byte, int) {
+	err := s.db.DeleteCustomer(s.ctx, id)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, nil)
+}
+
+func (s service) RestoreCustomer(id int64) ([]byte, int) {
+	err := s.db.RestoreCustomer(s.ctx, id)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err == repository.ErrReferenced {
+		return utils.ResponseWrapper(http.StatusConflict, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, nil)
+}
+
+// CustomerHistory lists a customer's orders with what was bought in each,
+// along with how much they have spent overall.
+func (s service) CustomerHistory(id int64, limit, skip int) ([]byte, int) {
+	customer, err := s.db.GetCustomerByID(s.ctx, id)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	totalOrder, totalSpent, lastOrderAt, err := s.db.GetCustomerSpend(s.ctx, id)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	orders, err := s.db.GetCustomerOrders(s.ctx, id, limit, skip)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	history := model.CustomerHistory{
+		Customer:    customer,
+		TotalOrder:  totalOrder,
+		TotalSpent:  totalSpent,
+		LastOrderAt: lastOrderAt,
+		Orders:      make([]model.CustomerOrder, 0, len(orders)),
+		Meta: model.Meta{
+			Total: len(orders),
+			Limit: limit,
+			Skip:  skip,
+		},
+	}
+	for _, order := range orders {
+		products, err := s.db.GetOrderedProductByOrderId(s.ctx, order.OrderId)
+		if err != nil {
+			log.Println(err)
+			return utils.ResponseWrapper(http.StatusBadRequest, nil)
+		}
+		if products == nil {
+			products = make([]model.OrderedProductDetail, 0)
+		}
+		history.Orders = append(history.Orders, model.CustomerOrder{
+			Order:    order,
+			Products: products,
+		})
+	}
+	return utils.ResponseWrapper(http.StatusOK, history)
+}
+
+func normalizeCustomer(customer model.Customer) model.Customer {
+	customer.Name = strings.TrimSpace(customer.Name)
+	customer.Phone = model.NormalizePhone(customer.Phone)
+	customer.Email = strings.TrimSpace(customer.Email)
+	customer.Tags = model.NormalizeTags(customer.Tags)
+	return customer
+}
+
+// phoneTaken tells whether another active customer has the customer's
+// phone number.
+func (s service) phoneTaken(customer model.Customer) (bool, error) {
+	if customer.Phone == "" {
+		return false, nil
+	}
+	other, err := s.db.GetCustomerByPhone(s.ctx, customer.Phone)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return other.CustomerId != customer.CustomerId, nil
+}
+
+// validCustomer tells whether an order can be placed for the customer,
+// when one is given.
+func (s service) validCustomer(customerId *int64) (bool, error) {
+	if customerId == nil {
+		return true, nil
+	}
+	customer, err := s.db.GetCustomerByID(s.ctx, *customerId)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return customer.ArchivedAt == nil, nil
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/utils"
+)
+
+type Discount interface {
+	ListDiscount(limit, skip int) ([]byte, int)
+	DetailDiscount(id int64) ([]byte, int)
+	CreateDiscount(discount model.Discount) ([]byte, int)
+	UpdateDiscount(discount model.Discount) ([]byte, int)
+	DeleteDiscount(id int64) ([]byte, int)
+	AttachDiscount(productId, discountId int64) ([]byte, int)
+	DetachDiscount(productId int64) ([]byte, int)
+}
+
+func (s service) ListDiscount(limit, skip int) ([]byte, int) {
+	discounts, err := s.db.GetDiscounts(s.ctx, limit, skip)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	listDiscount := model.ListDiscount{
+		Discounts: discounts,
+		Meta: model.Meta{
+			Total: len(discounts),
+			Limit: limit,
+			Skip:  skip,
+		},
+	}
+	return utils.ResponseWrapper(http.StatusOK, listDiscount)
+}
+
+func (s service) DetailDiscount(id int64) ([]byte, int) {
+	discount, err := s.db.GetDiscountByID(s.ctx, id)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, discount)
+}
+
+func (s service) CreateDiscount(discount model.Discount) ([]byte, int) {
+	if !s.validDiscount(discount) {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	discount, err := s.db.CreateDiscount(s.ctx, discount)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, discount)
+}
+
+func (s service) UpdateDiscount(discount model.Discount) ([]byte, int) {
+	if !s.validDiscount(discount) {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	err := s.db.UpdateDiscount(s.ctx, discount)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	s.reloadProductCache()
+	return utils.ResponseWrapper(http.StatusOK, nil)
+}
+
+func (s service) DeleteDiscount(id int64) ([]byte, int) {
+	err := s.db.DeleteDiscount(s.ctx, id)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	s.reloadProductCache()
+	return utils.ResponseWrapper(http.StatusOK, nil)
+}
+
+func (s service) AttachDiscount(productId, discountId int64) ([]byte, int) {
+	err := s.db.AttachDiscount(s.ctx, productId, discountId)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return s.refreshProduct(productId)
+}
+
+func (s service) DetachDiscount(productId int64) ([]byte, int) {
+	err := s.db.DetachDiscount(s.ctx, productId)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return s.refreshProduct(productId)
+}
+
+func (s service) validDiscount(discount model.Discount) bool {
+	err := s.validation.Struct(discount)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+	if !model.DiscountType[discount.Type] || discount.Qty < 1 {
+		return false
+	}
+	if discount.Type == model.Percent &&
+		(discount.Percent <= 0 || discount.Percent > 100 || discount.Amount != 0) {
+		return false
+	}
+	if discount.Type == model.BuyN && (discount.Amount <= 0 || discount.Percent != 0) {
+		return false
+	}
+	if discount.StartedAt != nil && discount.ExpiredAt != nil &&
+		!discount.StartedAt.Before(discount.ExpiredAt.Time) {
+		return false
+	}
+	return true
+}
+
+// refreshProduct reloads a product into the cache after its discount
+// changed and returns it.
+func (s service) refreshProduct(productId int64) ([]byte, int) {
+	product, err := s.db.GetProductByID(s.ctx, productId)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	productCache.Set(product.ProductId, product)
+	return utils.ResponseWrapper(http.StatusOK, product)
+}
+
+func (s service) reloadProductCache() {
+	err := s.LoadProduct()
+	if err != nil {
+		log.Println(err)
+	}
+}
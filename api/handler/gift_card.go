@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"log"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/repository"
+	"github.com/saptaka/pos/utils"
+)
+
+type GiftCard interface {
+	ListGiftCard(limit, skip int, customerId *int64) ([]byte, int)
+	DetailGiftCard(code string, limit, skip int) ([]byte, int)
+	IssueGiftCard(request model.IssueGiftCardRequest) ([]byte, int)
+	ReloadGiftCard(code string, request model.ReloadGiftCardRequest) ([]byte, int)
+	GiftCardLiability() ([]byte, int)
+}
+
+// giftCardAlphabet leaves out the letters and digits easily mistaken for
+// one another when a code is read out or typed in.
+const giftCardAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+const giftCardCodeLength = 16
+
+func (s service) ListGiftCard(limit, skip int, customerId *int64) ([]byte, int) {
+	cards, err := s.db.GetGiftCards(s.ctx, limit, skip, customerId)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	listGiftCard := model.ListGiftCard{
+		GiftCards: cards,
+		Meta: model.Meta{
+			Total: len(cards),
+			Limit: limit,
+			Skip:  skip,
+		},
+	}
+	return utils.ResponseWrapper(http.StatusOK, listGiftCard)
+}
+
+// DetailGiftCard answers a balance inquiry with the card's ledger.
+func (s service) DetailGiftCard(code string, limit, skip int) ([]byte, int) {
+	card, err := s.db.GetGiftCardByCode(s.ctx, model.NormalizeGiftCardCode(code))
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	entries, err := s.db.GetGiftCardEntries(s.ctx, card.GiftCardId, limit, skip)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	detail := model.GiftCardDetail{
+		GiftCard: card,
+		Entries:  entries,
+		Meta: model.Meta{
+			Total: len(entries),
+			Limit: limit,
+			Skip:  skip,
+		},
+	}
+	return utils.ResponseWrapper(http.StatusOK, detail)
+}
+
+// IssueGiftCard sells a new gift card under a fresh code.
+func (s service) IssueGiftCard(request model.IssueGiftCardRequest) ([]byte, int) {
+	err := s.validation.Struct(request)
+	if err != nil {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	now := time.Now().UTC()
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(now) {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	validCustomer, err := s.validCustomer(request.CustomerId)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	if !validCustomer {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	code, err := generateGiftCardCode()
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	expiresAt := request.ExpiresAt
+	if expiresAt == nil {
+		expiresAt = expiryAfter(now, s.cfg.Store.GiftCardExpiryDays)
+	}
+	card, err := s.db.CreateGiftCard(s.ctx, model.GiftCard{
+		Code:       code,
+		Type:       model.GiftCardTypeGift,
+		CustomerId: request.CustomerId,
+		Balance:    request.Amount,
+		ExpiresAt:  expiresAt,
+	})
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, card)
+}
+
+// ReloadGiftCard tops up a gift card that has not expired. Store credit
+// cannot be reloaded.
+func (s service) ReloadGiftCard(code string, request model.ReloadGiftCardRequest) ([]byte, int) {
+	err := s.validation.Struct(request)
+	if err != nil {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	card, err := s.db.GetGiftCardByCode(s.ctx, model.NormalizeGiftCardCode(code))
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	if card.Type != model.GiftCardTypeGift {
+		return utils.ResponseWrapper(http.StatusConflict, nil)
+	}
+	err = s.db.ReloadGiftCard(s.ctx, card.GiftCardId, request.Amount, time.Now().UTC())
+	if err == repository.ErrGiftCardUnavailable {
+		return utils.ResponseWrapper(http.StatusConflict, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	card, err = s.db.GetGiftCardByID(s.ctx, card.GiftCardId)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, card)
+}
+
+// GiftCardLiability reports the balances the store still owes on its
+// gift cards and store credit.
+func (s service) GiftCardLiability() ([]byte, int) {
+	liability, err := s.db.GetGiftCardLiability(s.ctx, time.Now().UTC())
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, liability)
+}
+
+// giftCardTenders checks the cards an order is paid with and answers with
+// the tenders under their normalized codes and their total. Unknown codes
+// and cards repeated or tendered for nothing are rejected, and so are
+// cards that expired or do not hold the amount.
+func (s service) giftCardTenders(tenders []model.GiftCardTender,
+	now time.Time) ([]model.GiftCardTender, model.Money, int) {
+
+	normalized := make([]model.GiftCardTender, 0, len(tenders))
+	seen := make(map[string]bool)
+	var total model.Money
+	for _, tender := range tenders {
+		tender.Code = model.NormalizeGiftCardCode(tender.Code)
+		if tender.Amount <= 0 || seen[tender.Code] {
+			return nil, 0, http.StatusBadRequest
+		}
+		seen[tender.Code] = true
+		card, err := s.db.GetGiftCardByCode(s.ctx, tender.Code)
+		if err == sql.ErrNoRows {
+			return nil, 0, http.StatusBadRequest
+		}
+		if err != nil {
+			log.Println(err)
+			return nil, 0, http.StatusBadRequest
+		}
+		if card.Expired(now) || card.Balance < tender.Amount {
+			return nil, 0, http.StatusConflict
+		}
+		normalized = append(normalized, tender)
+		total += tender.Amount
+	}
+	return normalized, total, http.StatusOK
+}
+
+// storeCredit is the card a refund of amount to store credit issues.
+func (s service) storeCredit(order model.Order, amount model.Money) (*model.GiftCard, error) {
+	code, err := generateGiftCardCode()
+	if err != nil {
+		return nil, err
+	}
+	orderId := order.OrderId
+	return &model.GiftCard{
+		Code:       code,
+		Type:       model.GiftCardTypeCredit,
+		CustomerId: order.CustomerId,
+		OrderId:    &orderId,
+		Balance:    amount,
+		ExpiresAt:  expiryAfter(time.Now().UTC(), s.cfg.Store.StoreCreditExpiryDays),
+	}, nil
+}
+
+// generateGiftCardCode draws a random card code. Codes are long enough
+// not to be guessed, and the unique key on them catches the rare repeat.
+func generateGiftCardCode() (string, error) {
+	code := make([]byte, giftCardCodeLength)
+	max := big.NewInt(int64(len(giftCardAlphabet)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = giftCardAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// expiryAfter is days after now, or nil when days is zero and nothing
+// expires.
+func expiryAfter(now time.Time, days int) *time.Time {
+	if days <= 0 {
+		return nil
+	}
+	expiresAt := now.AddDate(0, 0, days)
+	return &expiresAt
+}
@@ -4,7 +4,11 @@ import (
 	"context"
 
 	"github.com/go-playground/validator"
+	"github.com/saptaka/pos/config"
+	"github.com/saptaka/pos/gateway"
+	"github.com/saptaka/pos/notify"
 	"github.com/saptaka/pos/repository"
+	"github.com/saptaka/pos/storage"
 )
 
 type Service interface {
@@ -12,21 +16,46 @@ type Service interface {
 	Login
 	Category
 	Product
+	ProductImport
 	Payment
 	Order
 	Report
+	Upload
+	Discount
+	Promotion
+	Coupon
+	ManualDiscount
+	Tax
+	Tip
+	Currency
+	OrderPayment
+	QRIS
+	Webhook
+	Shift
+	SalesReport
+	Customer
+	Loyalty
+	GiftCard
+	Receipt
 }
 
 type service struct {
 	ctx        context.Context
 	db         repository.Repo
 	validation *validator.Validate
+	cfg        *config.Config
+	storage    storage.Storage
+	gateways   gateway.Registry
+	notifier   notify.Notifier
+	outbox     chan struct{}
 }
 
 var productCache syncMap
 
-func NewHandler(ctx context.Context, db repository.Repo, validation *validator.Validate) Service {
-	handlerService := service{ctx, db, validation}
+func NewHandler(ctx context.Context, db repository.Repo, validation *validator.Validate,
+	cfg *config.Config, fileStorage storage.Storage, gateways gateway.Registry,
+	notifier notify.Notifier) Service {
+	handlerService := service{ctx, db, validation, cfg, fileStorage, gateways, notifier, make(chan struct{}, 1)}
 	productCache = syncMap{}
 	go func() {
 		err := handlerService.LoadProduct()
@@ -34,5 +63,6 @@ func NewHandler(ctx context.Context, db repository.Repo, validation *validator.V
 			panic(err)
 		}
 	}()
+	go handlerService.runOutbox()
 	return handlerService
 }
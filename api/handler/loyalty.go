@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/utils"
+)
+
+type Loyalty interface {
+	ListLoyaltyRule() ([]byte, int)
+	CreateLoyaltyRule(rule model.LoyaltyRule) ([]byte, int)
+	UpdateLoyaltyRule(rule model.LoyaltyRule) ([]byte, int)
+	DeleteLoyaltyRule(id int64) ([]byte, int)
+	CustomerPoints(id int64, limit, skip int) ([]byte, int)
+}
+
+func (s service) ListLoyaltyRule() ([]byte, int) {
+	rules, err := s.db.GetLoyaltyRules(s.ctx)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	listLoyaltyRule := model.ListLoyaltyRule{
+		LoyaltyRules: rules,
+		Meta: model.Meta{
+			Total: len(rules),
+		},
+	}
+	return utils.ResponseWrapper(http.StatusOK, listLoyaltyRule)
+}
+
+// CreateLoyaltyRule adds an earn rule. A category, or the store as a whole
+// when no category is given, has one active rule at most.
+func (s service) CreateLoyaltyRule(rule model.LoyaltyRule) ([]byte, int) {
+	statusCode := s.validLoyaltyRule(rule)
+	if statusCode != http.StatusOK {
+		return utils.ResponseWrapper(statusCode, nil)
+	}
+	rule, err := s.db.CreateLoyaltyRule(s.ctx, rule)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, rule)
+}
+
+func (s service) UpdateLoyaltyRule(rule model.LoyaltyRule) ([]byte, int) {
+	statusCode := s.validLoyaltyRule(rule)
+	if statusCode != http.StatusOK {
+		return utils.ResponseWrapper(statusCode, nil)
+	}
+	err := s.db.UpdateLoyaltyRule(s.ctx, rule)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	rule, err = s.db.GetLoyaltyRuleByID(s.ctx, rule.LoyaltyRuleId)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, rule)
+}
+
+func (s service) DeleteLoyaltyRule(id int64) ([]byte, int) {
+	err := s.db.DeleteLoyaltyRule(s.ctx, id)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, nil)
+}
+
+// CustomerPoints answers with a customer's points balance, what it is
+// worth, and the ledger behind it.
+func (s service) CustomerPoints(id int64, limit, skip int) ([]byte, int) {
+	_, err := s.db.GetCustomerByID(s.ctx, id)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	balance, err := s.db.GetPointsBalance(s.ctx, id)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	entries, err := s.db.GetPointsEntries(s.ctx, id, limit, skip)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	points := model.PointsBalance{
+		CustomerId: id,
+		Balance:    balance,
+		Value:      s.pointsValue(balance),
+		Entries:    entries,
+		Meta: model.Meta{
+			Total: len(entries),
+			Limit: limit,
+			Skip:  skip,
+		},
+	}
+	return utils.ResponseWrapper(http.StatusOK, points)
+}
+
+// validLoyaltyRule checks a rule's fields and category, and that no other
+// active rule covers the same category.
+func (s service) validLoyaltyRule(rule model.LoyaltyRule) int {
+	err := s.validation.Struct(rule)
+	if err != nil {
+		return http.StatusBadRequest
+	}
+	if rule.CategoryId != nil {
+		category, err := s.db.GetCategoryByID(s.ctx, *rule.CategoryId)
+		if err == sql.ErrNoRows || category.ArchivedAt != nil {
+			return http.StatusBadRequest
+		}
+		if err != nil {
+			log.Println(err)
+			return http.StatusBadRequest
+		}
+	}
+	rules, err := s.db.GetLoyaltyRules(s.ctx)
+	if err != nil {
+		log.Println(err)
+		return http.StatusBadRequest
+	}
+	for _, other := range rules {
+		if other.LoyaltyRuleId != rule.LoyaltyRuleId && sameCategory(other.CategoryId, rule.CategoryId) {
+			return http.StatusConflict
+		}
+	}
+	return http.StatusOK
+}
+
+func sameCategory(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// pointsValue is what points are worth as a tender.
+func (s service) pointsValue(points int64) model.Money {
+	return model.Money(points * s.cfg.Store.LoyaltyPointValue)
+}
+
+// earnPoints works out the points an order's lines earn by the active
+// rules. Spend is summed per category before the rule is applied, and
+// lines sold below their normal price earn nothing.
+func (s service) earnPoints(details []model.SubOrderedProductDetail) (int64, error) {
+	rules, err := s.db.GetLoyaltyRules(s.ctx)
+	if err != nil {
+		return 0, err
+	}
+	var fallback *model.LoyaltyRule
+	byCategory := make(map[int64]model.LoyaltyRule)
+	for index, rule := range rules {
+		if rule.CategoryId == nil {
+			fallback = &rules[index]
+			continue
+		}
+		byCategory[*rule.CategoryId] = rule
+	}
+
+	byRule := make(map[int64]model.LoyaltyRule)
+	spend := make(map[int64]model.Money)
+	for _, detail := range details {
+		if detail.TotalFinalPrice < detail.TotalNormalPrice {
+			continue
+		}
+		rule, ok := model.LoyaltyRule{}, false
+		if detail.CategoryId != nil {
+			rule, ok = byCategory[*detail.CategoryId]
+		}
+		if !ok && fallback != nil {
+			rule, ok = *fallback, true
+		}
+		if !ok {
+			continue
+		}
+		byRule[rule.LoyaltyRuleId] = rule
+		spend[rule.LoyaltyRuleId] += detail.TotalFinalPrice
+	}
+
+	var points int64
+	for id, amount := range spend {
+		points += int64(amount/byRule[id].Spend) * byRule[id].Points
+	}
+	return points, nil
+}
+
+// pointsExpiry is when points earned now expire, or nil when they never do.
+func (s service) pointsExpiry(now time.Time) *time.Time {
+	return expiryAfter(now, s.cfg.Store.LoyaltyExpiryDays)
+}
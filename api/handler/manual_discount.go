@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/utils"
+)
+
+type ManualDiscount interface {
+	DiscountAudit(from, to *time.Time) ([]byte, int)
+}
+
+// DiscountAudit lists the manual discounts given in the period with who
+// approved them.
+func (s service) DiscountAudit(from, to *time.Time) ([]byte, int) {
+	discounts, err := s.db.GetDiscountAudit(s.ctx, from, to)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	audit := model.DiscountAudit{
+		Total:     len(discounts),
+		Discounts: discounts,
+	}
+	for _, discount := range discounts {
+		audit.TotalAmount += discount.Amount
+		if discount.ApprovedBy != nil {
+			audit.Approved++
+		}
+	}
+	return utils.ResponseWrapper(http.StatusOK, audit)
+}
+
+// applyManualDiscounts takes the requested manual discounts off the priced
+// lines, after promotions, and returns them with the amount taken off the
+// order as a whole. Line discounts are keyed by product; the first one
+// given for a product is used.
+func applyManualDiscounts(details []model.SubOrderedProductDetail,
+	requested []model.OrderedProduct, orderDiscount *model.ManualDiscount,
+	basketDiscount model.Money) ([]model.OrderDiscount, model.Money) {
+
+	lineDiscounts := make(map[int64]*model.ManualDiscount)
+	for _, item := range requested {
+		if item.ManualDiscount == nil {
+			continue
+		}
+		if _, ok := lineDiscounts[item.ProductId]; !ok {
+			lineDiscounts[item.ProductId] = item.ManualDiscount
+		}
+	}
+
+	discounts := make([]model.OrderDiscount, 0)
+	total := -basketDiscount
+	for index, detail := range details {
+		if discount, ok := lineDiscounts[detail.ProductId]; ok {
+			amount := manualAmount(*discount, detail.TotalFinalPrice)
+			details[index].TotalFinalPrice -= amount
+			productId := detail.ProductId
+			discounts = append(discounts, model.OrderDiscount{
+				ProductId: &productId,
+				Type:      discount.Type,
+				Percent:   discount.Percent,
+				Amount:    amount,
+				Reason:    discount.Reason,
+			})
+		}
+		total += details[index].TotalFinalPrice
+	}
+
+	var orderAmount model.Money
+	if orderDiscount != nil {
+		orderAmount = manualAmount(*orderDiscount, total)
+		discounts = append(discounts, model.OrderDiscount{
+			Type:    orderDiscount.Type,
+			Percent: orderDiscount.Percent,
+			Amount:  orderAmount,
+			Reason:  orderDiscount.Reason,
+		})
+	}
+	return discounts, orderAmount
+}
+
+func (s service) validManualDiscounts(requested []model.OrderedProduct,
+	orderDiscount *model.ManualDiscount) bool {
+	for _, item := range requested {
+		if item.ManualDiscount != nil && !s.validManualDiscount(*item.ManualDiscount) {
+			return false
+		}
+	}
+	return orderDiscount == nil || s.validManualDiscount(*orderDiscount)
+}
+
+func (s service) validManualDiscount(discount model.ManualDiscount) bool {
+	err := s.validation.Struct(discount)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+	switch discount.Type {
+	case model.ValueAmount:
+		return discount.Amount > 0 && discount.Percent == 0
+	case model.ValuePercent:
+		return discount.Amount == 0 && discount.Percent > 0 && discount.Percent <= 100
+	}
+	return false
+}
+
+func manualAmount(discount model.ManualDiscount, base model.Money) model.Money {
+	amount := discount.Amount
+	if discount.Type == model.ValuePercent {
+		amount = base.Percent(float64(discount.Percent))
+	}
+	if amount > base {
+		amount = base
+	}
+	if amount < 0 {
+		amount = 0
+	}
+	return amount
+}
+
+func (s service) approvalRequired(discounts []model.OrderDiscount) bool {
+	var total model.Money
+	for _, discount := range discounts {
+		total += discount.Amount
+	}
+	return total > model.Money(s.cfg.Store.ManualDiscountLimit)
+}
+
+// approveManualDiscounts checks the manager approval when the discounts go
+// over the store limit and records the approver on them.
+func (s service) approveManualDiscounts(discounts []model.OrderDiscount,
+	approval *model.ManagerApproval) bool {
+
+	if !s.approvalRequired(discounts) {
+		return true
+	}
+	if approval == nil || s.validation.Struct(approval) != nil {
+		return false
+	}
+	manager, err := s.db.GetCashierByID(s.ctx, approval.ManagerId)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Println(err)
+		}
+		return false
+	}
+	if manager.Role != model.RoleManager || manager.ArchivedAt != nil {
+		return false
+	}
+	passcode, err := s.db.GetPasscodeById(s.ctx, manager.CashierId)
+	if err != nil || passcode != approval.Passcode {
+		return false
+	}
+	for index := range discounts {
+		discounts[index].ApprovedBy = &manager.CashierId
+	}
+	return true
+}
+
+func approvalError() model.ErrorData {
+	return model.ErrorData{
+		Message: "\"approval\" is required for manual discounts above the limit",
+		Path:    []string{"approval"},
+		Type:    "any.required",
+		Context: model.ErrorContext{
+			Label: "approval",
+			Value: nil,
+		},
+	}
+}
@@ -1,22 +1,24 @@
 package handler
 
 import (
-	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 	"math/rand"
 	"net/http"
 	"time"
 
 	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/repository"
 	"github.com/saptaka/pos/utils"
 )
 
 type Order interface {
 	ListOrder(limit, skip int) ([]byte, int)
 	DetailOrder(id int64, receiptId string) ([]byte, int)
-	SubTotalOrder(orderRequest []model.OrderedProduct) ([]byte, int)
+	SubTotalOrder(orderRequest model.SubTotalRequest) ([]byte, int)
 	AddOrder(product model.AddOrderRequest) ([]byte, int)
 	DownloadOrder(id int64) ([]byte, int)
 	CheckOrderDownload(id int64) ([]byte, int)
@@ -102,53 +104,302 @@ func (s service) DetailOrder(id int64, receiptId string) ([]byte, int) {
 		return utils.ResponseWrapper(http.StatusBadRequest, nil)
 	}
 
+	promotions, err := s.db.GetOrderPromotions(s.ctx, order.OrderId)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+
+	coupons, err := s.db.GetCouponRedemptionsByOrderId(s.ctx, order.OrderId)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+
+	discounts, err := s.db.GetOrderDiscounts(s.ctx, order.OrderId)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+
+	taxes, err := s.orderTaxes(orderedProducts)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+
 	orderDetails := model.OrderDetails{
 		Order:          order,
 		OrderedProduct: orderedProducts,
+		Promotions:     promotions,
+		Coupons:        coupons,
+		Discounts:      discounts,
+		Taxes:          taxes,
 	}
 
 	return utils.ResponseWrapper(http.StatusOK, orderDetails)
 }
 
-func (s service) SubTotalOrder(orderRequest []model.OrderedProduct) ([]byte, int) {
+func (s service) SubTotalOrder(orderRequest model.SubTotalRequest) ([]byte, int) {
+
+	if orderRequest.Tip < 0 ||
+		!s.validManualDiscounts(orderRequest.OrderedProduct, orderRequest.ManualDiscount) {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	var payment model.Payment
+	if orderRequest.PaymentID != nil {
+		var err error
+		payment, err = s.db.GetPaymentByID(s.ctx, *orderRequest.PaymentID)
+		if err != nil {
+			log.Println(err)
+			return utils.ResponseWrapper(http.StatusBadRequest, nil)
+		}
+	}
+	now := time.Now()
+	var currency model.Currency
+	var rate model.ExchangeRate
+	if orderRequest.Currency != "" {
+		var err error
+		currency, rate, err = s.exchangeRate(orderRequest.Currency, now)
+		if err != nil {
+			log.Println(err)
+			return utils.ResponseWrapper(http.StatusBadRequest, nil)
+		}
+	}
+	coupons, unlocked, err := s.resolveCoupons(orderRequest.Coupons, orderRequest.CustomerId, now)
+	if invalid, ok := err.(couponError); ok {
+		return utils.ResponseWrapper(http.StatusBadRequest, invalid.data())
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
 
-	orderedProductDetails, totalPrice, err := s.generateSubOrderedProduct(orderRequest)
+	orderedProductDetails, err := s.generateSubOrderedProduct(orderRequest.OrderedProduct)
+	if invalid, ok := err.(lineError); ok {
+		return utils.ResponseWrapper(http.StatusBadRequest, invalid.data())
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	promotions, err := s.applyPromotions(orderedProductDetails, now, unlocked)
 	if err != nil {
 		log.Println(err)
 		return utils.ResponseWrapper(http.StatusBadRequest, nil)
 	}
+	discounts, manualDiscount := applyManualDiscounts(orderedProductDetails,
+		orderRequest.OrderedProduct, orderRequest.ManualDiscount, promotions.BasketDiscount)
+	taxes, totalTax, err := s.applyTaxes(orderedProductDetails, promotions.BasketDiscount+manualDiscount)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	var subtotal model.Money
+	for _, detail := range orderedProductDetails {
+		subtotal += detail.TotalFinalPrice
+	}
+	applied := promotions.Applied
+	if applied == nil {
+		applied = make([]model.AppliedPromotion, 0)
+	}
+	appliedCoupons := make([]string, 0)
+	for _, redemption := range couponRedemptions(coupons, promotions, orderRequest.CustomerId) {
+		appliedCoupons = append(appliedCoupons, redemption.Code)
+	}
+	total := subtotal - promotions.BasketDiscount - manualDiscount + exclusiveTax(s.cfg.Store.TaxInclusive, totalTax)
+	serviceCharge := s.serviceCharge(total, totalTax)
+	amountDue := total + serviceCharge + orderRequest.Tip
+	rounding := payment.Round(amountDue) - amountDue
+	var tender *model.Tender
+	if orderRequest.Currency != "" {
+		tender = &model.Tender{
+			Currency: currency.Code,
+			Amount:   json.Number(currency.FromStore(amountDue+rounding, rate.Rate)),
+			Rate:     rate.Rate,
+		}
+	}
 	subTotalOrder := model.SubTotalOrder{
-		Subtotal:       totalPrice,
-		OrderedProduct: orderedProductDetails,
+		Subtotal:         subtotal,
+		Discount:         promotions.BasketDiscount,
+		ManualDiscount:   manualDiscount,
+		Tax:              totalTax,
+		TaxInclusive:     s.cfg.Store.TaxInclusive,
+		Total:            total,
+		ServiceCharge:    serviceCharge,
+		Tip:              orderRequest.Tip,
+		Rounding:         rounding,
+		AmountDue:        amountDue + rounding,
+		Tender:           tender,
+		OrderedProduct:   orderedProductDetails,
+		Promotions:       applied,
+		Coupons:          appliedCoupons,
+		Discounts:        discounts,
+		Taxes:            taxes,
+		ApprovalRequired: s.approvalRequired(discounts),
 	}
 	return utils.ResponseWrapper(http.StatusOK, subTotalOrder)
 }
 
 func (s service) AddOrder(orderRequest model.AddOrderRequest) ([]byte, int) {
 
-	var totalPrice int
-	subOrderedProductDetails, totalPrice, err := s.generateSubOrderedProduct(orderRequest.OrderedProduct)
+	payment, err := s.db.GetPaymentByID(s.ctx, orderRequest.PaymentID)
+	if err == sql.ErrNoRows || payment.ArchivedAt != nil {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+
+	provider, online := s.gateways.Get(payment.Provider)
+	// Every sale is booked to its cashier's open shift, so it needs one.
+	if orderRequest.CashierID == nil || orderRequest.Tip < 0 || (online && orderRequest.Tender != nil) ||
+		orderRequest.RedeemPoints < 0 || (orderRequest.RedeemPoints > 0 && orderRequest.CustomerId == nil) ||
+		!s.validManualDiscounts(orderRequest.OrderedProduct, orderRequest.ManualDiscount) {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	validCashier, err := s.validCashier(orderRequest.CashierID)
 	if err != nil {
 		log.Println(err)
 		return utils.ResponseWrapper(http.StatusBadRequest, nil)
 	}
+	if !validCashier {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	validCustomer, err := s.validCustomer(orderRequest.CustomerId)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	if !validCustomer {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	var receiptRecipients []model.ReceiptMessage
+	if orderRequest.Receipt != nil {
+		var statusCode int
+		receiptRecipients, statusCode = s.receiptRecipients(*orderRequest.Receipt, orderRequest.CustomerId)
+		if statusCode != http.StatusOK {
+			return utils.ResponseWrapper(statusCode, nil)
+		}
+	}
 
 	now, _ := time.Parse(model.RFC3339MilliZ, time.Now().UTC().Format(model.RFC3339MilliZ))
-	order := model.Order{
-		PaymentID:   &orderRequest.PaymentID,
-		TotalPaid:   orderRequest.TotalPaid,
-		TotalPrice:  totalPrice,
-		TotalReturn: orderRequest.TotalPaid - totalPrice,
-		CreatedAt:   &now,
-		UpdatedAt:   &now,
-		ReceiptID:   s.generateOrderID(),
+	totalPaid := orderRequest.TotalPaid
+	if orderRequest.Tender != nil {
+		totalPaid, err = s.foreignTender(orderRequest.Tender, now)
+		if err != nil {
+			log.Println(err)
+			return utils.ResponseWrapper(http.StatusBadRequest, nil)
+		}
+	}
+	coupons, unlocked, err := s.resolveCoupons(orderRequest.Coupons, orderRequest.CustomerId, now)
+	if invalid, ok := err.(couponError); ok {
+		return utils.ResponseWrapper(http.StatusBadRequest, invalid.data())
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
 	}
 
-	order, err = s.db.CreateOrder(s.ctx, order)
+	subOrderedProductDetails, err := s.generateSubOrderedProduct(orderRequest.OrderedProduct)
+	if invalid, ok := err.(lineError); ok {
+		return utils.ResponseWrapper(http.StatusBadRequest, invalid.data())
+	}
 	if err != nil {
 		log.Println(err)
 		return utils.ResponseWrapper(http.StatusBadRequest, nil)
 	}
+
+	promotions, err := s.applyPromotions(subOrderedProductDetails, now, unlocked)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	discounts, manualDiscount := applyManualDiscounts(subOrderedProductDetails,
+		orderRequest.OrderedProduct, orderRequest.ManualDiscount, promotions.BasketDiscount)
+	if !s.approveManualDiscounts(discounts, orderRequest.Approval) {
+		return utils.ResponseWrapper(http.StatusForbidden, approvalError())
+	}
+
+	taxes, totalTax, err := s.applyTaxes(subOrderedProductDetails, promotions.BasketDiscount+manualDiscount)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+
+	var totalPrice model.Money
+	for _, detail := range subOrderedProductDetails {
+		totalPrice += detail.TotalFinalPrice
+	}
+	totalPrice -= promotions.BasketDiscount + manualDiscount
+	totalPrice += exclusiveTax(s.cfg.Store.TaxInclusive, totalTax)
+	serviceCharge := s.serviceCharge(totalPrice, totalTax)
+	amountDue := totalPrice + serviceCharge + orderRequest.Tip
+
+	// Redeemed points and gift cards tender part of the amount due, and the rest is paid
+	// with the order's payment.
+	pointsAmount := s.pointsValue(orderRequest.RedeemPoints)
+	giftCards, giftCardAmount, statusCode := s.giftCardTenders(orderRequest.GiftCards, now)
+	if statusCode != http.StatusOK {
+		return utils.ResponseWrapper(statusCode, nil)
+	}
+	if pointsAmount+giftCardAmount > amountDue {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	payable := amountDue - pointsAmount - giftCardAmount
+	rounding := payment.Round(payable) - payable
+	online = online && payable+rounding > 0
+
+	var pointsEarned int64
+	if orderRequest.CustomerId != nil {
+		pointsEarned, err = s.earnPoints(subOrderedProductDetails)
+		if err != nil {
+			log.Println(err)
+			return utils.ResponseWrapper(http.StatusBadRequest, nil)
+		}
+	}
+
+	var totalManualDiscount model.Money
+	for _, discount := range discounts {
+		totalManualDiscount += discount.Amount
+	}
+
+	order := model.Order{
+		PaymentID:      &orderRequest.PaymentID,
+		CashierID:      orderRequest.CashierID,
+		CustomerId:     orderRequest.CustomerId,
+		TotalPaid:      totalPaid,
+		TotalPrice:     totalPrice,
+		TotalDiscount:  promotions.LineDiscount + promotions.BasketDiscount,
+		ManualDiscount: totalManualDiscount,
+		TotalTax:       totalTax,
+		TaxInclusive:   s.cfg.Store.TaxInclusive,
+		ServiceCharge:  serviceCharge,
+		Tip:            orderRequest.Tip,
+		Rounding:       rounding,
+		TotalReturn:    totalPaid - payable - rounding,
+		PointsEarned:   pointsEarned,
+		PointsRedeemed: orderRequest.RedeemPoints,
+		PointsAmount:   pointsAmount,
+		PointsExpireAt: s.pointsExpiry(now),
+		GiftCardAmount: giftCardAmount,
+		GiftCards:      giftCards,
+		Tender:         orderRequest.Tender,
+		Status:         model.OrderPaid,
+		CreatedAt:      &now,
+		UpdatedAt:      &now,
+		ReceiptID:      s.generateOrderID(),
+	}
+	if online {
+		// The provider takes the exact amount due, and the order waits
+		// for it to confirm the payment.
+		order.Status = model.OrderPendingPayment
+		order.PaymentProvider = payment.Provider
+		order.TotalPaid = payable + rounding
+		order.TotalReturn = 0
+	}
+
 	var orderedProductDetails []model.OrderedProductDetail
 	for _, subOderedProductDetail := range subOrderedProductDetails {
 		orderedProductDetail := model.OrderedProductDetail{
@@ -156,26 +407,63 @@ func (s service) AddOrder(orderRequest model.AddOrderRequest) ([]byte, int) {
 			Name:             subOderedProductDetail.Name,
 			Price:            subOderedProductDetail.Price,
 			Qty:              subOderedProductDetail.Qty,
+			Unit:             subOderedProductDetail.Unit,
+			QtyFormat:        subOderedProductDetail.QtyFormat,
+			CostPrice:        subOderedProductDetail.CostPrice,
+			TotalCostPrice:   linePrice(subOderedProductDetail.CostPrice, subOderedProductDetail.Qty),
 			Discount:         subOderedProductDetail.Discount,
+			DiscountId:       subOderedProductDetail.DiscountId,
 			TotalFinalPrice:  subOderedProductDetail.TotalFinalPrice,
 			TotalNormalPrice: subOderedProductDetail.TotalNormalPrice,
+			TaxClassId:       subOderedProductDetail.TaxClassId,
+			TaxRate:          subOderedProductDetail.TaxRate,
+			TaxableAmount:    subOderedProductDetail.TaxableAmount,
+			TaxAmount:        subOderedProductDetail.TaxAmount,
 		}
 		orderedProductDetails = append(orderedProductDetails, orderedProductDetail)
 	}
 
-	orders := model.OrderDetails{
+	redemptions := couponRedemptions(coupons, promotions, orderRequest.CustomerId)
+	order, err = s.db.CreateOrder(s.ctx, model.OrderDetails{
 		Order:          order,
 		OrderedProduct: orderedProductDetails,
+		Promotions:     promotions.Applied,
+		Coupons:        redemptions,
+		Discounts:      discounts,
+	})
+	if err == repository.ErrCouponUnavailable || err == repository.ErrNoOpenShift ||
+		err == repository.ErrInsufficientPoints || err == repository.ErrGiftCardUnavailable ||
+		err == repository.ErrOutOfStock {
+		return utils.ResponseWrapper(http.StatusConflict, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	takeCachedStock(orderedProductDetails)
+	statusCode = http.StatusOK
+	if online {
+		order, statusCode = s.chargeOrder(provider, order, payment.Type)
 	}
 
-	go func() {
-		err = s.db.CreateOrderedProduct(context.Background(), order.OrderId, orderedProductDetails)
+	orders := model.OrderDetails{
+		Order:          order,
+		OrderedProduct: orderedProductDetails,
+		Promotions:     promotions.Applied,
+		Coupons:        redemptions,
+		Discounts:      discounts,
+		Taxes:          taxes,
+	}
+	// An order still waiting for its payment gets its receipt once paid,
+	// through SendReceipt.
+	if receiptRecipients != nil && order.Status == model.OrderPaid {
+		orders.Receipts, err = s.queueReceipt(order, orderedProductDetails, receiptRecipients)
 		if err != nil {
 			log.Println(err)
 		}
-	}()
+	}
 
-	return utils.ResponseWrapper(http.StatusOK, orders)
+	return utils.ResponseWrapper(statusCode, orders)
 }
 
 func (s service) DownloadOrder(id int64) ([]byte, int) {
@@ -198,15 +486,23 @@ func (s service) CheckOrderDownload(id int64) ([]byte, int) {
 	return utils.ResponseWrapper(http.StatusOK, isDownloadedJson)
 }
 
-func (s service) calculatePrice(discount model.Discount, price, qty int) int {
-	var finalPrice int
+// linePrice prices qty units of a product, rounding to the minor unit with
+// the store currency's rounding mode so fractional quantities are
+// deterministic.
+func linePrice(price model.Money, qty float64) model.Money {
+	return price.Mul(qty)
+}
+
+func (s service) calculatePrice(discount model.Discount, price model.Money, qty float64) model.Money {
+	var finalPrice model.Money
 	if discount.Type == "PERCENT" {
-		normalPrice := price * qty
-		discountPrice := normalPrice * discount.Result / 100
+		normalPrice := linePrice(price, qty)
+		discountPrice := normalPrice.Percent(float64(discount.Percent))
 		finalPrice = normalPrice - discountPrice
 	} else {
-		if qty >= discount.Qty {
-			finalPrice = qty*price - discount.Qty*discount.Result
+		finalPrice = linePrice(price, qty)
+		if qty >= float64(discount.Qty) {
+			finalPrice -= discount.Amount * model.Money(discount.Qty)
 		}
 	}
 
@@ -214,10 +510,10 @@ func (s service) calculatePrice(discount model.Discount, price, qty int) int {
 }
 
 func (s service) generateSubOrderedProduct(
-	orderRequest []model.OrderedProduct) ([]model.SubOrderedProductDetail, int, error) {
-	var totalPrice int
+	orderRequest []model.OrderedProduct) ([]model.SubOrderedProductDetail, error) {
 	var orderedProductDetails []model.SubOrderedProductDetail
 	mapOrderedProduct := make(map[int64]int)
+	now := time.Now()
 	for index, productItem := range orderRequest {
 
 		var product model.Product
@@ -228,31 +524,44 @@ func (s service) generateSubOrderedProduct(
 		} else {
 			product, err = s.db.GetProductByID(s.ctx, productItem.ProductId)
 			if err == sql.ErrNoRows {
-				continue
+				return nil, lineError{index, productItem, "is not a known product"}
 			}
 			if err != nil {
 				log.Println(err)
-				return orderedProductDetails, totalPrice, err
+				return orderedProductDetails, err
 			}
 		}
 
-		if product.Stock < productItem.Qty {
-			continue
+		if product.ArchivedAt != nil {
+			return nil, lineError{index, productItem, "is no longer sold"}
 		}
-		product.Stock = product.Stock - productItem.Qty
 
-		err = s.db.UpdateProduct(s.ctx, product)
-		if err != nil {
-			log.Printf("error update product in order process %d : %s",
-				product.ProductId, err)
+		if product.Unit == "" {
+			product.Unit = model.UnitPcs
+		}
+		if productItem.Qty <= 0 ||
+			(product.Unit == model.UnitPcs && productItem.Qty != math.Trunc(productItem.Qty)) {
+			return nil, lineError{index, productItem, "has a quantity the unit cannot be sold in"}
 		}
 
-		productCache.Set(product.ProductId, product)
+		// Stock is only checked here; an order takes it when it is stored.
+		var taken float64
+		if orderIndex, ok := mapOrderedProduct[product.ProductId]; ok {
+			taken = orderedProductDetails[orderIndex].Qty
+		}
+		if product.Stock < taken+productItem.Qty {
+			return nil, lineError{index, productItem, "is out of stock"}
+		}
+
+		var discount *model.Discount
+		if product.Discount != nil && product.Discount.ActiveAt(now) {
+			discount = product.Discount
+		}
 
-		var finalPrice int
-		normalPrice := product.Price * productItem.Qty
-		if product.DiscountId != nil {
-			finalPrice = s.calculatePrice(*product.Discount,
+		var finalPrice model.Money
+		normalPrice := linePrice(product.Price, productItem.Qty)
+		if discount != nil {
+			finalPrice = s.calculatePrice(*discount,
 				product.Price,
 				productItem.Qty)
 		} else {
@@ -263,35 +572,77 @@ func (s service) generateSubOrderedProduct(
 			orderedProductDetails[orderIndex].Qty += productItem.Qty
 			orderedProductDetails[orderIndex].TotalFinalPrice += finalPrice
 			orderedProductDetails[orderIndex].TotalNormalPrice += normalPrice
-			orderedProductDetails[orderIndex].Stock = product.Stock
-			totalPrice += finalPrice
+			orderedProductDetails[orderIndex].Stock = product.Stock - orderedProductDetails[orderIndex].Qty
+			orderedProductDetails[orderIndex].QtyFormat = utils.FormatUnitPrice(
+				orderedProductDetails[orderIndex].Qty, product.Unit, product.Price)
 			continue
 		}
 
-		var discount *model.Discount
-		if product.Discount != nil {
-			discount = product.Discount
-		}
-
-		totalPrice += finalPrice
 		orderedProductDetail := model.SubOrderedProductDetail{
 			Product: model.Product{
-				ProductId: product.ProductId,
-				Name:      product.Name,
-				Price:     product.Price,
-				Discount:  discount,
-				Stock:     product.Stock,
-				Image:     product.Image,
+				ProductId:  product.ProductId,
+				Name:       product.Name,
+				Price:      product.Price,
+				CostPrice:  product.CostPrice,
+				Unit:       product.Unit,
+				CategoryId: product.CategoryId,
+				TaxClassId: taxClassId(product),
+				Discount:   discount,
+				DiscountId: discountId(discount),
+				Stock:      product.Stock - productItem.Qty,
+				Image:      product.Image,
 			},
 			Qty:              productItem.Qty,
+			QtyFormat:        utils.FormatUnitPrice(productItem.Qty, product.Unit, product.Price),
 			TotalFinalPrice:  finalPrice,
 			TotalNormalPrice: normalPrice,
 		}
+		mapOrderedProduct[product.ProductId] = len(orderedProductDetails)
 		orderedProductDetails = append(orderedProductDetails, orderedProductDetail)
-		mapOrderedProduct[product.ProductId] = index
 	}
 
-	return orderedProductDetails, totalPrice, nil
+	return orderedProductDetails, nil
+}
+
+// lineError tells which ordered line cannot be sold and why.
+type lineError struct {
+	index  int
+	line   model.OrderedProduct
+	reason string
+}
+
+func (e lineError) Error() string {
+	return fmt.Sprintf("product %d %s", e.line.ProductId, e.reason)
+}
+
+func (e lineError) data() model.ErrorData {
+	return model.ErrorData{
+		Message: fmt.Sprintf("\"products[%d]\" %s", e.index, e.reason),
+		Path:    []string{"products", fmt.Sprint(e.index)},
+		Type:    "any.invalid",
+		Context: model.ErrorContext{
+			Label: "products",
+			Value: e.line,
+		},
+	}
+}
+
+// takeCachedStock takes the quantities of a stored order off the cached
+// products, as CreateOrder did in the database.
+func takeCachedStock(products []model.OrderedProductDetail) {
+	for _, item := range products {
+		if product, ok := productCache.Get(item.ProductId); ok {
+			product.Stock -= item.Qty
+			productCache.Set(product.ProductId, product)
+		}
+	}
+}
+
+func discountId(discount *model.Discount) *int64 {
+	if discount == nil {
+		return nil
+	}
+	return &discount.DiscountID
 }
 
 func (s service) generateOrderID() string {
@@ -0,0 +1,322 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/saptaka/pos/gateway"
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/repository"
+	"github.com/saptaka/pos/utils"
+)
+
+type OrderPayment interface {
+	PaymentStatus(id int64) ([]byte, int)
+	VoidOrder(id int64) ([]byte, int)
+	RefundOrder(id int64, request model.RefundRequest) ([]byte, int)
+}
+
+// PaymentStatus asks the provider how the payment of a pending order
+// ended and settles the order accordingly. Orders that are not pending are
+// returned as they are.
+func (s service) PaymentStatus(id int64) ([]byte, int) {
+	order, provider, statusCode := s.providerOrder(id)
+	if statusCode != http.StatusOK {
+		return utils.ResponseWrapper(statusCode, nil)
+	}
+	if order.Status != model.OrderPendingPayment || order.PaymentReference == "" {
+		return utils.ResponseWrapper(http.StatusOK, order)
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, s.paymentTimeout())
+	defer cancel()
+	transaction, err := provider.Status(ctx, order.PaymentReference)
+	if err == nil && transaction.Status == gateway.StatusAuthorized {
+		transaction, err = provider.Capture(ctx, transaction.Reference, order.TotalPaid)
+	}
+	order, statusCode = s.settleOrder(order, transaction, err)
+	return utils.ResponseWrapper(statusCode, order)
+}
+
+// VoidOrder cancels the payment of a pending or paid order at its
+// provider. A pending order the provider never answered for is voided
+// here only.
+func (s service) VoidOrder(id int64) ([]byte, int) {
+	order, provider, statusCode := s.providerOrder(id)
+	if statusCode != http.StatusOK {
+		return utils.ResponseWrapper(statusCode, nil)
+	}
+	from := []string{model.OrderPendingPayment, model.OrderPaid}
+	if order.Status != model.OrderPendingPayment && order.Status != model.OrderPaid {
+		return utils.ResponseWrapper(http.StatusConflict, nil)
+	}
+	if order.PaymentReference != "" {
+		ctx, cancel := context.WithTimeout(s.ctx, s.paymentTimeout())
+		defer cancel()
+		_, err := provider.Void(ctx, order.PaymentReference)
+		if err != nil {
+			log.Println(err)
+			return utils.ResponseWrapper(http.StatusBadRequest, nil)
+		}
+	}
+	order.Status = model.OrderVoided
+	return s.updateOrderStatus(order, from)
+}
+
+// RefundOrder gives the whole amount of a paid order back through its
+// provider, or as store credit when asked to.
+func (s service) RefundOrder(id int64, request model.RefundRequest) ([]byte, int) {
+	if request.StoreCredit {
+		return s.refundStoreCredit(id)
+	}
+	order, provider, statusCode := s.providerOrder(id)
+	if statusCode != http.StatusOK {
+		return utils.ResponseWrapper(statusCode, nil)
+	}
+	from := []string{model.OrderPaid}
+	if order.Status != model.OrderPaid {
+		return utils.ResponseWrapper(http.StatusConflict, nil)
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, s.paymentTimeout())
+	defer cancel()
+	_, err := provider.Refund(ctx, order.PaymentReference, order.TotalPaid)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	order.Status = model.OrderRefunded
+	return s.updateOrderStatus(order, from)
+}
+
+// refundStoreCredit refunds a paid order, however it was paid, by issuing
+// what was paid for it as store credit. What it took from gift cards goes
+// back on them.
+func (s service) refundStoreCredit(id int64) ([]byte, int) {
+	order, err := s.db.GetOrderByID(s.ctx, id)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	if order.Status != model.OrderPaid {
+		return utils.ResponseWrapper(http.StatusConflict, nil)
+	}
+	if amount := order.TotalPaid - order.TotalReturn; amount > 0 {
+		order.StoreCredit, err = s.storeCredit(order, amount)
+		if err != nil {
+			log.Println(err)
+			return utils.ResponseWrapper(http.StatusBadRequest, nil)
+		}
+	}
+	order.Status = model.OrderRefunded
+	return s.updateOrderStatus(order, []string{model.OrderPaid})
+}
+
+// providerOrder loads an order paid through a payment provider along with
+// the provider.
+func (s service) providerOrder(id int64) (model.Order, gateway.Provider, int) {
+	order, err := s.db.GetOrderByID(s.ctx, id)
+	if err == sql.ErrNoRows {
+		return order, nil, http.StatusNotFound
+	}
+	if err != nil {
+		log.Println(err)
+		return order, nil, http.StatusBadRequest
+	}
+	provider, ok := s.gateways.Get(order.PaymentProvider)
+	if !ok {
+		return order, nil, http.StatusConflict
+	}
+	return order, provider, http.StatusOK
+}
+
+func (s service) updateOrderStatus(order model.Order, from []string) ([]byte, int) {
+	err := s.db.UpdateOrderStatus(s.ctx, order.OrderId, from, order)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusConflict, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	s.reloadOrderedProducts(order.OrderId)
+	return utils.ResponseWrapper(http.StatusOK, order)
+}
+
+// reloadOrderedProducts refreshes the cached products of an order after
+// its stock was put back or taken again.
+func (s service) reloadOrderedProducts(orderId int64) {
+	products, err := s.db.GetOrderedProductByOrderId(s.ctx, orderId)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	for _, item := range products {
+		product, err := s.db.GetProductByID(s.ctx, item.ProductId)
+		if err != nil {
+			log.Println(err)
+			productCache.Delete(item.ProductId)
+			continue
+		}
+		productCache.Set(product.ProductId, product)
+	}
+}
+
+// chargeOrder takes the payment of a new order through its provider:
+// the amount is authorized and captured straight away.
+func (s service) chargeOrder(provider gateway.Provider, order model.Order, method string) (model.Order, int) {
+	ctx, cancel := context.WithTimeout(s.ctx, s.paymentTimeout())
+	defer cancel()
+	transaction, err := provider.Authorize(ctx, gateway.Request{
+		Reference: order.ReceiptID,
+		Amount:    order.TotalPaid,
+		Currency:  model.StoreCurrency.Code,
+		Method:    method,
+	})
+	if err == nil && transaction.Status == gateway.StatusAuthorized {
+		transaction, err = provider.Capture(ctx, transaction.Reference, order.TotalPaid)
+	}
+	return s.settleOrder(order, transaction, err)
+}
+
+// settleOrder moves a pending order on by a provider's answer: a captured
+// payment pays it and a declined or expired one fails it. Any other answer, a timeout
+// included, leaves it pending to be settled later.
+func (s service) settleOrder(order model.Order, transaction gateway.Transaction, err error) (model.Order, int) {
+	statusCode := http.StatusOK
+	if transaction.Reference != "" {
+		order.PaymentReference = transaction.Reference
+	}
+	if transaction.ApprovalCode != "" {
+		order.ApprovalCode = transaction.ApprovalCode
+		order.MaskedPAN = transaction.MaskedPAN
+		order.TerminalID = transaction.TerminalID
+	}
+	switch {
+	case err == gateway.ErrDeclined || transaction.Status == gateway.StatusDeclined ||
+		transaction.Status == gateway.StatusExpired:
+		order.Status = model.OrderPaymentFailed
+		statusCode = http.StatusPaymentRequired
+	case err == nil && transaction.Status == gateway.StatusCaptured:
+		order.Status = model.OrderPaid
+	case err == nil && transaction.Status == gateway.StatusVoided:
+		order.Status = model.OrderVoided
+	default:
+		if err != nil {
+			log.Println(err)
+		}
+	}
+	err = s.db.UpdateOrderStatus(s.ctx, order.OrderId, []string{model.OrderPendingPayment}, order)
+	if err == sql.ErrNoRows {
+		// Settled meanwhile by another request, or left as it was.
+		current, err := s.db.GetOrderByID(s.ctx, order.OrderId)
+		if err != nil {
+			log.Println(err)
+			return order, http.StatusBadRequest
+		}
+		if current.Status == model.OrderPaymentFailed {
+			return current, http.StatusPaymentRequired
+		}
+		return current, http.StatusOK
+	}
+	if err != nil {
+		log.Println(err)
+		return order, http.StatusBadRequest
+	}
+	if order.Status == model.OrderPaymentFailed || order.Status == model.OrderVoided {
+		s.reloadOrderedProducts(order.OrderId)
+	}
+	return order, statusCode
+}
+
+// confirmPayment applies a provider's callback to the order it is for. A
+// payment arriving after the order failed for want of one still pays it,
+// as the customer has been charged, unless what the order took is gone by
+// now; the payment is refunded then.
+func (s service) confirmPayment(provider gateway.Provider, callback gateway.Callback) (model.Order, int) {
+	confirmer, ok := provider.(gateway.Confirmer)
+	if !ok {
+		return model.Order{}, http.StatusNotFound
+	}
+	order, err := s.db.GetOrderByReceiptID(s.ctx, callback.OrderReference)
+	if err == sql.ErrNoRows {
+		return order, http.StatusNotFound
+	}
+	if err != nil {
+		log.Println(err)
+		return order, http.StatusBadRequest
+	}
+	if order.PaymentProvider != provider.Name() {
+		return order, http.StatusConflict
+	}
+	transaction, err := confirmer.Confirm(s.ctx, callback)
+	if err == gateway.ErrNotFound {
+		return order, http.StatusNotFound
+	}
+	if err == gateway.ErrMismatch {
+		return order, http.StatusConflict
+	}
+	if err != nil {
+		log.Println(err)
+		return order, http.StatusBadRequest
+	}
+	switch {
+	case order.Status == model.OrderPaymentFailed && transaction.Status == gateway.StatusCaptured:
+		return s.reinstateOrder(provider, order, transaction)
+	case order.Status == model.OrderPendingPayment:
+		order, statusCode := s.settleOrder(order, transaction, nil)
+		if statusCode == http.StatusPaymentRequired {
+			// The failure is applied; the callback itself was fine.
+			statusCode = http.StatusOK
+		}
+		return order, statusCode
+	}
+	return order, http.StatusOK
+}
+
+// reinstateOrder pays a failed order with a payment that arrived late. When
+// the stock, coupons, points or gift card balance the order took are gone
+// by now, the payment is refunded and the order stays failed.
+func (s service) reinstateOrder(provider gateway.Provider, order model.Order,
+	transaction gateway.Transaction) (model.Order, int) {
+
+	paid := order
+	paid.Status = model.OrderPaid
+	paid.PaymentReference = transaction.Reference
+	err := s.db.ReinstateOrder(s.ctx, order.OrderId, paid)
+	switch err {
+	case nil:
+		s.reloadOrderedProducts(order.OrderId)
+		return paid, http.StatusOK
+	case sql.ErrNoRows:
+		// Settled meanwhile by another callback.
+		current, err := s.db.GetOrderByID(s.ctx, order.OrderId)
+		if err != nil {
+			log.Println(err)
+			return order, http.StatusBadRequest
+		}
+		return current, http.StatusOK
+	case repository.ErrOutOfStock, repository.ErrCouponUnavailable, repository.ErrInsufficientPoints,
+		repository.ErrGiftCardUnavailable:
+		ctx, cancel := context.WithTimeout(s.ctx, s.paymentTimeout())
+		defer cancel()
+		_, refundErr := provider.Refund(ctx, transaction.Reference, order.TotalPaid)
+		if refundErr != nil {
+			log.Println(refundErr)
+			return order, http.StatusBadRequest
+		}
+		log.Printf("late payment of order %s refunded: %s", order.ReceiptID, err)
+		return order, http.StatusOK
+	default:
+		log.Println(err)
+		return order, http.StatusBadRequest
+	}
+}
+
+func (s service) paymentTimeout() time.Duration {
+	return time.Duration(s.cfg.Store.PaymentTimeout) * time.Second
+}
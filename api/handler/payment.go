@@ -7,6 +7,7 @@ import (
 	"net/http"
 
 	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/repository"
 	"github.com/saptaka/pos/utils"
 )
 
@@ -16,6 +17,7 @@ type Payment interface {
 	CreatePayment(payment model.Payment) ([]byte, int)
 	UpdatePayment(payment model.Payment) ([]byte, int)
 	DeletePayment(id int) ([]byte, int)
+	RestorePayment(id int64) ([]byte, int)
 }
 
 func (s service) ListPayment(limit, skip int) ([]byte, int) {
@@ -54,7 +56,7 @@ func (s service) CreatePayment(payment model.Payment) ([]byte, int) {
 		log.Println(err)
 		return utils.ResponseWrapper(http.StatusBadRequest, nil)
 	}
-	if !model.PaymentType[payment.Type] {
+	if !model.PaymentType[payment.Type] || !validRounding(&payment) || !s.validProvider(payment) {
 		return utils.ResponseWrapper(http.StatusBadRequest, nil)
 	}
 
@@ -67,6 +69,9 @@ func (s service) CreatePayment(payment model.Payment) ([]byte, int) {
 }
 
 func (s service) UpdatePayment(payment model.Payment) ([]byte, int) {
+	if !validRounding(&payment) || !s.validProvider(payment) {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
 	err := s.db.UpdatePayment(s.ctx, payment)
 	if err == sql.ErrNoRows {
 		return utils.ResponseWrapper(http.StatusNotFound, nil)
@@ -80,8 +85,56 @@ func (s service) UpdatePayment(payment model.Payment) ([]byte, int) {
 
 func (s service) DeletePayment(id int) ([]byte, int) {
 	err := s.db.DeletePayment(s.ctx, id)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err == repository.ErrReferenced {
+		return utils.ResponseWrapper(http.StatusConflict, nil)
+	}
 	if err != nil {
+		log.Println(err)
 		return utils.ResponseWrapper(http.StatusBadRequest, nil)
 	}
 	return utils.ResponseWrapper(http.StatusOK, nil)
 }
+
+func (s service) RestorePayment(id int64) ([]byte, int) {
+	err := s.db.RestorePayment(s.ctx, id)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err == repository.ErrReferenced {
+		return utils.ResponseWrapper(http.StatusConflict, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, nil)
+}
+
+// validRounding checks the rounding rule of a payment type, rounding to
+// the nearest unit when no mode is given.
+func validRounding(payment *model.Payment) bool {
+	if payment.RoundingMode == "" {
+		payment.RoundingMode = model.RoundNearest
+	}
+	return payment.RoundingUnit >= 0 && model.RoundingMode[payment.RoundingMode]
+}
+
+// validProvider checks that a payment type taken by a payment provider
+// names a registered one. Cash is always taken at the counter, QRIS codes
+// are paid from e-wallets and the EDC terminal takes cards.
+func (s service) validProvider(payment model.Payment) bool {
+	if payment.Provider == "" {
+		return true
+	}
+	_, ok := s.gateways.Get(payment.Provider)
+	switch payment.Provider {
+	case "qris":
+		return ok && payment.Type == "E-WALLET"
+	case "edc":
+		return ok && payment.Type == "EDC"
+	}
+	return ok && payment.Type != "CASH"
+}
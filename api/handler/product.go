@@ -8,6 +8,7 @@ import (
 	"sync"
 
 	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/repository"
 	"github.com/saptaka/pos/utils"
 )
 
@@ -28,12 +29,18 @@ func (c *syncMap) Set(key int64, value model.Product) {
 	c.m.Store(key, value)
 }
 
+func (c *syncMap) Delete(key int64) {
+	c.m.Delete(key)
+}
+
 type Product interface {
 	ListProduct(limit, skip int, product model.Product) ([]byte, int)
 	DetailProduct(id int64) ([]byte, int)
 	CreateProduct(product model.ProductCreateRequest) ([]byte, int)
 	UpdateProduct(product model.Product) ([]byte, int)
 	DeleteProduct(id int64) ([]byte, int)
+	RestoreProduct(id int64) ([]byte, int)
+	ReceiveGoods(receipt model.GoodsReceipt) ([]byte, int)
 }
 
 func (s service) ListProduct(limit, skip int, product model.Product) ([]byte, int) {
@@ -67,6 +74,15 @@ func (s service) DetailProduct(id int64) ([]byte, int) {
 }
 
 func (s service) CreateProduct(productRequest model.ProductCreateRequest) ([]byte, int) {
+	if productRequest.Unit == "" {
+		productRequest.Unit = model.UnitPcs
+	}
+	if !model.UnitType[productRequest.Unit] {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	if productRequest.Discount != nil && !s.validDiscount(*productRequest.Discount) {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
 
 	product, err := s.db.CreateProduct(s.ctx, productRequest)
 	if err != nil {
@@ -74,7 +90,12 @@ func (s service) CreateProduct(productRequest model.ProductCreateRequest) ([]byt
 		return utils.ResponseWrapper(http.StatusBadRequest, product)
 	}
 
-	productCache.Set(product.ProductId, product)
+	cachedProduct, err := s.db.GetProductByID(s.ctx, product.ProductId)
+	if err != nil {
+		log.Println(err)
+		cachedProduct = product
+	}
+	productCache.Set(product.ProductId, cachedProduct)
 
 	productCreatedResponse := model.ProductCreateResponse{
 		Name:       product.Name,
@@ -82,6 +103,7 @@ func (s service) CreateProduct(productRequest model.ProductCreateRequest) ([]byt
 		Stock:      product.Stock,
 		SKU:        product.SKU,
 		Price:      product.Price,
+		Unit:       product.Unit,
 		Image:      product.Image,
 		CreatedAt:  product.CreatedAt,
 		UpdatedAt:  product.UpdatedAt,
@@ -92,6 +114,9 @@ func (s service) CreateProduct(productRequest model.ProductCreateRequest) ([]byt
 }
 
 func (s service) UpdateProduct(product model.Product) ([]byte, int) {
+	if product.Unit != "" && !model.UnitType[product.Unit] {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
 	err := s.db.UpdateProduct(s.ctx, product)
 	if err == sql.ErrNoRows {
 		return utils.ResponseWrapper(http.StatusNotFound, nil)
@@ -101,7 +126,15 @@ func (s service) UpdateProduct(product model.Product) ([]byte, int) {
 		return utils.ResponseWrapper(http.StatusBadRequest, nil)
 	}
 
-	productCache.Set(product.ProductId, product)
+	// The request only carries the fields being changed, so cache the
+	// product as stored rather than the request.
+	updated, err := s.db.GetProductByID(s.ctx, product.ProductId)
+	if err != nil {
+		log.Println(err)
+		productCache.Delete(product.ProductId)
+		return utils.ResponseWrapper(http.StatusOK, nil)
+	}
+	productCache.Set(updated.ProductId, updated)
 
 	return utils.ResponseWrapper(http.StatusOK, nil)
 }
@@ -115,9 +148,54 @@ func (s service) DeleteProduct(id int64) ([]byte, int) {
 		log.Println(err)
 		return utils.ResponseWrapper(http.StatusBadRequest, nil)
 	}
+	productCache.Delete(id)
 	return utils.ResponseWrapper(http.StatusOK, nil)
 }
 
+func (s service) RestoreProduct(id int64) ([]byte, int) {
+	err := s.db.RestoreProduct(s.ctx, id)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err == repository.ErrReferenced {
+		return utils.ResponseWrapper(http.StatusConflict, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+
+	product, err := s.db.GetProductByID(s.ctx, id)
+	if err == nil {
+		productCache.Set(product.ProductId, product)
+	}
+	return utils.ResponseWrapper(http.StatusOK, nil)
+}
+
+func (s service) ReceiveGoods(receipt model.GoodsReceipt) ([]byte, int) {
+	err := s.validation.Struct(receipt)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+
+	receipt, err = s.db.CreateGoodsReceipt(s.ctx, receipt)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+
+	product, err := s.db.GetProductByID(s.ctx, receipt.ProductId)
+	if err == nil {
+		productCache.Set(product.ProductId, product)
+	}
+
+	return utils.ResponseWrapper(http.StatusOK, receipt)
+}
+
 func (s service) LoadProduct() error {
 	products, err := s.db.GetProducts(s.ctx, 0, 0, model.Product{})
 	for _, product := range products {
@@ -0,0 +1,287 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/utils"
+)
+
+type ProductImport interface {
+	ImportProducts(format string, data []byte, dryRun bool) ([]byte, int)
+	ExportProducts(format string) ([]byte, int)
+}
+
+func (s service) ImportProducts(format string, data []byte, dryRun bool) ([]byte, int) {
+	rows, err := readImportRows(format, data)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+
+	imports, importErrors := parseProductImport(rows)
+
+	skus := make([]string, 0)
+	for _, item := range imports {
+		if item.Product.SKU != "" {
+			skus = append(skus, item.Product.SKU)
+		}
+	}
+	existing, err := s.db.GetProductsBySKU(s.ctx, skus)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	result := model.ProductImportResult{DryRun: dryRun}
+	valid := make([]model.ProductImport, 0, len(imports))
+	for _, item := range imports {
+		product, ok := existing[item.Product.SKU]
+		switch {
+		case ok && product.ArchivedAt != nil:
+			importErrors = append(importErrors, model.ProductImportError{
+				Row: item.Row, Column: "sku", Message: "product is archived",
+			})
+			continue
+		case ok:
+			result.Updated++
+		default:
+			result.Created++
+		}
+		valid = append(valid, item)
+	}
+	imports = valid
+	sort.SliceStable(importErrors, func(i, j int) bool {
+		return importErrors[i].Row < importErrors[j].Row
+	})
+	result.Total = len(imports)
+	result.Errors = importErrors
+
+	if dryRun {
+		return utils.ResponseWrapper(http.StatusOK, result)
+	}
+	if len(importErrors) > 0 {
+		return utils.ResponseWrapper(http.StatusBadRequest, result)
+	}
+
+	result.Created, result.Updated, err = s.db.ImportProducts(s.ctx, imports)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+
+	err = s.LoadProduct()
+	if err != nil {
+		log.Println(err)
+	}
+
+	return utils.ResponseWrapper(http.StatusOK, result)
+}
+
+func (s service) ExportProducts(format string) ([]byte, int) {
+	products, err := s.db.GetProducts(s.ctx, 0, 0, model.Product{})
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+
+	rows := [][]string{model.ProductExportColumns}
+	for _, product := range products {
+		var category, discountType, discountQty, discountAmount, discountPercent string
+		if product.Category != nil {
+			category = product.Category.Name
+		}
+		if product.Discount != nil {
+			discountType = product.Discount.Type
+			discountQty = strconv.Itoa(product.Discount.Qty)
+			if product.Discount.Amount != 0 {
+				discountAmount = product.Discount.Amount.Major()
+			}
+			if product.Discount.Percent != 0 {
+				discountPercent = strconv.Itoa(product.Discount.Percent)
+			}
+		}
+		rows = append(rows, []string{
+			product.SKU,
+			product.Name,
+			category,
+			product.Price.Major(),
+			utils.FormatQty(product.Stock),
+			product.CostPrice.Major(),
+			product.Unit,
+			product.Barcode,
+			discountType,
+			discountQty,
+			discountAmount,
+			discountPercent,
+		})
+	}
+
+	var file bytes.Buffer
+	switch format {
+	case model.ImportFormatCSV:
+		writer := csv.NewWriter(&file)
+		err = writer.WriteAll(rows)
+	case model.ImportFormatXLSX:
+		err = utils.WriteXLSX(&file, rows)
+	default:
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return file.Bytes(), http.StatusOK
+}
+
+// readImportRows returns the rows of an import file, the header first.
+func readImportRows(format string, data []byte) ([][]string, error) {
+	switch format {
+	case model.ImportFormatCSV:
+		reader := csv.NewReader(bytes.NewReader(data))
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		return reader.ReadAll()
+	case model.ImportFormatXLSX:
+		return utils.ReadXLSX(data)
+	}
+	return nil, fmt.Errorf("import: unknown format %q", format)
+}
+
+func parseProductImport(rows [][]string) ([]model.ProductImport, []model.ProductImportError) {
+	imports := make([]model.ProductImport, 0)
+	importErrors := make([]model.ProductImportError, 0)
+	if len(rows) == 0 {
+		importErrors = append(importErrors, model.ProductImportError{
+			Row: 1, Message: "file is empty",
+		})
+		return imports, importErrors
+	}
+
+	columns := make(map[string]int)
+	present := make(map[string]bool)
+	for index, header := range rows[0] {
+		name := strings.ToLower(strings.TrimSpace(header))
+		columns[name] = index
+		present[name] = true
+	}
+	for _, required := range []string{"name", "price"} {
+		if _, ok := columns[required]; !ok {
+			importErrors = append(importErrors, model.ProductImportError{
+				Row: 1, Column: required, Message: "column is required",
+			})
+		}
+	}
+	if len(importErrors) > 0 {
+		return imports, importErrors
+	}
+
+	seenSKU := make(map[string]int)
+	for index, row := range rows[1:] {
+		rowNumber := index + 2
+		value := func(column string) string {
+			position, ok := columns[column]
+			if !ok || position >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[position])
+		}
+		if strings.Join(row, "") == "" {
+			continue
+		}
+		addError := func(column, message string) {
+			importErrors = append(importErrors, model.ProductImportError{
+				Row: rowNumber, Column: column, Message: message,
+			})
+		}
+		errorCount := len(importErrors)
+
+		product := model.Product{
+			SKU:     value("sku"),
+			Name:    value("name"),
+			Unit:    strings.ToLower(value("unit")),
+			Barcode: value("barcode"),
+		}
+		if product.Name == "" {
+			addError("name", "name is required")
+		}
+		if product.SKU != "" {
+			if firstRow, ok := seenSKU[product.SKU]; ok {
+				addError("sku", fmt.Sprintf("duplicate of row %d", firstRow))
+			}
+			seenSKU[product.SKU] = rowNumber
+		}
+
+		price, err := model.ParseMoney(value("price"))
+		if err != nil || price <= 0 {
+			addError("price", "price must be a positive amount")
+		}
+		product.Price = price
+
+		if stock := value("stock"); stock != "" {
+			product.Stock, err = strconv.ParseFloat(stock, 64)
+			if err != nil || product.Stock < 0 {
+				addError("stock", "stock must be a non-negative number")
+			}
+		}
+
+		var unitCost model.Money
+		if cost := value("unit_cost"); cost != "" {
+			unitCost, err = model.ParseMoney(cost)
+			if err != nil || unitCost < 0 {
+				addError("unit_cost", "unit_cost must be a non-negative amount")
+			}
+		}
+
+		if product.Unit == "" {
+			product.Unit = model.UnitPcs
+		}
+		if !model.UnitType[product.Unit] {
+			addError("unit", fmt.Sprintf("unknown unit %q", product.Unit))
+		}
+
+		if discountType := strings.ToUpper(value("discount_type")); discountType != "" {
+			discount := model.Discount{Type: discountType, Qty: 1}
+			if !model.DiscountType[discountType] {
+				addError("discount_type", fmt.Sprintf("unknown discount type %q", discountType))
+			}
+			if qty := value("discount_qty"); qty != "" {
+				discount.Qty, err = strconv.Atoi(qty)
+				if err != nil || discount.Qty <= 0 {
+					addError("discount_qty", "discount_qty must be a positive whole number")
+				}
+			}
+			if discountType == model.Percent {
+				discount.Percent, err = strconv.Atoi(value("discount_percent"))
+				if err != nil || discount.Percent <= 0 || discount.Percent > 100 {
+					addError("discount_percent", "discount_percent must be a whole number from 1 to 100")
+				}
+			} else {
+				discount.Amount, err = model.ParseMoney(value("discount_amount"))
+				if err != nil || discount.Amount <= 0 {
+					addError("discount_amount", "discount_amount must be a positive amount")
+				}
+			}
+			product.Discount = &discount
+		}
+
+		if len(importErrors) > errorCount {
+			continue
+		}
+		imports = append(imports, model.ProductImport{
+			Row:          rowNumber,
+			Product:      product,
+			CategoryName: value("category"),
+			UnitCost:     unitCost,
+			Columns:      present,
+		})
+	}
+
+	return imports, importErrors
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/saptaka/pos/model"
+)
+
+func TestParseProductImport(t *testing.T) {
+	tests := []struct {
+		name       string
+		file       string
+		want       []model.ProductImport
+		wantErrors []model.ProductImportError
+	}{
+		{"minimal",
+			"name,price\nKopi,12500\n",
+			[]model.ProductImport{{Row: 2, Product: model.Product{Name: "Kopi", Price: 12500, Unit: model.UnitPcs}}},
+			nil},
+		{"every column",
+			"SKU, Name ,category,price,stock,unit_cost,unit,barcode,discount_type,discount_qty,discount_amount,discount_percent\n" +
+				"GL-1,Gula,Dapur,15000,2.5,11000,KG,899100,buy_n,3,1000,\n" +
+				"TH-1,Teh,Minuman,8000,10,,,,PERCENT,,,15\n",
+			[]model.ProductImport{
+				{Row: 2, CategoryName: "Dapur", UnitCost: 11000, Product: model.Product{
+					SKU: "GL-1", Name: "Gula", Price: 15000, Stock: 2.5, Unit: "kg", Barcode: "899100",
+					Discount: &model.Discount{Type: model.BuyN, Qty: 3, Amount: 1000}}},
+				{Row: 3, CategoryName: "Minuman", Product: model.Product{
+					SKU: "TH-1", Name: "Teh", Price: 8000, Stock: 10, Unit: model.UnitPcs,
+					Discount: &model.Discount{Type: model.Percent, Qty: 1, Percent: 15}}},
+			},
+			nil},
+		{"blank rows skipped",
+			"name,price\n,\nKopi,12500\n ,\n",
+			[]model.ProductImport{{Row: 3, Product: model.Product{Name: "Kopi", Price: 12500, Unit: model.UnitPcs}}},
+			nil},
+		{"empty file",
+			"",
+			nil,
+			[]model.ProductImportError{{Row: 1}}},
+		{"missing required columns",
+			"sku,stock\nKP-1,3\n",
+			nil,
+			[]model.ProductImportError{{Row: 1, Column: "name"}, {Row: 1, Column: "price"}}},
+		{"invalid values",
+			"name,price,stock,unit,unit_cost\n,0,-1,box,-5\n",
+			nil,
+			[]model.ProductImportError{
+				{Row: 2, Column: "name"},
+				{Row: 2, Column: "price"},
+				{Row: 2, Column: "stock"},
+				{Row: 2, Column: "unit_cost"},
+				{Row: 2, Column: "unit"},
+			}},
+		{"invalid discounts",
+			"name,price,discount_type,discount_qty,discount_amount,discount_percent\n" +
+				"Kopi,12500,PERCENT,0,,101\nTeh,8000,BUY_N,2,,\nGula,9000,FREE,,,\n",
+			nil,
+			[]model.ProductImportError{
+				{Row: 2, Column: "discount_qty"},
+				{Row: 2, Column: "discount_percent"},
+				{Row: 3, Column: "discount_amount"},
+				{Row: 4, Column: "discount_type"},
+				{Row: 4, Column: "discount_amount"},
+			}},
+		{"duplicate sku",
+			"sku,name,price\nKP-1,Kopi,12500\nKP-1,Kopi Susu,15000\n",
+			[]model.ProductImport{{Row: 2, Product: model.Product{SKU: "KP-1", Name: "Kopi", Price: 12500, Unit: model.UnitPcs}}},
+			[]model.ProductImportError{{Row: 3, Column: "sku"}}},
+		{"price with too many digits",
+			"name,price\nKopi,99999999999999999999\n",
+			nil,
+			[]model.ProductImportError{{Row: 2, Column: "price"}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rows, err := readImportRows(model.ImportFormatCSV, []byte(test.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, gotErrors := parseProductImport(rows)
+			if len(gotErrors) != len(test.wantErrors) {
+				t.Fatalf("errors = %+v, want %+v", gotErrors, test.wantErrors)
+			}
+			for index, importError := range gotErrors {
+				want := test.wantErrors[index]
+				if importError.Row != want.Row || importError.Column != want.Column {
+					t.Errorf("error %d = %+v, want row %d column %q", index, importError, want.Row, want.Column)
+				}
+			}
+			if len(got) != len(test.want) {
+				t.Fatalf("got %d products, want %d", len(got), len(test.want))
+			}
+			for index, item := range got {
+				if !sameImport(item, test.want[index]) {
+					t.Errorf("product %d = %+v, want %+v", index, item, test.want[index])
+				}
+			}
+		})
+	}
+}
+
+func sameImport(got, want model.ProductImport) bool {
+	if got.Row != want.Row || got.CategoryName != want.CategoryName || got.UnitCost != want.UnitCost {
+		return false
+	}
+	a, b := got.Product, want.Product
+	if a.SKU != b.SKU || a.Name != b.Name || a.Price != b.Price || a.Stock != b.Stock ||
+		a.Unit != b.Unit || a.Barcode != b.Barcode {
+		return false
+	}
+	if a.Discount == nil || b.Discount == nil {
+		return a.Discount == b.Discount
+	}
+	return a.Discount.Type == b.Discount.Type && a.Discount.Qty == b.Discount.Qty &&
+		a.Discount.Amount == b.Discount.Amount && a.Discount.Percent == b.Discount.Percent
+}
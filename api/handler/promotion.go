@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/promotion"
+	"github.com/saptaka/pos/utils"
+)
+
+type Promotion interface {
+	ListPromotion(limit, skip int) ([]byte, int)
+	DetailPromotion(id int64) ([]byte, int)
+	CreatePromotion(promotion model.Promotion) ([]byte, int)
+	UpdatePromotion(promotion model.Promotion) ([]byte, int)
+	DeletePromotion(id int64) ([]byte, int)
+	ActivePromotion(at time.Time) ([]byte, int)
+}
+
+func (s service) ListPromotion(limit, skip int) ([]byte, int) {
+	promotions, err := s.db.GetPromotions(s.ctx, limit, skip)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	listPromotion := model.ListPromotion{
+		Promotions: promotions,
+		Meta: model.Meta{
+			Total: len(promotions),
+			Limit: limit,
+			Skip:  skip,
+		},
+	}
+	return utils.ResponseWrapper(http.StatusOK, listPromotion)
+}
+
+func (s service) DetailPromotion(id int64) ([]byte, int) {
+	promotion, err := s.db.GetPromotionByID(s.ctx, id)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, promotion)
+}
+
+func (s service) CreatePromotion(promotion model.Promotion) ([]byte, int) {
+	if !s.validPromotion(promotion) {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	promotion, err := s.db.CreatePromotion(s.ctx, promotion)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, promotion)
+}
+
+func (s service) UpdatePromotion(promotion model.Promotion) ([]byte, int) {
+	if !s.validPromotion(promotion) {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	err := s.db.UpdatePromotion(s.ctx, promotion)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, nil)
+}
+
+func (s service) DeletePromotion(id int64) ([]byte, int) {
+	err := s.db.DeletePromotion(s.ctx, id)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, nil)
+}
+
+// ActivePromotion lists the promotions that would apply to an order priced
+// at the given time in the store's timezone.
+func (s service) ActivePromotion(at time.Time) ([]byte, int) {
+	promotions, err := s.db.GetPromotions(s.ctx, 0, 0)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	at = s.storeTime(at)
+	active := make([]model.Promotion, 0)
+	for _, promotion := range promotions {
+		if !promotion.CouponOnly && promotion.ActiveAt(at) {
+			active = append(active, promotion)
+		}
+	}
+	listPromotion := model.ListPromotion{
+		Promotions: active,
+		Meta: model.Meta{
+			Total: len(active),
+		},
+	}
+	return utils.ResponseWrapper(http.StatusOK, listPromotion)
+}
+
+func (s service) validPromotion(promotion model.Promotion) bool {
+	err := s.validation.Struct(promotion)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+	if !model.PromotionType[promotion.Type] {
+		return false
+	}
+	if promotion.StartedAt != nil && promotion.ExpiredAt != nil &&
+		!promotion.StartedAt.Before(promotion.ExpiredAt.Time) {
+		return false
+	}
+	for _, window := range promotion.Windows {
+		if !window.Valid() {
+			return false
+		}
+	}
+
+	switch promotion.Type {
+	case model.PromotionFixedAmount:
+		return promotion.Amount > 0 && promotion.Percent == 0
+	case model.PromotionBuyXGetY:
+		return promotion.ProductId != nil && promotion.BuyQty > 0 &&
+			promotion.GetQty > 0 && promotion.Amount == 0 &&
+			promotion.Percent >= 0 && promotion.Percent <= 100
+	case model.PromotionMixMatch:
+		return promotion.CategoryId != nil && promotion.BuyQty > 1 &&
+			promotion.Amount > 0 && promotion.Percent == 0
+	case model.PromotionSpendThreshold:
+		if promotion.ValueType == model.ValuePercent {
+			return promotion.MinSpend > 0 && promotion.Amount == 0 &&
+				promotion.Percent > 0 && promotion.Percent <= 100
+		}
+		return promotion.ValueType == model.ValueAmount &&
+			promotion.MinSpend > 0 && promotion.Amount > 0 && promotion.Percent == 0
+	case model.PromotionTiered:
+		if promotion.ProductId == nil || len(promotion.Tiers) == 0 {
+			return false
+		}
+		for _, tier := range promotion.Tiers {
+			if tier.MinQty <= 0 || tier.Price < 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// applyPromotions runs the active promotions, together with those unlocked
+// by coupons, over the priced lines. Line level savings are taken off each
+// line's TotalFinalPrice; the basket level saving is returned in the result.
+func (s service) applyPromotions(details []model.SubOrderedProductDetail,
+	now time.Time, unlocked []model.Promotion) (promotion.Result, error) {
+
+	stored, err := s.db.GetPromotions(s.ctx, 0, 0)
+	if err != nil {
+		return promotion.Result{}, err
+	}
+	var promotions []model.Promotion
+	included := make(map[int64]bool)
+	for _, item := range stored {
+		if !item.CouponOnly {
+			promotions = append(promotions, item)
+			included[item.PromotionId] = true
+		}
+	}
+	for _, item := range unlocked {
+		if !included[item.PromotionId] {
+			promotions = append(promotions, item)
+			included[item.PromotionId] = true
+		}
+	}
+
+	lines := make([]promotion.Line, 0, len(details))
+	for _, detail := range details {
+		lines = append(lines, promotion.Line{
+			ProductId:  detail.ProductId,
+			CategoryId: detail.CategoryId,
+			UnitPrice:  detail.Price,
+			Qty:        detail.Qty,
+			Amount:     detail.TotalFinalPrice,
+			Discounted: detail.Discount != nil,
+		})
+	}
+
+	result := promotion.Apply(promotions, lines, s.storeTime(now))
+	for index, line := range result.Lines {
+		details[index].TotalFinalPrice = line.Amount
+	}
+	return result, nil
+}
+
+// storeTime moves t into the store's timezone, in which promotion windows
+// are evaluated.
+func (s service) storeTime(t time.Time) time.Time {
+	if s.cfg == nil || s.cfg.Store.Location == nil {
+		return t
+	}
+	return t.In(s.cfg.Store.Location)
+}
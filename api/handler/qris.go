@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"context"
+	"crypto/hmac"
+	"database/sql"
+	"log"
+	"net/http"
+
+	"github.com/saptaka/pos/gateway"
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/qris"
+	"github.com/saptaka/pos/utils"
+)
+
+type QRIS interface {
+	QRISCode(orderId int64, format string) ([]byte, int)
+	QRISCallback(token string, callback model.QRISCallback) ([]byte, int)
+}
+
+const qrisImageSize = 512
+
+// QRISCode is the code issued for an order paid by QRIS, as JSON or as an
+// image for the customer display. Only a code that can still be paid is
+// rendered.
+func (s service) QRISCode(orderId int64, format string) ([]byte, int) {
+	order, provider, statusCode := s.providerOrder(orderId)
+	if statusCode == http.StatusConflict || order.PaymentProvider != "qris" {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if statusCode != http.StatusOK {
+		return utils.ResponseWrapper(statusCode, nil)
+	}
+	if order.Status == model.OrderPendingPayment && order.PaymentReference != "" {
+		// Expires the code, and fails the order, once its time is up.
+		ctx, cancel := context.WithTimeout(s.ctx, s.paymentTimeout())
+		transaction, err := provider.Status(ctx, order.PaymentReference)
+		cancel()
+		if err == nil && transaction.Status != gateway.StatusPending {
+			s.settleOrder(order, transaction, nil)
+		}
+	}
+	code, err := s.db.GetQRISCodeByOrder(s.ctx, order.ReceiptID)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+
+	switch format {
+	case "", model.QRISFormatJSON:
+		return utils.ResponseWrapper(http.StatusOK, code)
+	case model.QRISFormatPNG, model.QRISFormatSVG:
+	default:
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	if code.Status != model.QRISPending {
+		return utils.ResponseWrapper(http.StatusConflict, code)
+	}
+	var image []byte
+	if format == model.QRISFormatPNG {
+		image, err = qris.PNG(code.Payload, qrisImageSize)
+	} else {
+		image, err = qris.SVG(code.Payload)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return image, http.StatusOK
+}
+
+// QRISCallback takes the acquirer's notice that the code for a bill was
+// paid or failed, and settles its order.
+func (s service) QRISCallback(token string, callback model.QRISCallback) ([]byte, int) {
+	secret := s.cfg.Store.QRIS.CallbackToken
+	if secret == "" || !hmac.Equal([]byte(token), []byte(secret)) {
+		return utils.ResponseWrapper(http.StatusUnauthorized, nil)
+	}
+	err := s.validation.Struct(callback)
+	if err != nil || (callback.Status != model.QRISPaid && callback.Status != model.QRISFailed) {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	provider, ok := s.gateways.Get("qris")
+	if !ok {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	order, statusCode := s.confirmPayment(provider, gateway.Callback{
+		OrderReference: callback.BillNumber,
+		Amount:         callback.Amount,
+		Paid:           callback.Status == model.QRISPaid,
+	})
+	if statusCode != http.StatusOK {
+		return utils.ResponseWrapper(statusCode, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, order)
+}
@@ -0,0 +1,258 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/notify"
+	"github.com/saptaka/pos/report"
+	"github.com/saptaka/pos/utils"
+)
+
+type Receipt interface {
+	SendReceipt(orderId int64, request model.ReceiptRequest) ([]byte, int)
+	ListReceiptMessage(limit, skip int, orderId int64, status string) ([]byte, int)
+	RetryReceiptMessage(id int64) ([]byte, int)
+}
+
+// outboxBatch is how many receipts are sent on each pass over the outbox,
+// and receiptTimeout how long one may take to hand over.
+const (
+	outboxBatch    = 50
+	receiptTimeout = 30 * time.Second
+)
+
+// SendReceipt queues the receipt of a paid or refunded order for sending.
+func (s service) SendReceipt(orderId int64, request model.ReceiptRequest) ([]byte, int) {
+	order, err := s.db.GetOrderByID(s.ctx, orderId)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	if order.Status != model.OrderPaid && order.Status != model.OrderRefunded {
+		return utils.ResponseWrapper(http.StatusConflict, nil)
+	}
+	recipients, statusCode := s.receiptRecipients(request, order.CustomerId)
+	if statusCode != http.StatusOK {
+		return utils.ResponseWrapper(statusCode, nil)
+	}
+	products, err := s.db.GetOrderedProductByOrderId(s.ctx, orderId)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	messages, err := s.queueReceipt(order, products, recipients)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	listReceiptMessage := model.ListReceiptMessage{
+		ReceiptMessages: messages,
+		Meta: model.Meta{
+			Total: len(messages),
+		},
+	}
+	return utils.ResponseWrapper(http.StatusOK, listReceiptMessage)
+}
+
+func (s service) ListReceiptMessage(limit, skip int, orderId int64, status string) ([]byte, int) {
+	messages, err := s.db.GetReceiptMessages(s.ctx, limit, skip, orderId, status)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	listReceiptMessage := model.ListReceiptMessage{
+		ReceiptMessages: messages,
+		Meta: model.Meta{
+			Total: len(messages),
+			Limit: limit,
+			Skip:  skip,
+		},
+	}
+	return utils.ResponseWrapper(http.StatusOK, listReceiptMessage)
+}
+
+// RetryReceiptMessage queues a receipt that was given up on again.
+func (s service) RetryReceiptMessage(id int64) ([]byte, int) {
+	_, err := s.db.GetReceiptMessageByID(s.ctx, id)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	err = s.db.RetryReceiptMessage(s.ctx, id, time.Now().UTC())
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusConflict, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	s.wakeOutbox()
+	message, err := s.db.GetReceiptMessageByID(s.ctx, id)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, message)
+}
+
+// receiptRecipients works out where a receipt goes: to the email address
+// and phone asked for, or else to those of the customer over the channels
+// the store can send on. Asking for a channel the store cannot send on is
+// rejected.
+func (s service) receiptRecipients(request model.ReceiptRequest,
+	customerId *int64) ([]model.ReceiptMessage, int) {
+
+	request.Email = strings.TrimSpace(request.Email)
+	request.Phone = model.NormalizePhone(request.Phone)
+	err := s.validation.Struct(request)
+	if err != nil {
+		return nil, http.StatusBadRequest
+	}
+	if (request.Email != "" && !s.notifier.Has(model.ReceiptEmail)) ||
+		(request.Phone != "" && !s.notifier.Has(model.ReceiptSMS)) {
+		return nil, http.StatusBadRequest
+	}
+	if request.Email == "" && request.Phone == "" && customerId != nil {
+		customer, err := s.db.GetCustomerByID(s.ctx, *customerId)
+		if err != nil && err != sql.ErrNoRows {
+			log.Println(err)
+			return nil, http.StatusBadRequest
+		}
+		if s.notifier.Has(model.ReceiptEmail) {
+			request.Email = customer.Email
+		}
+		if s.notifier.Has(model.ReceiptSMS) {
+			request.Phone = customer.Phone
+		}
+	}
+
+	var recipients []model.ReceiptMessage
+	if request.Email != "" {
+		recipients = append(recipients, model.ReceiptMessage{Channel: model.ReceiptEmail, Recipient: request.Email})
+	}
+	if request.Phone != "" {
+		recipients = append(recipients, model.ReceiptMessage{Channel: model.ReceiptSMS, Recipient: request.Phone})
+	}
+	if len(recipients) == 0 {
+		return nil, http.StatusBadRequest
+	}
+	return recipients, http.StatusOK
+}
+
+// queueReceipt lays out the order's receipt for each recipient and puts
+// the messages in the outbox, due straight away.
+func (s service) queueReceipt(order model.Order, products []model.OrderedProductDetail,
+	recipients []model.ReceiptMessage) ([]model.ReceiptMessage, error) {
+
+	now := time.Now().UTC()
+	for index := range recipients {
+		recipients[index].OrderId = order.OrderId
+		recipients[index].NextAttemptAt = &now
+		switch recipients[index].Channel {
+		case model.ReceiptEmail:
+			recipients[index].Subject = "Your receipt " + order.ReceiptID + " from " + s.cfg.Store.Name
+			recipients[index].Body = string(report.Receipt(s.cfg.Store.Name, order, products, s.cfg.Store.Location))
+		case model.ReceiptSMS:
+			recipients[index].Body = report.ReceiptSummary(s.cfg.Store.Name, order, s.cfg.Store.Location)
+		}
+	}
+	messages, err := s.db.CreateReceiptMessages(s.ctx, recipients)
+	if err != nil {
+		return nil, err
+	}
+	s.wakeOutbox()
+	return messages, nil
+}
+
+// wakeOutbox has the outbox checked now rather than on its next tick.
+func (s service) wakeOutbox() {
+	select {
+	case s.outbox <- struct{}{}:
+	default:
+	}
+}
+
+// runOutbox sends the receipts due, on every tick and whenever woken.
+func (s service) runOutbox() {
+	interval := time.Duration(s.cfg.Store.OutboxInterval) * time.Second
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.outbox:
+		}
+		s.sendReceipts()
+	}
+}
+
+// sendReceipts tries the receipts due once each. A failed receipt is tried
+// again after the retry delay, doubled for every failure before, until it
+// has been tried as often as the store allows.
+func (s service) sendReceipts() {
+	now := time.Now().UTC()
+	messages, err := s.db.GetDueReceiptMessages(s.ctx, now, outboxBatch)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	for _, message := range messages {
+		ctx, cancel := context.WithTimeout(s.ctx, receiptTimeout)
+		err := s.notifier.Send(ctx, message.Channel, notify.Message{
+			To:      message.Recipient,
+			Subject: message.Subject,
+			Body:    message.Body,
+		})
+		cancel()
+
+		now := time.Now().UTC()
+		message.Attempts++
+		if err == nil {
+			message.Status = model.ReceiptSent
+			message.LastError = ""
+			message.SentAt = &now
+			message.NextAttemptAt = nil
+		} else {
+			log.Printf("receipt %d to %s: %v", message.ReceiptMessageId, message.Recipient, err)
+			message.LastError = clipError(err.Error())
+			if message.Attempts >= s.cfg.Store.ReceiptMaxAttempts {
+				message.Status = model.ReceiptFailed
+				message.NextAttemptAt = nil
+			} else {
+				delay := time.Duration(s.cfg.Store.ReceiptRetryDelay) * time.Second << (message.Attempts - 1)
+				next := now.Add(delay)
+				message.NextAttemptAt = &next
+			}
+		}
+		err = s.db.UpdateReceiptMessage(s.ctx, message)
+		if err != nil && err != sql.ErrNoRows {
+			log.Println(err)
+		}
+	}
+}
+
+// clipError keeps an error short enough for the outbox to store.
+func clipError(message string) string {
+	runes := []rune(message)
+	if len(runes) <= 255 {
+		return message
+	}
+	return string(runes[:255])
+}
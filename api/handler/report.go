@@ -1,14 +1,18 @@
 package handler
 
 import (
+	"log"
 	"net/http"
 
+	"github.com/saptaka/pos/model"
 	"github.com/saptaka/pos/utils"
 )
 
 type Report interface {
 	Revenue() ([]byte, int)
 	Solds() ([]byte, int)
+	Margin(groupBy string) ([]byte, int)
+	InventoryValuation() ([]byte, int)
 }
 
 func (s service) Revenue() ([]byte, int) {
@@ -26,3 +30,27 @@ func (s service) Solds() ([]byte, int) {
 	}
 	return utils.ResponseWrapper(http.StatusOK, sold)
 }
+
+func (s service) Margin(groupBy string) ([]byte, int) {
+	if groupBy == "" {
+		groupBy = model.MarginByProduct
+	}
+	if groupBy != model.MarginByProduct && groupBy != model.MarginByCategory {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	margins, err := s.db.GetMargins(s.ctx, groupBy)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, margins)
+}
+
+func (s service) InventoryValuation() ([]byte, int) {
+	valuation, err := s.db.GetInventoryValuation(s.ctx)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, valuation)
+}
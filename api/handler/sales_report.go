@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/report"
+	"github.com/saptaka/pos/repository"
+	"github.com/saptaka/pos/utils"
+)
+
+type SalesReport interface {
+	XReport(shiftId int64, format string) ([]byte, int)
+	CreateZReport(format string) ([]byte, int)
+	DetailZReport(number int64, format string) ([]byte, int)
+	ListZReport(limit, skip int) ([]byte, int)
+}
+
+// XReport is a snapshot of a shift, by default the open one. It changes
+// nothing, so it can be taken as often as needed.
+func (s service) XReport(shiftId int64, format string) ([]byte, int) {
+	if !validReportFormat(format) {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	var shift model.Shift
+	var err error
+	if shiftId == 0 {
+		shift, err = s.db.GetOpenShift(s.ctx)
+	} else {
+		shift, err = s.db.GetShift(s.ctx, shiftId)
+	}
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	salesReport, err := s.db.GetShiftSalesReport(s.ctx, shift.ShiftId)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return s.renderReport(salesReport, format)
+}
+
+// CreateZReport closes the day under the next Z number. It needs every
+// shift closed and every pending payment settled first.
+func (s service) CreateZReport(format string) ([]byte, int) {
+	if !validReportFormat(format) {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	salesReport, err := s.db.CreateZReport(s.ctx)
+	if err == repository.ErrShiftOpen || err == repository.ErrPendingOrders {
+		return utils.ResponseWrapper(http.StatusConflict, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return s.renderReport(salesReport, format)
+}
+
+func (s service) DetailZReport(number int64, format string) ([]byte, int) {
+	if !validReportFormat(format) {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	salesReport, err := s.db.GetZReport(s.ctx, number)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return s.renderReport(salesReport, format)
+}
+
+func (s service) ListZReport(limit, skip int) ([]byte, int) {
+	reports, err := s.db.GetZReports(s.ctx, limit, skip)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	listZReport := model.ListZReport{
+		ZReports: reports,
+		Meta: model.Meta{
+			Total: len(reports),
+			Limit: limit,
+			Skip:  skip,
+		},
+	}
+	return utils.ResponseWrapper(http.StatusOK, listZReport)
+}
+
+func validReportFormat(format string) bool {
+	return format == model.ReportFormatJSON || format == model.ReportFormatText ||
+		format == model.ReportFormatPDF
+}
+
+// renderReport answers with a report in JSON, or laid out for printing.
+func (s service) renderReport(salesReport model.SalesReport, format string) ([]byte, int) {
+	switch format {
+	case model.ReportFormatText:
+		return report.Text(s.cfg.Store.Name, salesReport, s.cfg.Store.Location), http.StatusOK
+	case model.ReportFormatPDF:
+		file, err := report.PDF(s.cfg.Store.Name, salesReport, s.cfg.Store.Location)
+		if err != nil {
+			log.Println(err)
+			return utils.ResponseWrapper(http.StatusInternalServerError, nil)
+		}
+		return file, http.StatusOK
+	}
+	return utils.ResponseWrapper(http.StatusOK, salesReport)
+}
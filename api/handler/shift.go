@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/repository"
+	"github.com/saptaka/pos/utils"
+)
+
+type Shift interface {
+	ListShift(limit, skip int) ([]byte, int)
+	CurrentShift() ([]byte, int)
+	DetailShift(id int64) ([]byte, int)
+	OpenShift(request model.OpenShiftRequest) ([]byte, int)
+	CreateShiftMovement(movement model.ShiftMovement) ([]byte, int)
+	CloseShift(id int64, request model.CloseShiftRequest) ([]byte, int)
+}
+
+func (s service) ListShift(limit, skip int) ([]byte, int) {
+	shifts, err := s.db.GetShifts(s.ctx, limit, skip)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	listShift := model.ListShift{
+		Shifts: shifts,
+		Meta: model.Meta{
+			Total: len(shifts),
+			Limit: limit,
+			Skip:  skip,
+		},
+	}
+	return utils.ResponseWrapper(http.StatusOK, listShift)
+}
+
+func (s service) CurrentShift() ([]byte, int) {
+	shift, err := s.db.GetOpenShift(s.ctx)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return s.shiftDetail(shift)
+}
+
+func (s service) DetailShift(id int64) ([]byte, int) {
+	shift, err := s.db.GetShift(s.ctx, id)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return s.shiftDetail(shift)
+}
+
+// shiftDetail shows a shift with its movements and, once it is closed, the
+// expected and counted amount per payment type. An open shift hides what
+// the drawer should hold, so the closing count stays blind.
+func (s service) shiftDetail(shift model.Shift) ([]byte, int) {
+	movements, err := s.db.GetShiftMovements(s.ctx, shift.ShiftId)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	detail := model.ShiftDetail{
+		Shift:     shift,
+		Movements: movements,
+	}
+	if shift.Status == model.ShiftClosed {
+		detail.Tenders, err = s.db.GetShiftTenders(s.ctx, shift.ShiftId)
+		if err != nil {
+			log.Println(err)
+			return utils.ResponseWrapper(http.StatusBadRequest, nil)
+		}
+		for _, tender := range detail.Tenders {
+			s.normalizeTenders(tender.Foreign)
+		}
+	}
+	return utils.ResponseWrapper(http.StatusOK, detail)
+}
+
+// OpenShift opens the drawer with the cash float counted into it. Only one
+// shift is open at a time.
+func (s service) OpenShift(request model.OpenShiftRequest) ([]byte, int) {
+	err := s.validation.Struct(request)
+	if err != nil {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	validCashier, err := s.validCashier(&request.CashierId)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	if !validCashier {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	shift, err := s.db.OpenShift(s.ctx, model.Shift{
+		CashierId:    request.CashierId,
+		OpeningFloat: request.OpeningFloat,
+	})
+	if err == repository.ErrShiftOpen {
+		return utils.ResponseWrapper(http.StatusConflict, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, shift)
+}
+
+// CreateShiftMovement records a pay-in, pay-out or cash drop on an open
+// shift.
+func (s service) CreateShiftMovement(movement model.ShiftMovement) ([]byte, int) {
+	err := s.validation.Struct(movement)
+	if err != nil || !model.MovementType[movement.Type] {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	validCashier, err := s.validCashier(&movement.CashierId)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	if !validCashier {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	_, err = s.db.GetShift(s.ctx, movement.ShiftId)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	movement, err = s.db.CreateShiftMovement(s.ctx, movement)
+	if err == repository.ErrShiftClosed {
+		return utils.ResponseWrapper(http.StatusConflict, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, movement)
+}
+
+// CloseShift closes a shift with the cashier's blind count and answers
+// with what each payment type was expected to hold against what was
+// counted.
+func (s service) CloseShift(id int64, request model.CloseShiftRequest) ([]byte, int) {
+	err := s.validation.Struct(request)
+	if err != nil {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	validCashier, err := s.validCashier(&request.CashierId)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	if !validCashier {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	_, err = s.db.GetShift(s.ctx, id)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	shift, err := s.db.CloseShift(s.ctx, id, request.CashierId, request.Counts)
+	if err == repository.ErrShiftClosed {
+		return utils.ResponseWrapper(http.StatusConflict, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return s.shiftDetail(shift)
+}
@@ -0,0 +1,245 @@
+package handler
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/repository"
+	"github.com/saptaka/pos/tax"
+	"github.com/saptaka/pos/utils"
+)
+
+type Tax interface {
+	ListTaxClass(limit, skip int) ([]byte, int)
+	DetailTaxClass(id int64) ([]byte, int)
+	CreateTaxClass(taxClass model.TaxClass) ([]byte, int)
+	UpdateTaxClass(taxClass model.TaxClass) ([]byte, int)
+	DeleteTaxClass(id int64) ([]byte, int)
+	SetProductTaxClass(productId int64, taxClassId *int64) ([]byte, int)
+	SetCategoryTaxClass(categoryId int64, taxClassId *int64) ([]byte, int)
+	TaxSummary(from, to *time.Time) ([]byte, int)
+}
+
+func (s service) ListTaxClass(limit, skip int) ([]byte, int) {
+	taxClasses, err := s.db.GetTaxClasses(s.ctx, limit, skip, false)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	listTaxClass := model.ListTaxClass{
+		TaxClasses: taxClasses,
+		Meta: model.Meta{
+			Total: len(taxClasses),
+			Limit: limit,
+			Skip:  skip,
+		},
+	}
+	return utils.ResponseWrapper(http.StatusOK, listTaxClass)
+}
+
+func (s service) DetailTaxClass(id int64) ([]byte, int) {
+	taxClass, err := s.db.GetTaxClassByID(s.ctx, id)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, taxClass)
+}
+
+func (s service) CreateTaxClass(taxClass model.TaxClass) ([]byte, int) {
+	err := s.validation.Struct(taxClass)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	taxClass, err = s.db.CreateTaxClass(s.ctx, taxClass)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, taxClass)
+}
+
+func (s service) UpdateTaxClass(taxClass model.TaxClass) ([]byte, int) {
+	err := s.validation.Struct(taxClass)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	err = s.db.UpdateTaxClass(s.ctx, taxClass)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, nil)
+}
+
+func (s service) DeleteTaxClass(id int64) ([]byte, int) {
+	err := s.db.DeleteTaxClass(s.ctx, id)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err == repository.ErrReferenced {
+		return utils.ResponseWrapper(http.StatusConflict, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, nil)
+}
+
+func (s service) SetProductTaxClass(productId int64, taxClassId *int64) ([]byte, int) {
+	err := s.db.SetProductTaxClass(s.ctx, productId, taxClassId)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	return s.refreshProduct(productId)
+}
+
+func (s service) SetCategoryTaxClass(categoryId int64, taxClassId *int64) ([]byte, int) {
+	err := s.db.SetCategoryTaxClass(s.ctx, categoryId, taxClassId)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	s.reloadProductCache()
+	return utils.ResponseWrapper(http.StatusOK, nil)
+}
+
+func (s service) TaxSummary(from, to *time.Time) ([]byte, int) {
+	taxes, err := s.db.GetTaxSummary(s.ctx, from, to)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	summary := model.TaxSummary{
+		From:  from,
+		To:    to,
+		Taxes: taxes,
+	}
+	for _, orderTax := range taxes {
+		summary.TotalTaxable += orderTax.TaxableAmount
+		summary.TotalTax += orderTax.TaxAmount
+	}
+	return utils.ResponseWrapper(http.StatusOK, summary)
+}
+
+// applyTaxes taxes the priced lines, after every discount, and returns the
+// tax per class and in total. Order level discounts lower the taxed amounts
+// of all lines.
+func (s service) applyTaxes(details []model.SubOrderedProductDetail,
+	orderDiscount model.Money) ([]model.OrderTax, model.Money, error) {
+
+	taxClasses, err := s.db.GetTaxClasses(s.ctx, 0, 0, true)
+	if err != nil {
+		return nil, 0, err
+	}
+	mapTaxClass := make(map[int64]model.TaxClass)
+	for _, taxClass := range taxClasses {
+		mapTaxClass[taxClass.TaxClassId] = taxClass
+	}
+
+	lines := make([]tax.Line, 0, len(details))
+	for index, detail := range details {
+		if detail.TaxClassId != nil {
+			details[index].TaxRate = mapTaxClass[*detail.TaxClassId].Rate
+		}
+		lines = append(lines, tax.Line{
+			Rate:   details[index].TaxRate,
+			Amount: detail.TotalFinalPrice,
+		})
+	}
+
+	result := tax.Compute(lines, orderDiscount, s.cfg.Store.TaxInclusive)
+	taxes := make([]model.OrderTax, 0)
+	for index, lineTax := range result.Lines {
+		details[index].TaxableAmount = lineTax.Taxable
+		details[index].TaxAmount = lineTax.Tax
+		if details[index].TaxClassId != nil {
+			taxClass := mapTaxClass[*details[index].TaxClassId]
+			taxes = addOrderTax(taxes, details[index].TaxClassId, taxClass.Name,
+				details[index].TaxRate, lineTax.Taxable, lineTax.Tax)
+		}
+	}
+	return taxes, result.Tax, nil
+}
+
+// orderTaxes rebuilds the tax per class of a stored order.
+func (s service) orderTaxes(orderedProducts []model.OrderedProductDetail) ([]model.OrderTax, error) {
+	taxClasses, err := s.db.GetTaxClasses(s.ctx, 0, 0, true)
+	if err != nil {
+		return nil, err
+	}
+	mapTaxClass := make(map[int64]model.TaxClass)
+	for _, taxClass := range taxClasses {
+		mapTaxClass[taxClass.TaxClassId] = taxClass
+	}
+
+	taxes := make([]model.OrderTax, 0)
+	for _, orderedProduct := range orderedProducts {
+		if orderedProduct.TaxClassId == nil {
+			continue
+		}
+		taxes = addOrderTax(taxes, orderedProduct.TaxClassId,
+			mapTaxClass[*orderedProduct.TaxClassId].Name, orderedProduct.TaxRate,
+			orderedProduct.TaxableAmount, orderedProduct.TaxAmount)
+	}
+	return taxes, nil
+}
+
+func addOrderTax(taxes []model.OrderTax, taxClassId *int64, name string,
+	rate float64, taxable, amount model.Money) []model.OrderTax {
+
+	for index, orderTax := range taxes {
+		if *orderTax.TaxClassId == *taxClassId && orderTax.Rate == rate {
+			taxes[index].TaxableAmount += taxable
+			taxes[index].TaxAmount += amount
+			return taxes
+		}
+	}
+	return append(taxes, model.OrderTax{
+		TaxClassId:    taxClassId,
+		Name:          name,
+		Rate:          rate,
+		TaxableAmount: taxable,
+		TaxAmount:     amount,
+	})
+}
+
+// taxClassId is the tax class a product is taxed by: its own, or else its
+// category's.
+func taxClassId(product model.Product) *int64 {
+	if product.TaxClassId != nil {
+		return product.TaxClassId
+	}
+	if product.Category != nil {
+		return product.Category.TaxClassId
+	}
+	return nil
+}
+
+// exclusiveTax is the tax to add on top of the prices, none when they
+// include it.
+func exclusiveTax(inclusive bool, totalTax model.Money) model.Money {
+	if inclusive {
+		return 0
+	}
+	return totalTax
+}
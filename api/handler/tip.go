@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/utils"
+)
+
+type Tip interface {
+	TipReport(from, to *time.Time) ([]byte, int)
+}
+
+// TipReport totals the service charge and tips per cashier so they can be
+// distributed.
+func (s service) TipReport(from, to *time.Time) ([]byte, int) {
+	cashiers, err := s.db.GetTipReport(s.ctx, from, to)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	report := model.TipReport{
+		From:     from,
+		To:       to,
+		Cashiers: cashiers,
+	}
+	for _, cashier := range cashiers {
+		report.TotalServiceCharge += cashier.ServiceCharge
+		report.TotalTip += cashier.Tip
+	}
+	return utils.ResponseWrapper(http.StatusOK, report)
+}
+
+// serviceCharge is the configured percentage of the order total. The total
+// carries its tax, inclusive or exclusive, which is taken back out unless
+// the charge is applied after tax.
+func (s service) serviceCharge(total, totalTax model.Money) model.Money {
+	percent := s.cfg.Store.ServiceChargePercent
+	if percent <= 0 {
+		return 0
+	}
+	base := total
+	if !s.cfg.Store.ServiceChargeAfterTax {
+		base -= totalTax
+	}
+	return base.Percent(percent)
+}
+
+// validCashier reports whether the cashier exists and is not archived. A nil
+// cashier passes; callers that need one refuse it themselves.
+func (s service) validCashier(cashierId *int64) (bool, error) {
+	if cashierId == nil {
+		return true, nil
+	}
+	cashier, err := s.db.GetCashierByID(s.ctx, *cashierId)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return cashier.ArchivedAt == nil, nil
+}
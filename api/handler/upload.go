@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"net/http"
+
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/storage"
+	"github.com/saptaka/pos/utils"
+)
+
+const maxImagePixels = 40000000
+
+type Upload interface {
+	UploadProductImage(id int64, data []byte) ([]byte, int)
+	UploadPaymentLogo(id int64, data []byte) ([]byte, int)
+	File(key string) (storage.Object, int)
+}
+
+func (s service) UploadProductImage(id int64, data []byte) ([]byte, int) {
+	_, err := s.db.GetProductByID(s.ctx, id)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+
+	upload, statusCode := s.saveImage(fmt.Sprintf("products/%d", id), data)
+	if statusCode != http.StatusOK {
+		return utils.ResponseWrapper(statusCode, nil)
+	}
+
+	err = s.db.UpdateProductImage(s.ctx, id, upload.Url, upload.ThumbnailUrl)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+
+	product, err := s.db.GetProductByID(s.ctx, id)
+	if err == nil {
+		productCache.Set(product.ProductId, product)
+	}
+
+	return utils.ResponseWrapper(http.StatusOK, upload)
+}
+
+func (s service) UploadPaymentLogo(id int64, data []byte) ([]byte, int) {
+	_, err := s.db.GetPaymentByID(s.ctx, id)
+	if err == sql.ErrNoRows {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+
+	upload, statusCode := s.saveImage(fmt.Sprintf("payments/%d", id), data)
+	if statusCode != http.StatusOK {
+		return utils.ResponseWrapper(statusCode, nil)
+	}
+
+	err = s.db.UpdatePaymentLogo(s.ctx, id, upload.Url, upload.ThumbnailUrl)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+
+	return utils.ResponseWrapper(http.StatusOK, upload)
+}
+
+func (s service) File(key string) (storage.Object, int) {
+	object, err := s.storage.Get(s.ctx, key)
+	if err == storage.ErrNotFound {
+		return object, http.StatusNotFound
+	}
+	if err != nil {
+		log.Println(err)
+		return object, http.StatusBadRequest
+	}
+	return object, http.StatusOK
+}
+
+// saveImage validates an uploaded image and stores it together with its
+// thumbnail. Keys are derived from the content hash so stored files never
+// change and can be cached indefinitely.
+func (s service) saveImage(prefix string, data []byte) (model.Upload, int) {
+	var upload model.Upload
+	if int64(len(data)) > s.cfg.Store.MaxUploadSize {
+		return upload, http.StatusRequestEntityTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	extension, ok := model.UploadContentType[contentType]
+	if !ok {
+		return upload, http.StatusUnsupportedMediaType
+	}
+
+	imageConfig, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || imageConfig.Width*imageConfig.Height > maxImagePixels {
+		return upload, http.StatusBadRequest
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return upload, http.StatusBadRequest
+	}
+
+	var thumbnail bytes.Buffer
+	thumbnailImage := utils.Thumbnail(img, s.cfg.Store.ThumbnailSize)
+	thumbnailType, thumbnailExtension := "image/png", ".png"
+	if contentType == "image/jpeg" {
+		thumbnailType, thumbnailExtension = contentType, extension
+		err = jpeg.Encode(&thumbnail, thumbnailImage, &jpeg.Options{Quality: 85})
+	} else {
+		err = png.Encode(&thumbnail, thumbnailImage)
+	}
+	if err != nil {
+		log.Println(err)
+		return upload, http.StatusBadRequest
+	}
+
+	hash := fmt.Sprintf("%x", sha256.Sum256(data))
+	key := fmt.Sprintf("%s/%s%s", prefix, hash, extension)
+	thumbnailKey := fmt.Sprintf("%s/%s_thumb%s", prefix, hash, thumbnailExtension)
+
+	err = s.storage.Put(s.ctx, key, data, contentType)
+	if err != nil {
+		log.Println(err)
+		return upload, http.StatusInternalServerError
+	}
+	err = s.storage.Put(s.ctx, thumbnailKey, thumbnail.Bytes(), thumbnailType)
+	if err != nil {
+		log.Println(err)
+		return upload, http.StatusInternalServerError
+	}
+
+	upload = model.Upload{
+		Url:          "/files/" + key,
+		ThumbnailUrl: "/files/" + thumbnailKey,
+		ContentType:  contentType,
+		Size:         len(data),
+	}
+	return upload, http.StatusOK
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/saptaka/pos/gateway"
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/repository"
+	"github.com/saptaka/pos/utils"
+)
+
+type Webhook interface {
+	PaymentWebhook(provider, timestamp, signature string, body []byte) ([]byte, int)
+	ListPaymentEvent(receiptId string, limit, skip int) ([]byte, int)
+}
+
+// PaymentWebhook takes a provider's signed notice that the payment of an
+// order was made or failed. The raw payload is kept whether or not it
+// can be applied. An event delivered again is acknowledged without being
+// applied twice, unless an error kept it from being applied before.
+func (s service) PaymentWebhook(providerName, timestamp, signature string, body []byte) ([]byte, int) {
+	provider, ok := s.gateways.Get(providerName)
+	secret := s.cfg.Store.WebhookSecrets[providerName]
+	if !ok || secret == "" {
+		return utils.ResponseWrapper(http.StatusNotFound, nil)
+	}
+	err := gateway.VerifySignature(secret, timestamp, signature, body, time.Now())
+	if err != nil {
+		log.Println(providerName, err)
+		return utils.ResponseWrapper(http.StatusUnauthorized, nil)
+	}
+
+	var event model.PaymentEvent
+	err = json.Unmarshal(body, &event)
+	if err != nil {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	err = s.validation.Struct(event)
+	if err != nil || (event.Status != model.PaymentEventPaid && event.Status != model.PaymentEventFailed) {
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+
+	record, err := s.db.CreatePaymentEvent(s.ctx, model.PaymentEventRecord{
+		Provider:       providerName,
+		EventId:        event.EventId,
+		OrderReference: event.OrderReference,
+		Status:         event.Status,
+		Payload:        string(body),
+		Signature:      signature,
+	})
+	if err == repository.ErrDuplicateEvent {
+		record, err = s.db.GetPaymentEvent(s.ctx, providerName, event.EventId)
+		if err == nil && !failedEvent(record) {
+			record.Duplicate = true
+			return utils.ResponseWrapper(http.StatusOK, record)
+		}
+	}
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+
+	order, statusCode := s.confirmPayment(provider, gateway.Callback{
+		OrderReference: event.OrderReference,
+		Amount:         event.Amount,
+		Paid:           event.Status == model.PaymentEventPaid,
+	})
+	record.Result = order.Status
+	if statusCode != http.StatusOK {
+		record.Result = http.StatusText(statusCode)
+	}
+	err = s.db.UpdatePaymentEventResult(s.ctx, record.PaymentEventId, record.Result)
+	if err != nil {
+		log.Println(err)
+	}
+	if statusCode != http.StatusOK {
+		return utils.ResponseWrapper(statusCode, nil)
+	}
+	return utils.ResponseWrapper(http.StatusOK, record)
+}
+
+// failedEvent reports whether a stored event was left unapplied by an
+// error, so a redelivery of it is processed again. Its result is then an
+// error text rather than the order state it left; an event still being
+// processed has no result yet.
+func failedEvent(record model.PaymentEventRecord) bool {
+	switch record.Result {
+	case "", model.OrderPaid, model.OrderPendingPayment, model.OrderPaymentFailed,
+		model.OrderVoided, model.OrderRefunded:
+		return false
+	}
+	return true
+}
+
+// ListPaymentEvent lists the webhooks received, for one order when a
+// receipt ID is given.
+func (s service) ListPaymentEvent(receiptId string, limit, skip int) ([]byte, int) {
+	events, err := s.db.GetPaymentEvents(s.ctx, receiptId, limit, skip)
+	if err != nil {
+		log.Println(err)
+		return utils.ResponseWrapper(http.StatusBadRequest, nil)
+	}
+	listPaymentEvent := model.ListPaymentEvent{
+		PaymentEvents: events,
+		Meta: model.Meta{
+			Total: len(events),
+			Limit: limit,
+			Skip:  skip,
+		},
+	}
+	return utils.ResponseWrapper(http.StatusOK, listPaymentEvent)
+}
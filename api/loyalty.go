@@ -0,0 +1,131 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/utils"
+)
+
+type LoyaltyRouter interface {
+	ListLoyaltyRule(res http.ResponseWriter, req *http.Request)
+	CreateLoyaltyRule(res http.ResponseWriter, req *http.Request)
+	UpdateLoyaltyRule(res http.ResponseWriter, req *http.Request)
+	DeleteLoyaltyRule(res http.ResponseWriter, req *http.Request)
+	CustomerPoints(res http.ResponseWriter, req *http.Request)
+	RouteLoyaltyPath()
+}
+
+func (r *router) RouteLoyaltyPath() {
+	r.mux.HandleFunc("/loyalty-rules", middleware(r.ListLoyaltyRule)).Methods("GET")
+	r.mux.HandleFunc("/loyalty-rules", middleware(r.CreateLoyaltyRule)).Methods("POST")
+	r.mux.HandleFunc("/loyalty-rules/{loyaltyRuleId}", middleware(r.UpdateLoyaltyRule)).Methods("PUT")
+	r.mux.HandleFunc("/loyalty-rules/{loyaltyRuleId}", middleware(r.DeleteLoyaltyRule)).Methods("DELETE")
+	r.mux.HandleFunc("/customers/{customerId}/points", middleware(r.CustomerPoints)).Methods("GET")
+}
+
+func (r *router) ListLoyaltyRule(res http.ResponseWriter, req *http.Request) {
+	response, statusCode := r.handlerService.ListLoyaltyRule()
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) CreateLoyaltyRule(res http.ResponseWriter, req *http.Request) {
+	var rule model.LoyaltyRule
+	err := json.NewDecoder(req.Body).Decode(&rule)
+	if err != nil {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.CreateLoyaltyRule(rule)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) UpdateLoyaltyRule(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["loyaltyRuleId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusNotFound, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	var rule model.LoyaltyRule
+	err := json.NewDecoder(req.Body).Decode(&rule)
+	if err != nil {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	rule.LoyaltyRuleId = id
+	response, statusCode := r.handlerService.UpdateLoyaltyRule(rule)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) DeleteLoyaltyRule(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["loyaltyRuleId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusNotFound, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.DeleteLoyaltyRule(id)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) CustomerPoints(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["customerId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusNotFound, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	limitQuery := req.URL.Query().Get("limit")
+	skipQuery := req.URL.Query().Get("skip")
+	limit, _ := strconv.Atoi(limitQuery)
+	skip, _ := strconv.Atoi(skipQuery)
+	response, statusCode := r.handlerService.CustomerPoints(id, limit, skip)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
@@ -81,15 +81,15 @@ func (r *router) DetailOrder(res http.ResponseWriter, req *http.Request) {
 }
 
 func (r *router) SubTotalOrder(res http.ResponseWriter, req *http.Request) {
-	var orderedProducts []model.OrderedProduct
-	err := json.NewDecoder(req.Body).Decode(&orderedProducts)
+	var subTotalRequest model.SubTotalRequest
+	err := json.NewDecoder(req.Body).Decode(&subTotalRequest)
 	if err != nil {
 		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
 		res.WriteHeader(statusCode)
 		res.Write(response)
 		return
 	}
-	if len(orderedProducts) == 0 {
+	if len(subTotalRequest.OrderedProduct) == 0 {
 		response, _ := utils.ResponseWrapper(http.StatusBadRequest,
 			model.ErrorData{
 				Message: "\"value\" must be an array",
@@ -103,7 +103,7 @@ func (r *router) SubTotalOrder(res http.ResponseWriter, req *http.Request) {
 		res.Write(response)
 		return
 	}
-	response, statusCode := r.handlerService.SubTotalOrder(orderedProducts)
+	response, statusCode := r.handlerService.SubTotalOrder(subTotalRequest)
 	if statusCode != http.StatusOK {
 		res.WriteHeader(statusCode)
 		res.Write(response)
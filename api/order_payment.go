@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/utils"
+)
+
+type OrderPaymentRouter interface {
+	PaymentStatus(res http.ResponseWriter, req *http.Request)
+	VoidOrder(res http.ResponseWriter, req *http.Request)
+	RefundOrder(res http.ResponseWriter, req *http.Request)
+	RouteOrderPaymentPath()
+}
+
+func (r *router) RouteOrderPaymentPath() {
+	r.mux.HandleFunc("/orders/{orderId}/payment", middleware(r.PaymentStatus)).Methods("GET")
+	r.mux.HandleFunc("/orders/{orderId}/void", middleware(r.VoidOrder)).Methods("POST")
+	r.mux.HandleFunc("/orders/{orderId}/refund", middleware(r.RefundOrder)).Methods("POST")
+}
+
+func (r *router) PaymentStatus(res http.ResponseWriter, req *http.Request) {
+	r.orderPayment(res, req, r.handlerService.PaymentStatus)
+}
+
+func (r *router) VoidOrder(res http.ResponseWriter, req *http.Request) {
+	r.orderPayment(res, req, r.handlerService.VoidOrder)
+}
+
+// RefundOrder takes an optional body; without one the order is refunded
+// through its payment provider.
+func (r *router) RefundOrder(res http.ResponseWriter, req *http.Request) {
+	var request model.RefundRequest
+	err := json.NewDecoder(req.Body).Decode(&request)
+	if err != nil && err != io.EOF {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	r.orderPayment(res, req, func(id int64) ([]byte, int) {
+		return r.handlerService.RefundOrder(id, request)
+	})
+}
+
+func (r *router) orderPayment(res http.ResponseWriter, req *http.Request,
+	handle func(id int64) ([]byte, int)) {
+	params := mux.Vars(req)
+	idParams := params["orderId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusNotFound, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := handle(id)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
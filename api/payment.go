@@ -16,6 +16,7 @@ type PaymentRouter interface {
 	CreatePayment(res http.ResponseWriter, req *http.Request)
 	UpdatePayment(res http.ResponseWriter, req *http.Request)
 	DeletePayment(res http.ResponseWriter, req *http.Request)
+	RestorePayment(res http.ResponseWriter, req *http.Request)
 	RoutePaymentPath()
 }
 
@@ -25,6 +26,7 @@ func (r *router) RoutePaymentPath() {
 	r.mux.HandleFunc("/payments", r.CreatePayment).Methods("POST")
 	r.mux.HandleFunc("/payments/{paymentId}", r.UpdatePayment).Methods("PUT")
 	r.mux.HandleFunc("/payments/{paymentId}", r.DeletePayment).Methods("DELETE")
+	r.mux.HandleFunc("/payments/{paymentId}/restore", r.RestorePayment).Methods("POST")
 }
 
 func (r *router) ListPayment(res http.ResponseWriter, req *http.Request) {
@@ -132,3 +134,23 @@ func (r *router) DeletePayment(res http.ResponseWriter, req *http.Request) {
 	res.Header().Set("Content-Type", "application/json")
 	res.Write(response)
 }
+
+func (r *router) RestorePayment(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["paymentId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusNotFound, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.RestorePayment(id)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
@@ -2,8 +2,12 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/saptaka/pos/model"
@@ -16,15 +20,23 @@ type ProductRouter interface {
 	CreateProduct(res http.ResponseWriter, req *http.Request)
 	UpdateProduct(res http.ResponseWriter, req *http.Request)
 	DeleteProduct(res http.ResponseWriter, req *http.Request)
+	RestoreProduct(res http.ResponseWriter, req *http.Request)
+	ReceiveGoods(res http.ResponseWriter, req *http.Request)
+	ImportProducts(res http.ResponseWriter, req *http.Request)
+	ExportProducts(res http.ResponseWriter, req *http.Request)
 	RouteProductPath()
 }
 
 func (r *router) RouteProductPath() {
 	r.mux.HandleFunc("/products", middleware(r.ListProduct)).Methods("GET")
+	r.mux.HandleFunc("/products/export", middleware(r.ExportProducts)).Methods("GET")
+	r.mux.HandleFunc("/products/import", middleware(r.ImportProducts)).Methods("POST")
 	r.mux.HandleFunc("/products/{productId}", middleware(r.DetailProduct)).Methods("GET")
 	r.mux.HandleFunc("/products", r.CreateProduct).Methods("POST")
 	r.mux.HandleFunc("/products/{productId}", (r.UpdateProduct)).Methods("PUT")
 	r.mux.HandleFunc("/products/{productId}", r.DeleteProduct).Methods("DELETE")
+	r.mux.HandleFunc("/products/{productId}/restore", r.RestoreProduct).Methods("POST")
+	r.mux.HandleFunc("/products/{productId}/receipts", middleware(r.ReceiveGoods)).Methods("POST")
 }
 
 func (r *router) ListProduct(res http.ResponseWriter, req *http.Request) {
@@ -145,3 +157,117 @@ func (r *router) DeleteProduct(res http.ResponseWriter, req *http.Request) {
 	res.Header().Set("Content-Type", "application/json")
 	res.Write(response)
 }
+
+func (r *router) ReceiveGoods(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["productId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	var receipt model.GoodsReceipt
+	err := json.NewDecoder(req.Body).Decode(&receipt)
+	if err != nil {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	receipt.ProductId = id
+	response, statusCode := r.handlerService.ReceiveGoods(receipt)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+const maxImportSize = 10 << 20
+
+func (r *router) ImportProducts(res http.ResponseWriter, req *http.Request) {
+	err := req.ParseMultipartForm(maxImportSize)
+	if err != nil {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	file, header, err := req.FormFile("file")
+	if err != nil {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxImportSize))
+	if err != nil {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+
+	format := req.URL.Query().Get("format")
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+	}
+	dryRun, _ := strconv.ParseBool(req.URL.Query().Get("dryRun"))
+
+	response, statusCode := r.handlerService.ImportProducts(format, data, dryRun)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) ExportProducts(res http.ResponseWriter, req *http.Request) {
+	format := req.URL.Query().Get("format")
+	if format == "" {
+		format = model.ImportFormatCSV
+	}
+	response, statusCode := r.handlerService.ExportProducts(format)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+
+	contentType := "text/csv"
+	if format == model.ImportFormatXLSX {
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	res.Header().Set("Content-Type", contentType)
+	res.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=\"products.%s\"", format))
+	res.Write(response)
+}
+
+func (r *router) RestoreProduct(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["productId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusNotFound, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.RestoreProduct(id)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/utils"
+)
+
+type PromotionRouter interface {
+	ListPromotion(res http.ResponseWriter, req *http.Request)
+	DetailPromotion(res http.ResponseWriter, req *http.Request)
+	CreatePromotion(res http.ResponseWriter, req *http.Request)
+	UpdatePromotion(res http.ResponseWriter, req *http.Request)
+	DeletePromotion(res http.ResponseWriter, req *http.Request)
+	ActivePromotion(res http.ResponseWriter, req *http.Request)
+	RoutePromotionPath()
+}
+
+func (r *router) RoutePromotionPath() {
+	r.mux.HandleFunc("/promotions", middleware(r.ListPromotion)).Methods("GET")
+	r.mux.HandleFunc("/promotions/active", middleware(r.ActivePromotion)).Methods("GET")
+	r.mux.HandleFunc("/promotions/{promotionId}", middleware(r.DetailPromotion)).Methods("GET")
+	r.mux.HandleFunc("/promotions", middleware(r.CreatePromotion)).Methods("POST")
+	r.mux.HandleFunc("/promotions/{promotionId}", middleware(r.UpdatePromotion)).Methods("PUT")
+	r.mux.HandleFunc("/promotions/{promotionId}", middleware(r.DeletePromotion)).Methods("DELETE")
+}
+
+func (r *router) ListPromotion(res http.ResponseWriter, req *http.Request) {
+	limitQuery := req.URL.Query().Get("limit")
+	skipQuery := req.URL.Query().Get("skip")
+	limit, _ := strconv.Atoi(limitQuery)
+	skip, _ := strconv.Atoi(skipQuery)
+	response, statusCode := r.handlerService.ListPromotion(limit, skip)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) DetailPromotion(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["promotionId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.DetailPromotion(id)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) CreatePromotion(res http.ResponseWriter, req *http.Request) {
+	var promotion model.Promotion
+	err := json.NewDecoder(req.Body).Decode(&promotion)
+	if err != nil {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.CreatePromotion(promotion)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) UpdatePromotion(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["promotionId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusNotFound, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	var promotion model.Promotion
+	err := json.NewDecoder(req.Body).Decode(&promotion)
+	if err != nil {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	promotion.PromotionId = id
+	response, statusCode := r.handlerService.UpdatePromotion(promotion)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) DeletePromotion(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["promotionId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusNotFound, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.DeletePromotion(id)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) ActivePromotion(res http.ResponseWriter, req *http.Request) {
+	at := time.Now()
+	if atQuery := req.URL.Query().Get("at"); atQuery != "" {
+		timestamp, err := model.ParseTimestamp(atQuery)
+		if err != nil {
+			response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+			res.WriteHeader(statusCode)
+			res.Write(response)
+			return
+		}
+		at = timestamp.Time
+	}
+	response, statusCode := r.handlerService.ActivePromotion(at)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/utils"
+)
+
+type QRISRouter interface {
+	QRISCode(res http.ResponseWriter, req *http.Request)
+	QRISCallback(res http.ResponseWriter, req *http.Request)
+	RouteQRISPath()
+}
+
+func (r *router) RouteQRISPath() {
+	r.mux.HandleFunc("/orders/{orderId}/qris", middleware(r.QRISCode)).Methods("GET")
+	// Called by the acquirer, which authenticates with the callback token.
+	r.mux.HandleFunc("/payments/qris/callback", r.QRISCallback).Methods("POST")
+}
+
+func (r *router) QRISCode(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["orderId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusNotFound, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	format := req.URL.Query().Get("format")
+	response, statusCode := r.handlerService.QRISCode(id, format)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+
+	contentType := "application/json"
+	switch format {
+	case model.QRISFormatPNG:
+		contentType = "image/png"
+	case model.QRISFormatSVG:
+		contentType = "image/svg+xml"
+	}
+	res.Header().Set("Content-Type", contentType)
+	res.Header().Set("Cache-Control", "no-store")
+	res.Write(response)
+}
+
+func (r *router) QRISCallback(res http.ResponseWriter, req *http.Request) {
+	var callback model.QRISCallback
+	err := json.NewDecoder(req.Body).Decode(&callback)
+	if err != nil {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	token := req.Header.Get("X-Callback-Token")
+	response, statusCode := r.handlerService.QRISCallback(token, callback)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
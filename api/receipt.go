@@ -0,0 +1,115 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/utils"
+)
+
+type ReceiptRouter interface {
+	SendReceipt(res http.ResponseWriter, req *http.Request)
+	OrderReceipts(res http.ResponseWriter, req *http.Request)
+	ListReceiptMessage(res http.ResponseWriter, req *http.Request)
+	RetryReceiptMessage(res http.ResponseWriter, req *http.Request)
+	RouteReceiptPath()
+}
+
+func (r *router) RouteReceiptPath() {
+	r.mux.HandleFunc("/orders/{orderId}/receipt", middleware(r.SendReceipt)).Methods("POST")
+	r.mux.HandleFunc("/orders/{orderId}/receipt", middleware(r.OrderReceipts)).Methods("GET")
+	r.mux.HandleFunc("/receipts", middleware(r.ListReceiptMessage)).Methods("GET")
+	r.mux.HandleFunc("/receipts/{receiptMessageId}/retry", middleware(r.RetryReceiptMessage)).Methods("POST")
+}
+
+// SendReceipt takes an optional body; without one the receipt goes to the
+// order's customer.
+func (r *router) SendReceipt(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["orderId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusNotFound, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	var request model.ReceiptRequest
+	err := json.NewDecoder(req.Body).Decode(&request)
+	if err != nil && err != io.EOF {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.SendReceipt(id, request)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) OrderReceipts(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["orderId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusNotFound, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.ListReceiptMessage(0, 0, id, "")
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) ListReceiptMessage(res http.ResponseWriter, req *http.Request) {
+	limitQuery := req.URL.Query().Get("limit")
+	skipQuery := req.URL.Query().Get("skip")
+	orderQuery := req.URL.Query().Get("orderId")
+	limit, _ := strconv.Atoi(limitQuery)
+	skip, _ := strconv.Atoi(skipQuery)
+	orderId, _ := strconv.ParseInt(orderQuery, 10, 0)
+	status := req.URL.Query().Get("status")
+	response, statusCode := r.handlerService.ListReceiptMessage(limit, skip, orderId, status)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) RetryReceiptMessage(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["receiptMessageId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusNotFound, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.RetryReceiptMessage(id)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
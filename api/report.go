@@ -2,17 +2,29 @@ package api
 
 import (
 	"net/http"
+	"time"
+
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/utils"
 )
 
 type ReportRouter interface {
 	Revenue(res http.ResponseWriter, req *http.Request)
 	Solds(res http.ResponseWriter, req *http.Request)
+	Margin(res http.ResponseWriter, req *http.Request)
+	InventoryValuation(res http.ResponseWriter, req *http.Request)
+	DiscountAudit(res http.ResponseWriter, req *http.Request)
+	TipReport(res http.ResponseWriter, req *http.Request)
 	RouteReportPath()
 }
 
 func (r *router) RouteReportPath() {
 	r.mux.HandleFunc("/revenues", middleware(r.Revenue)).Methods("GET")
 	r.mux.HandleFunc("/solds", middleware(r.Solds)).Methods("GET")
+	r.mux.HandleFunc("/margins", middleware(r.Margin)).Methods("GET")
+	r.mux.HandleFunc("/inventory-valuation", middleware(r.InventoryValuation)).Methods("GET")
+	r.mux.HandleFunc("/discount-audit", middleware(r.DiscountAudit)).Methods("GET")
+	r.mux.HandleFunc("/tips", middleware(r.TipReport)).Methods("GET")
 }
 
 func (r *router) Revenue(res http.ResponseWriter, req *http.Request) {
@@ -37,3 +49,78 @@ func (r *router) Solds(res http.ResponseWriter, req *http.Request) {
 	res.Header().Set("Content-Type", "application/json")
 	res.Write(response)
 }
+
+func (r *router) Margin(res http.ResponseWriter, req *http.Request) {
+	groupBy := req.URL.Query().Get("groupBy")
+	response, statusCode := r.handlerService.Margin(groupBy)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) InventoryValuation(res http.ResponseWriter, req *http.Request) {
+	response, statusCode := r.handlerService.InventoryValuation()
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) DiscountAudit(res http.ResponseWriter, req *http.Request) {
+	from, okFrom := queryTime(req, "from")
+	to, okTo := queryTime(req, "to")
+	if !okFrom || !okTo {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.DiscountAudit(from, to)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) TipReport(res http.ResponseWriter, req *http.Request) {
+	from, okFrom := queryTime(req, "from")
+	to, okTo := queryTime(req, "to")
+	if !okFrom || !okTo {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.TipReport(from, to)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+// queryTime reads an optional timestamp query parameter, given as unix
+// seconds or RFC3339.
+func queryTime(req *http.Request, key string) (*time.Time, bool) {
+	value := req.URL.Query().Get(key)
+	if value == "" {
+		return nil, true
+	}
+	timestamp, err := model.ParseTimestamp(value)
+	if err != nil {
+		return nil, false
+	}
+	return &timestamp.Time, true
+}
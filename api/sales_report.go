@@ -0,0 +1,98 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/utils"
+)
+
+type SalesReportRouter interface {
+	XReport(res http.ResponseWriter, req *http.Request)
+	CreateZReport(res http.ResponseWriter, req *http.Request)
+	DetailZReport(res http.ResponseWriter, req *http.Request)
+	ListZReport(res http.ResponseWriter, req *http.Request)
+	RouteSalesReportPath()
+}
+
+func (r *router) RouteSalesReportPath() {
+	r.mux.HandleFunc("/reports/x", middleware(r.XReport)).Methods("GET")
+	r.mux.HandleFunc("/reports/z", middleware(r.ListZReport)).Methods("GET")
+	r.mux.HandleFunc("/reports/z", middleware(r.CreateZReport)).Methods("POST")
+	r.mux.HandleFunc("/reports/z/{number}", middleware(r.DetailZReport)).Methods("GET")
+}
+
+func (r *router) XReport(res http.ResponseWriter, req *http.Request) {
+	shiftId, _ := strconv.ParseInt(req.URL.Query().Get("shiftId"), 10, 0)
+	format := reportFormat(req)
+	response, statusCode := r.handlerService.XReport(shiftId, format)
+	writeReport(res, response, statusCode, format, "x-report")
+}
+
+func (r *router) CreateZReport(res http.ResponseWriter, req *http.Request) {
+	format := reportFormat(req)
+	response, statusCode := r.handlerService.CreateZReport(format)
+	writeReport(res, response, statusCode, format, "z-report")
+}
+
+func (r *router) DetailZReport(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	numberParams := params["number"]
+	number, _ := strconv.ParseInt(numberParams, 10, 0)
+	if number == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusNotFound, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	format := reportFormat(req)
+	response, statusCode := r.handlerService.DetailZReport(number, format)
+	writeReport(res, response, statusCode, format, fmt.Sprintf("z-report-%06d", number))
+}
+
+func (r *router) ListZReport(res http.ResponseWriter, req *http.Request) {
+	limitQuery := req.URL.Query().Get("limit")
+	skipQuery := req.URL.Query().Get("skip")
+	limit, _ := strconv.Atoi(limitQuery)
+	skip, _ := strconv.Atoi(skipQuery)
+	response, statusCode := r.handlerService.ListZReport(limit, skip)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func reportFormat(req *http.Request) string {
+	format := req.URL.Query().Get("format")
+	if format == "" {
+		format = model.ReportFormatJSON
+	}
+	return format
+}
+
+// writeReport answers with a report in its format, a PDF as a download
+// named after the report.
+func writeReport(res http.ResponseWriter, response []byte, statusCode int, format, name string) {
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	switch format {
+	case model.ReportFormatText:
+		res.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	case model.ReportFormatPDF:
+		res.Header().Set("Content-Type", "application/pdf")
+		res.Header().Set("Content-Disposition",
+			fmt.Sprintf("attachment; filename=\"%s.pdf\"", name))
+	default:
+		res.Header().Set("Content-Type", "application/json")
+	}
+	res.Write(response)
+}
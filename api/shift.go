@@ -0,0 +1,140 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/utils"
+)
+
+type ShiftRouter interface {
+	ListShift(res http.ResponseWriter, req *http.Request)
+	CurrentShift(res http.ResponseWriter, req *http.Request)
+	DetailShift(res http.ResponseWriter, req *http.Request)
+	OpenShift(res http.ResponseWriter, req *http.Request)
+	CreateShiftMovement(res http.ResponseWriter, req *http.Request)
+	CloseShift(res http.ResponseWriter, req *http.Request)
+	RouteShiftPath()
+}
+
+func (r *router) RouteShiftPath() {
+	r.mux.HandleFunc("/shifts", middleware(r.ListShift)).Methods("GET")
+	r.mux.HandleFunc("/shifts/current", middleware(r.CurrentShift)).Methods("GET")
+	r.mux.HandleFunc("/shifts/{shiftId}", middleware(r.DetailShift)).Methods("GET")
+	r.mux.HandleFunc("/shifts", middleware(r.OpenShift)).Methods("POST")
+	r.mux.HandleFunc("/shifts/{shiftId}/movements", middleware(r.CreateShiftMovement)).Methods("POST")
+	r.mux.HandleFunc("/shifts/{shiftId}/close", middleware(r.CloseShift)).Methods("POST")
+}
+
+func (r *router) ListShift(res http.ResponseWriter, req *http.Request) {
+	limitQuery := req.URL.Query().Get("limit")
+	skipQuery := req.URL.Query().Get("skip")
+	limit, _ := strconv.Atoi(limitQuery)
+	skip, _ := strconv.Atoi(skipQuery)
+	response, statusCode := r.handlerService.ListShift(limit, skip)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) CurrentShift(res http.ResponseWriter, req *http.Request) {
+	response, statusCode := r.handlerService.CurrentShift()
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) DetailShift(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["shiftId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.DetailShift(id)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) OpenShift(res http.ResponseWriter, req *http.Request) {
+	var request model.OpenShiftRequest
+	err := json.NewDecoder(req.Body).Decode(&request)
+	if err != nil {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.OpenShift(request)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) CreateShiftMovement(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["shiftId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	var movement model.ShiftMovement
+	err := json.NewDecoder(req.Body).Decode(&movement)
+	if err != nil || id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	movement.ShiftId = id
+	response, statusCode := r.handlerService.CreateShiftMovement(movement)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) CloseShift(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["shiftId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	var request model.CloseShiftRequest
+	err := json.NewDecoder(req.Body).Decode(&request)
+	if err != nil || id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.CloseShift(id, request)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/utils"
+)
+
+type TaxRouter interface {
+	ListTaxClass(res http.ResponseWriter, req *http.Request)
+	DetailTaxClass(res http.ResponseWriter, req *http.Request)
+	CreateTaxClass(res http.ResponseWriter, req *http.Request)
+	UpdateTaxClass(res http.ResponseWriter, req *http.Request)
+	DeleteTaxClass(res http.ResponseWriter, req *http.Request)
+	SetProductTaxClass(res http.ResponseWriter, req *http.Request)
+	SetCategoryTaxClass(res http.ResponseWriter, req *http.Request)
+	TaxSummary(res http.ResponseWriter, req *http.Request)
+	RouteTaxPath()
+}
+
+func (r *router) RouteTaxPath() {
+	r.mux.HandleFunc("/tax-classes", middleware(r.ListTaxClass)).Methods("GET")
+	r.mux.HandleFunc("/tax-classes/{taxClassId}", middleware(r.DetailTaxClass)).Methods("GET")
+	r.mux.HandleFunc("/tax-classes", middleware(r.CreateTaxClass)).Methods("POST")
+	r.mux.HandleFunc("/tax-classes/{taxClassId}", middleware(r.UpdateTaxClass)).Methods("PUT")
+	r.mux.HandleFunc("/tax-classes/{taxClassId}", middleware(r.DeleteTaxClass)).Methods("DELETE")
+	r.mux.HandleFunc("/products/{productId}/tax-class", middleware(r.SetProductTaxClass)).Methods("PUT")
+	r.mux.HandleFunc("/categories/{categoryId}/tax-class", middleware(r.SetCategoryTaxClass)).Methods("PUT")
+	r.mux.HandleFunc("/tax-summary", middleware(r.TaxSummary)).Methods("GET")
+}
+
+func (r *router) ListTaxClass(res http.ResponseWriter, req *http.Request) {
+	limitQuery := req.URL.Query().Get("limit")
+	skipQuery := req.URL.Query().Get("skip")
+	limit, _ := strconv.Atoi(limitQuery)
+	skip, _ := strconv.Atoi(skipQuery)
+	response, statusCode := r.handlerService.ListTaxClass(limit, skip)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) DetailTaxClass(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["taxClassId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.DetailTaxClass(id)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) CreateTaxClass(res http.ResponseWriter, req *http.Request) {
+	var taxClass model.TaxClass
+	err := json.NewDecoder(req.Body).Decode(&taxClass)
+	if err != nil {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.CreateTaxClass(taxClass)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) UpdateTaxClass(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["taxClassId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusNotFound, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	var taxClass model.TaxClass
+	err := json.NewDecoder(req.Body).Decode(&taxClass)
+	if err != nil {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	taxClass.TaxClassId = id
+	response, statusCode := r.handlerService.UpdateTaxClass(taxClass)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) DeleteTaxClass(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["taxClassId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusNotFound, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.DeleteTaxClass(id)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) SetProductTaxClass(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["productId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	var taxClassRequest model.TaxClassRequest
+	err := json.NewDecoder(req.Body).Decode(&taxClassRequest)
+	if err != nil {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.SetProductTaxClass(id, taxClassRequest.TaxClassId)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) SetCategoryTaxClass(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["categoryId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	var taxClassRequest model.TaxClassRequest
+	err := json.NewDecoder(req.Body).Decode(&taxClassRequest)
+	if err != nil {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.SetCategoryTaxClass(id, taxClassRequest.TaxClassId)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) TaxSummary(res http.ResponseWriter, req *http.Request) {
+	from, okFrom := queryTime(req, "from")
+	to, okTo := queryTime(req, "to")
+	if !okFrom || !okTo {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.TaxSummary(from, to)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/saptaka/pos/utils"
+)
+
+type UploadRouter interface {
+	UploadProductImage(res http.ResponseWriter, req *http.Request)
+	UploadPaymentLogo(res http.ResponseWriter, req *http.Request)
+	File(res http.ResponseWriter, req *http.Request)
+	RouteUploadPath()
+}
+
+func (r *router) RouteUploadPath() {
+	r.mux.HandleFunc("/products/{productId}/image", middleware(r.UploadProductImage)).Methods("POST")
+	r.mux.HandleFunc("/payments/{paymentId}/logo", middleware(r.UploadPaymentLogo)).Methods("POST")
+	r.mux.HandleFunc("/files/{key:.+}", r.File).Methods("GET", "HEAD")
+}
+
+func (r *router) UploadProductImage(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["productId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	data, statusCode := readUpload(res, req)
+	if statusCode != http.StatusOK {
+		response, statusCode := utils.ResponseWrapper(statusCode, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.UploadProductImage(id, data)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) UploadPaymentLogo(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	idParams := params["paymentId"]
+	id, _ := strconv.ParseInt(idParams, 10, 0)
+	if id == 0 {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	data, statusCode := readUpload(res, req)
+	if statusCode != http.StatusOK {
+		response, statusCode := utils.ResponseWrapper(statusCode, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	response, statusCode := r.handlerService.UploadPaymentLogo(id, data)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) File(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	object, statusCode := r.handlerService.File(params["key"])
+	if statusCode != http.StatusOK {
+		response, statusCode := utils.ResponseWrapper(statusCode, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	defer object.Body.Close()
+
+	if object.ContentType != "" {
+		res.Header().Set("Content-Type", object.ContentType)
+	}
+	res.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	res.Header().Set("ETag", strconv.Quote(path.Base(object.Key)))
+	http.ServeContent(res, req, object.Key, object.ModTime, object.Body)
+}
+
+// readUpload reads the "file" part of a multipart upload, leaving the size
+// and type checks to the handler.
+func readUpload(res http.ResponseWriter, req *http.Request) ([]byte, int) {
+	req.Body = http.MaxBytesReader(res, req.Body, maxImportSize)
+	err := req.ParseMultipartForm(maxImportSize)
+	if err != nil {
+		return nil, http.StatusBadRequest
+	}
+	file, _, err := req.FormFile("file")
+	if err != nil {
+		return nil, http.StatusBadRequest
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, http.StatusBadRequest
+	}
+	return data, http.StatusOK
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/saptaka/pos/gateway"
+	"github.com/saptaka/pos/utils"
+)
+
+// maxWebhookSize bounds the body of a payment webhook.
+const maxWebhookSize = 1 << 20
+
+type WebhookRouter interface {
+	PaymentWebhook(res http.ResponseWriter, req *http.Request)
+	ListPaymentEvent(res http.ResponseWriter, req *http.Request)
+	RouteWebhookPath()
+}
+
+func (r *router) RouteWebhookPath() {
+	// Called by payment providers, which authenticate by signing the body.
+	r.mux.HandleFunc("/webhooks/payments/{provider}", r.PaymentWebhook).Methods("POST")
+	r.mux.HandleFunc("/payment-events", middleware(r.ListPaymentEvent)).Methods("GET")
+}
+
+func (r *router) PaymentWebhook(res http.ResponseWriter, req *http.Request) {
+	params := mux.Vars(req)
+	provider := params["provider"]
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxWebhookSize))
+	if err != nil {
+		response, statusCode := utils.ResponseWrapper(http.StatusBadRequest, nil)
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	timestamp := req.Header.Get(gateway.TimestampHeader)
+	signature := req.Header.Get(gateway.SignatureHeader)
+	response, statusCode := r.handlerService.PaymentWebhook(provider, timestamp, signature, body)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
+
+func (r *router) ListPaymentEvent(res http.ResponseWriter, req *http.Request) {
+	receiptId := req.URL.Query().Get("receiptId")
+	limitQuery := req.URL.Query().Get("limit")
+	skipQuery := req.URL.Query().Get("skip")
+	limit, _ := strconv.Atoi(limitQuery)
+	skip, _ := strconv.Atoi(skipQuery)
+	response, statusCode := r.handlerService.ListPaymentEvent(receiptId, limit, skip)
+	if statusCode != http.StatusOK {
+		res.WriteHeader(statusCode)
+		res.Write(response)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(response)
+}
@@ -0,0 +1,31 @@
+// Command edcsim emulates an EDC terminal's ECR link on a local socket,
+// for running the POS without a terminal:
+//
+//	edcsim -addr 127.0.0.1:9100 -delay 3s
+//
+// with POS_EDC_ADDRESS=127.0.0.1:9100 set for the server.
+package main
+
+import (
+	"flag"
+	"log"
+	"net"
+
+	"github.com/saptaka/pos/edc"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:9100", "address to listen on")
+	terminal := flag.String("terminal", "SIM00001", "terminal ID reported with approvals")
+	decline := flag.Bool("decline", false, "decline every sale")
+	delay := flag.Duration("delay", 0, "time taken by the customer to pay")
+	flag.Parse()
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("EDC simulator %s listening on %s", *terminal, *addr)
+	simulator := edc.NewSimulator(*terminal, *decline, *delay)
+	log.Fatal(simulator.Serve(listener))
+}
@@ -0,0 +1,31 @@
+// Command smtpsink stands in for a mail server, printing the receipts the
+// POS emails instead of delivering them:
+//
+//	smtpsink -addr 127.0.0.1:2525
+//
+// with POS_SMTP_ADDRESS=127.0.0.1:2525 set for the server. With -reject
+// every message is turned away, to watch the outbox retry.
+package main
+
+import (
+	"flag"
+	"log"
+	"net"
+	"os"
+
+	"github.com/saptaka/pos/notify"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:2525", "address to listen on")
+	reject := flag.Bool("reject", false, "turn every message away with a temporary failure")
+	flag.Parse()
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("SMTP sink listening on %s", *addr)
+	sink := notify.NewSink(os.Stdout, *reject)
+	log.Fatal(sink.Serve(listener))
+}
@@ -0,0 +1,43 @@
+// Command webhooksign signs a payment webhook body the way a provider
+// would, standing in for one when testing locally:
+//
+//	echo '{"eventId":"evt-1","receiptId":"...","amount":15000,"status":"PAID"}' |
+//		webhooksign -secret "$SECRET" -url http://localhost:3030/webhooks/payments/mock
+//
+// prints a curl command posting the body with its signature headers.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/saptaka/pos/gateway"
+)
+
+func main() {
+	secret := flag.String("secret", os.Getenv("WEBHOOK_SECRET"), "provider webhook secret")
+	url := flag.String("url", "http://localhost:3030/webhooks/payments/mock", "webhook endpoint")
+	flag.Parse()
+	if *secret == "" {
+		log.Fatal("a secret is required")
+	}
+
+	body, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		log.Fatal(err)
+	}
+	body = []byte(strings.TrimSpace(string(body)))
+	timestamp := time.Now().Unix()
+	signature := gateway.Sign(*secret, timestamp, body)
+
+	fmt.Printf("curl -X POST %s \\\n", *url)
+	fmt.Printf("  -H 'Content-Type: application/json' \\\n")
+	fmt.Printf("  -H '%s: %d' \\\n", gateway.TimestampHeader, timestamp)
+	fmt.Printf("  -H '%s: %s' \\\n", gateway.SignatureHeader, signature)
+	fmt.Printf("  --data-raw '%s'\n", strings.ReplaceAll(string(body), "'", `'\''`))
+}
@@ -1,22 +1,160 @@
 package config
 
 import (
+	"time"
+	_ "time/tzdata"
+
 	"github.com/kelseyhightower/envconfig"
+	"github.com/saptaka/pos/gateway"
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/notify"
 )
 
 type Config struct {
-	DBName              string `envconfig:"DBNAME" required:"true"`
-	DBHost              string `envconfig:"HOST" required:"true"`
-	DBuser              string `envconfig:"USER" required:"true"`
-	DBPassword          string `envconfig:"PASSWORD" required:"true"`
-	DBPort              int    `envconfig:"PORT" required:"true"`
-	DBMaxIdle           int    `envconfig:"DB_MAX_IDLE" default:"100"`
-	DBMaxConnection     int    `envconfig:"DB_MAX_CONNECTION" default:"100"`
-	DBConnectionTimeout int    `envconfig:"DB_CONNECTION_TIMEOUT" default:"10"`
+	DBName              string      `envconfig:"DBNAME" required:"true"`
+	DBHost              string      `envconfig:"HOST" required:"true"`
+	DBuser              string      `envconfig:"USER" required:"true"`
+	DBPassword          string      `envconfig:"PASSWORD" required:"true"`
+	DBPort              int         `envconfig:"PORT" required:"true"`
+	DBMaxIdle           int         `envconfig:"DB_MAX_IDLE" default:"100"`
+	DBMaxConnection     int         `envconfig:"DB_MAX_CONNECTION" default:"100"`
+	DBConnectionTimeout int         `envconfig:"DB_CONNECTION_TIMEOUT" default:"10"`
+	Store               StoreConfig `ignored:"true"`
+}
+
+type StoreConfig struct {
+	// Name heads the printed reports.
+	Name string `envconfig:"STORE_NAME" default:"POS"`
+
+	StorageDir    string `envconfig:"STORAGE_DIR" default:"uploads"`
+	MaxUploadSize int64  `envconfig:"MAX_UPLOAD_SIZE" default:"5242880"`
+	ThumbnailSize int    `envconfig:"THUMBNAIL_SIZE" default:"200"`
+	Timezone      string `envconfig:"TIMEZONE" default:"Asia/Jakarta"`
+
+	// Currency is the ISO 4217 code of the store currency every amount is
+	// in, and Rounding how amounts between two of its minor units round.
+	Currency string `envconfig:"CURRENCY" default:"IDR"`
+	Rounding string `envconfig:"ROUNDING" default:"HALF_UP"`
+
+	// ManualDiscountLimit is the total of manual discounts, in minor units,
+	// an order may get without a manager's approval.
+	ManualDiscountLimit int `envconfig:"MANUAL_DISCOUNT_LIMIT" default:"50000"`
+
+	// TaxInclusive tells whether product prices already contain tax.
+	TaxInclusive bool `envconfig:"TAX_INCLUSIVE" default:"true"`
+
+	// ServiceChargePercent is charged on dine-in orders, on the amount
+	// excluding tax unless ServiceChargeAfterTax is set.
+	ServiceChargePercent  float64 `envconfig:"SERVICE_CHARGE_PERCENT" default:"0"`
+	ServiceChargeAfterTax bool    `envconfig:"SERVICE_CHARGE_AFTER_TAX" default:"false"`
+
+	// LoyaltyPointValue is what a loyalty point is worth as a tender, in
+	// minor units, and LoyaltyExpiryDays how long earned points last; zero
+	// keeps them forever.
+	LoyaltyPointValue int64 `envconfig:"LOYALTY_POINT_VALUE" default:"100"`
+	LoyaltyExpiryDays int   `envconfig:"LOYALTY_EXPIRY_DAYS" default:"365"`
+
+	// GiftCardExpiryDays and StoreCreditExpiryDays are how long new gift
+	// cards and store credit can be redeemed; zero keeps them forever.
+	GiftCardExpiryDays    int `envconfig:"GIFT_CARD_EXPIRY_DAYS" default:"365"`
+	StoreCreditExpiryDays int `envconfig:"STORE_CREDIT_EXPIRY_DAYS" default:"365"`
+
+	// PaymentTimeout is how long, in seconds, to wait for a payment
+	// provider before leaving the order pending.
+	PaymentTimeout int `envconfig:"PAYMENT_TIMEOUT" default:"30"`
+
+	// MockPaymentOutcome is how the built-in mock payment provider answers:
+	// SUCCESS, DECLINE or TIMEOUT.
+	MockPaymentOutcome string `envconfig:"MOCK_PAYMENT_OUTCOME" default:"SUCCESS"`
+
+	// WebhookSecrets are the secrets payment providers sign their webhooks
+	// with, by provider name, as in "mock:secret,qris:secret".
+	WebhookSecrets map[string]string `envconfig:"WEBHOOK_SECRETS"`
+
+	// EDCAddress is where the EDC terminal's ECR link is reached, over TCP.
+	// The EDC provider is available once it is set.
+	EDCAddress string `envconfig:"EDC_ADDRESS"`
+
+	// QRIS is the store's QRIS registration. The QRIS provider is
+	// available once a merchant ID is set.
+	QRIS QRISConfig `envconfig:"QRIS"`
+
+	// SMTP is the mail server receipts are emailed through. Email
+	// receipts are available once its address is set.
+	SMTP SMTPConfig `envconfig:"SMTP"`
+
+	// SMS is the gateway receipts are texted through: "log" writes them to
+	// the log and "http" posts them to the gateway's URL. Text receipts are
+	// available once a gateway is set.
+	SMS SMSConfig `envconfig:"SMS"`
+
+	// ReceiptMaxAttempts is how often a receipt is tried before it is
+	// given up on, ReceiptRetryDelay how long, in seconds, to wait after
+	// the first failure, doubling with each one, and OutboxInterval how
+	// often, in seconds, the outbox is checked for receipts due.
+	ReceiptMaxAttempts int `envconfig:"RECEIPT_MAX_ATTEMPTS" default:"5"`
+	ReceiptRetryDelay  int `envconfig:"RECEIPT_RETRY_DELAY" default:"60"`
+	OutboxInterval     int `envconfig:"OUTBOX_INTERVAL" default:"15"`
+
+	// Location is the loaded Timezone, the store's local time.
+	Location *time.Location `ignored:"true"`
+}
+
+type QRISConfig struct {
+	MerchantName string `envconfig:"MERCHANT_NAME"`
+	MerchantCity string `envconfig:"MERCHANT_CITY"`
+	PostalCode   string `envconfig:"POSTAL_CODE"`
+	MerchantID   string `envconfig:"MERCHANT_ID"`
+	Acquirer     string `envconfig:"ACQUIRER"`
+	MerchantPAN  string `envconfig:"MERCHANT_PAN"`
+	Category     string `envconfig:"CATEGORY" default:"5499"`
+	Criteria     string `envconfig:"CRITERIA" default:"UMI"`
+
+	// Expiry is how long, in seconds, a code can be paid.
+	Expiry int `envconfig:"EXPIRY" default:"300"`
+
+	// CallbackToken is the secret the acquirer sends with its callbacks.
+	CallbackToken string `envconfig:"CALLBACK_TOKEN"`
+}
+
+type SMTPConfig struct {
+	Address  string `envconfig:"ADDRESS"`
+	From     string `envconfig:"FROM" default:"receipts@localhost"`
+	Username string `envconfig:"USERNAME"`
+	Password string `envconfig:"PASSWORD"`
+}
+
+type SMSConfig struct {
+	Gateway string `envconfig:"GATEWAY"`
+	URL     string `envconfig:"URL"`
+	Token   string `envconfig:"TOKEN"`
+	Sender  string `envconfig:"SENDER"`
 }
 
 func Setup() *Config {
 	var db Config
 	envconfig.MustProcess("MYSQL", &db)
+	envconfig.MustProcess("POS", &db.Store)
+	location, err := time.LoadLocation(db.Store.Timezone)
+	if err != nil {
+		panic(err)
+	}
+	db.Store.Location = location
+	currency, ok := model.Currencies[db.Store.Currency]
+	if !ok {
+		panic("unknown store currency " + db.Store.Currency)
+	}
+	currency.Rounding = model.Rounding(db.Store.Rounding)
+	if !model.RoundingMode[currency.Rounding] {
+		panic("unknown rounding mode " + db.Store.Rounding)
+	}
+	model.StoreCurrency = currency
+	if !gateway.MockOutcome[gateway.Outcome(db.Store.MockPaymentOutcome)] {
+		panic("unknown mock payment outcome " + db.Store.MockPaymentOutcome)
+	}
+	if gateway := db.Store.SMS.Gateway; gateway != "" && gateway != notify.SMSGatewayLog &&
+		gateway != notify.SMSGatewayHTTP {
+		panic("unknown sms gateway " + gateway)
+	}
 	return &db
 }
@@ -0,0 +1,147 @@
+package edc
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/saptaka/pos/model"
+)
+
+var (
+	ErrDeclined = errors.New("edc: transaction declined by the terminal")
+	ErrNoAnswer = errors.New("edc: terminal did not acknowledge")
+)
+
+// Result is a terminal's answer to a transaction. Trace is the terminal's
+// number for it, used to void it.
+type Result struct {
+	Approved     bool
+	ApprovalCode string
+	MaskedPAN    string
+	TerminalID   string
+	Trace        string
+	ResponseCode string
+	Message      string
+}
+
+// Driver talks to an EDC terminal over its ECR link. Sale pushes an
+// amount to the terminal and waits for the customer to pay it, until ctx
+// expires.
+type Driver interface {
+	Sale(ctx context.Context, amount model.Money, reference string) (Result, error)
+	Void(ctx context.Context, trace string) (Result, error)
+}
+
+// sendAttempts is how often a message is sent before giving up on a
+// terminal that answers NAK or nothing.
+const sendAttempts = 3
+
+// ackTimeout is how long the terminal has to acknowledge a message.
+const ackTimeout = 2 * time.Second
+
+type tcpDriver struct {
+	address string
+}
+
+// NewTCPDriver is a driver for a terminal whose serial ECR port is
+// reached through a serial-to-TCP bridge, or which speaks ECR over TCP
+// itself, at address.
+func NewTCPDriver(address string) Driver {
+	return &tcpDriver{address}
+}
+
+func (d *tcpDriver) Sale(ctx context.Context, amount model.Money, reference string) (Result, error) {
+	return d.transact(ctx, fmt.Sprintf("%s|%012d|%s", MessageSale, int64(amount), reference))
+}
+
+func (d *tcpDriver) Void(ctx context.Context, trace string) (Result, error) {
+	return d.transact(ctx, fmt.Sprintf("%s|%s", MessageVoid, trace))
+}
+
+func (d *tcpDriver) transact(ctx context.Context, request string) (Result, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", d.address)
+	if err != nil {
+		return Result{}, err
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	reader := bufio.NewReader(conn)
+
+	err = send(conn, reader, request, deadline)
+	if err != nil {
+		return Result{}, err
+	}
+	for {
+		data, err := ReadFrame(reader)
+		if err == ErrLRC || err == ErrFrame {
+			conn.Write([]byte{NAK})
+			continue
+		}
+		if err != nil {
+			return Result{}, err
+		}
+		_, err = conn.Write([]byte{ACK})
+		if err != nil {
+			return Result{}, err
+		}
+		return parseResult(data)
+	}
+}
+
+// send writes a message until the terminal acknowledges it, then leaves
+// the connection to read until deadline.
+func send(conn net.Conn, reader *bufio.Reader, data string, deadline time.Time) error {
+	frame := Frame(data)
+	for attempt := 0; attempt < sendAttempts; attempt++ {
+		_, err := conn.Write(frame)
+		if err != nil {
+			return err
+		}
+		ackDeadline := time.Now().Add(ackTimeout)
+		if !deadline.IsZero() && deadline.Before(ackDeadline) {
+			ackDeadline = deadline
+		}
+		conn.SetReadDeadline(ackDeadline)
+		answer, err := reader.ReadByte()
+		if err != nil {
+			if !deadline.IsZero() && !time.Now().Before(deadline) {
+				return err
+			}
+			continue
+		}
+		if answer == ACK {
+			conn.SetReadDeadline(deadline)
+			return nil
+		}
+	}
+	return ErrNoAnswer
+}
+
+// parseResult reads an answer: APPROVED|code|pan|terminal|trace or
+// DECLINED|response code|message.
+func parseResult(data string) (Result, error) {
+	fields := Fields(data)
+	switch {
+	case fields[0] == MessageApproved && len(fields) >= 5:
+		return Result{
+			Approved:     true,
+			ApprovalCode: fields[1],
+			MaskedPAN:    MaskPAN(fields[2]),
+			TerminalID:   fields[3],
+			Trace:        fields[4],
+			ResponseCode: "00",
+		}, nil
+	case fields[0] == MessageDeclined && len(fields) >= 3:
+		return Result{
+			ResponseCode: fields[1],
+			Message:      fields[2],
+		}, ErrDeclined
+	}
+	return Result{}, ErrFrame
+}
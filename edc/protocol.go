@@ -0,0 +1,114 @@
+package edc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Control characters of the ECR link. A message travels as STX, four
+// ASCII digits giving the length of the data, the data, ETX and an LRC:
+// the XOR of every byte after STX up to and including ETX. The receiver
+// answers each message with ACK, or NAK to have it sent again.
+const (
+	STX byte = 0x02
+	ETX byte = 0x03
+	ACK byte = 0x06
+	NAK byte = 0x15
+)
+
+var (
+	ErrFrame = errors.New("edc: malformed message")
+	ErrLRC   = errors.New("edc: message checksum mismatch")
+)
+
+// Message data are fields separated by "|", the first naming the
+// message.
+const (
+	MessageSale     = "SALE"
+	MessageVoid     = "VOID"
+	MessageApproved = "APPROVED"
+	MessageDeclined = "DECLINED"
+)
+
+// Frame wraps data for the link.
+func Frame(data string) []byte {
+	frame := make([]byte, 0, len(data)+7)
+	frame = append(frame, STX)
+	frame = append(frame, fmt.Sprintf("%04d", len(data))...)
+	frame = append(frame, data...)
+	frame = append(frame, ETX)
+	return append(frame, lrc(frame[1:]))
+}
+
+// ReadFrame reads one message off the link, skipping anything before its
+// STX, and returns its data.
+func ReadFrame(reader *bufio.Reader) (string, error) {
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		if b == STX {
+			break
+		}
+	}
+	header := make([]byte, 4)
+	_, err := readFull(reader, header)
+	if err != nil {
+		return "", err
+	}
+	length, err := strconv.Atoi(string(header))
+	if err != nil || length > 9999 {
+		return "", ErrFrame
+	}
+	rest := make([]byte, length+2)
+	_, err = readFull(reader, rest)
+	if err != nil {
+		return "", err
+	}
+	if rest[length] != ETX {
+		return "", ErrFrame
+	}
+	body := append(header, rest[:length+1]...)
+	if lrc(body) != rest[length+1] {
+		return "", ErrLRC
+	}
+	return string(rest[:length]), nil
+}
+
+func readFull(reader *bufio.Reader, buffer []byte) (int, error) {
+	read := 0
+	for read < len(buffer) {
+		n, err := reader.Read(buffer[read:])
+		read += n
+		if err != nil {
+			return read, err
+		}
+	}
+	return read, nil
+}
+
+func lrc(data []byte) byte {
+	var sum byte
+	for _, b := range data {
+		sum ^= b
+	}
+	return sum
+}
+
+// Fields splits message data.
+func Fields(data string) []string {
+	return strings.Split(data, "|")
+}
+
+// MaskPAN keeps only the first six and last four digits of a card number,
+// so a full number never reaches storage even if a terminal sends one.
+func MaskPAN(pan string) string {
+	if len(pan) <= 10 {
+		return strings.Repeat("*", len(pan))
+	}
+	return pan[:6] + strings.Repeat("*", len(pan)-10) + pan[len(pan)-4:]
+}
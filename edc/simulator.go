@@ -0,0 +1,103 @@
+package edc
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Simulator emulates a terminal on the ECR link, for development without
+// one. It approves every sale, or declines it when Decline is set, after
+// Delay standing for the customer presenting a card.
+type Simulator struct {
+	TerminalID string
+	Decline    bool
+	Delay      time.Duration
+
+	mu    sync.Mutex
+	trace int
+	sales map[string]bool
+}
+
+func NewSimulator(terminalID string, decline bool, delay time.Duration) *Simulator {
+	return &Simulator{
+		TerminalID: terminalID,
+		Decline:    decline,
+		Delay:      delay,
+		sales:      make(map[string]bool),
+	}
+}
+
+// Serve answers the connections on listener until it is closed.
+func (s *Simulator) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *Simulator) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		data, err := ReadFrame(reader)
+		if err == ErrLRC || err == ErrFrame {
+			conn.Write([]byte{NAK})
+			continue
+		}
+		if err != nil {
+			return
+		}
+		_, err = conn.Write([]byte{ACK})
+		if err != nil {
+			return
+		}
+		answer := s.answer(data)
+		log.Printf("edc simulator: %s -> %s", data, answer)
+		err = send(conn, reader, answer, time.Time{})
+		if err != nil {
+			log.Println("edc simulator:", err)
+			return
+		}
+	}
+}
+
+func (s *Simulator) answer(data string) string {
+	fields := Fields(data)
+	switch {
+	case fields[0] == MessageSale && len(fields) >= 2:
+		amount, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil || amount <= 0 {
+			return MessageDeclined + "|13|INVALID AMOUNT"
+		}
+		time.Sleep(s.Delay)
+		if s.Decline {
+			return MessageDeclined + "|51|INSUFFICIENT FUNDS"
+		}
+		s.mu.Lock()
+		s.trace++
+		trace := fmt.Sprintf("%06d", s.trace)
+		s.sales[trace] = true
+		s.mu.Unlock()
+		return fmt.Sprintf("%s|%06d|%s|%s|%s", MessageApproved,
+			rand.Intn(1000000), "411111******1111", s.TerminalID, trace)
+	case fields[0] == MessageVoid && len(fields) >= 2:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if !s.sales[fields[1]] {
+			return MessageDeclined + "|12|INVALID TRANSACTION"
+		}
+		delete(s.sales, fields[1])
+		return fmt.Sprintf("%s|%06d|%s|%s|%s", MessageApproved,
+			rand.Intn(1000000), "411111******1111", s.TerminalID, fields[1])
+	}
+	return MessageDeclined + "|30|FORMAT ERROR"
+}
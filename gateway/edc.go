@@ -0,0 +1,117 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/saptaka/pos/edc"
+	"github.com/saptaka/pos/model"
+)
+
+type edcProvider struct {
+	driver       edc.Driver
+	mu           sync.Mutex
+	transactions map[string]Transaction
+}
+
+// NewEDC is a provider taking card payments on an EDC terminal through
+// driver. A sale approved on the terminal is captured at once. Refunds
+// are made on the terminal itself.
+func NewEDC(driver edc.Driver) Provider {
+	return &edcProvider{
+		driver:       driver,
+		transactions: make(map[string]Transaction),
+	}
+}
+
+func (e *edcProvider) Name() string {
+	return "edc"
+}
+
+func (e *edcProvider) Authorize(ctx context.Context, request Request) (Transaction, error) {
+	result, err := e.driver.Sale(ctx, request.Amount, request.Reference)
+	transaction := Transaction{
+		OrderReference: request.Reference,
+		Amount:         request.Amount,
+		Message:        result.Message,
+	}
+	if err == edc.ErrDeclined {
+		transaction.Status = StatusDeclined
+		return transaction, ErrDeclined
+	}
+	if err != nil {
+		return transaction, terminalError(ctx, err)
+	}
+	transaction.Reference = result.TerminalID + "-" + result.Trace
+	transaction.Status = StatusCaptured
+	transaction.ApprovalCode = result.ApprovalCode
+	transaction.MaskedPAN = result.MaskedPAN
+	transaction.TerminalID = result.TerminalID
+	e.mu.Lock()
+	e.transactions[transaction.Reference] = transaction
+	e.mu.Unlock()
+	return transaction, nil
+}
+
+func (e *edcProvider) Capture(ctx context.Context, reference string, amount model.Money) (Transaction, error) {
+	transaction, err := e.Status(ctx, reference)
+	if err != nil {
+		return transaction, err
+	}
+	if transaction.Status != StatusCaptured {
+		return transaction, ErrInvalidState
+	}
+	return transaction, nil
+}
+
+// Void cancels a sale on the terminal that took it, by its trace number.
+func (e *edcProvider) Void(ctx context.Context, reference string) (Transaction, error) {
+	dash := strings.LastIndex(reference, "-")
+	if dash < 0 {
+		return Transaction{}, ErrNotFound
+	}
+	result, err := e.driver.Void(ctx, reference[dash+1:])
+	if err == edc.ErrDeclined {
+		return Transaction{Reference: reference, Message: result.Message}, ErrInvalidState
+	}
+	if err != nil {
+		return Transaction{}, terminalError(ctx, err)
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	transaction, ok := e.transactions[reference]
+	if !ok {
+		transaction = Transaction{Reference: reference, TerminalID: result.TerminalID}
+	}
+	transaction.Status = StatusVoided
+	e.transactions[reference] = transaction
+	return transaction, nil
+}
+
+func (e *edcProvider) Refund(ctx context.Context, reference string, amount model.Money) (Transaction, error) {
+	return Transaction{}, ErrNotSupported
+}
+
+// Status reports the transactions taken since start up; the terminal
+// cannot be asked about earlier ones.
+func (e *edcProvider) Status(ctx context.Context, reference string) (Transaction, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	transaction, ok := e.transactions[reference]
+	if !ok {
+		return Transaction{}, ErrNotFound
+	}
+	return transaction, nil
+}
+
+// terminalError reports a terminal that did not answer before ctx expired
+// as a timeout.
+func terminalError(ctx context.Context, err error) error {
+	if ctx.Err() != nil || errors.Is(err, os.ErrDeadlineExceeded) {
+		return ErrTimeout
+	}
+	return err
+}
@@ -0,0 +1,100 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+
+	"github.com/saptaka/pos/model"
+)
+
+var (
+	ErrDeclined     = errors.New("gateway: payment declined")
+	ErrTimeout      = errors.New("gateway: provider did not answer in time")
+	ErrNotFound     = errors.New("gateway: transaction not found")
+	ErrInvalidState = errors.New("gateway: transaction cannot move to the requested state")
+	ErrNotSupported = errors.New("gateway: operation not supported by the provider")
+	ErrMismatch     = errors.New("gateway: callback does not match the transaction")
+)
+
+// Status is the state of a transaction at the provider.
+type Status string
+
+const (
+	StatusPending    Status = "PENDING"
+	StatusAuthorized Status = "AUTHORIZED"
+	StatusCaptured   Status = "CAPTURED"
+	StatusDeclined   Status = "DECLINED"
+	StatusVoided     Status = "VOIDED"
+	StatusRefunded   Status = "REFUNDED"
+	StatusExpired    Status = "EXPIRED"
+)
+
+// Request asks a provider to authorize an amount for an order. Reference
+// is the order's receipt ID, which the provider echoes back in callbacks.
+type Request struct {
+	Reference string
+	Amount    model.Money
+	Currency  string
+	Method    string
+}
+
+// Transaction is a provider's answer. Reference is the provider's own ID
+// for the transaction, used for every later call about it. Payload is
+// what the customer needs to complete a pending payment, such as the
+// content of a QR code. A card payment comes with the approval code, the
+// masked card number and the terminal that took it.
+type Transaction struct {
+	Reference      string
+	OrderReference string
+	Status         Status
+	Amount         model.Money
+	Message        string
+	Payload        string
+	ApprovalCode   string
+	MaskedPAN      string
+	TerminalID     string
+}
+
+// Provider is a payment service taking non-cash tenders. Authorize may
+// answer with a pending transaction when the customer still has to
+// confirm the payment; Status tells how it ended. Calls return ErrTimeout
+// when ctx expires before the provider answers, and ErrDeclined when the
+// payment is refused.
+type Provider interface {
+	Name() string
+	Authorize(ctx context.Context, request Request) (Transaction, error)
+	Capture(ctx context.Context, reference string, amount model.Money) (Transaction, error)
+	Void(ctx context.Context, reference string) (Transaction, error)
+	Refund(ctx context.Context, reference string, amount model.Money) (Transaction, error)
+	Status(ctx context.Context, reference string) (Transaction, error)
+}
+
+// Callback is a provider's notice, arriving on its own, that the payment
+// for an order ended.
+type Callback struct {
+	OrderReference string
+	Amount         model.Money
+	Paid           bool
+}
+
+// Confirmer is a provider whose payments are confirmed by a callback
+// rather than by the answer to Authorize.
+type Confirmer interface {
+	Confirm(ctx context.Context, callback Callback) (Transaction, error)
+}
+
+// Registry holds the providers payment types can be linked to, by name.
+type Registry map[string]Provider
+
+func NewRegistry(providers ...Provider) Registry {
+	registry := make(Registry)
+	for _, provider := range providers {
+		registry[provider.Name()] = provider
+	}
+	return registry
+}
+
+func (r Registry) Get(name string) (Provider, bool) {
+	provider, ok := r[name]
+	return provider, ok
+}
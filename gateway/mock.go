@@ -0,0 +1,162 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/saptaka/pos/model"
+)
+
+// Outcome is how the mock provider answers authorizations.
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "SUCCESS"
+	OutcomeDecline Outcome = "DECLINE"
+	OutcomeTimeout Outcome = "TIMEOUT"
+)
+
+var MockOutcome = map[Outcome]bool{
+	OutcomeSuccess: true,
+	OutcomeDecline: true,
+	OutcomeTimeout: true,
+}
+
+type mockProvider struct {
+	outcome      Outcome
+	delay        time.Duration
+	mu           sync.Mutex
+	sequence     int
+	transactions map[string]*Transaction
+}
+
+// NewMock is a provider that keeps its transactions in memory, for tests
+// and demos. Every authorization succeeds, is declined, or never answers
+// according to outcome, after waiting delay. A timed out authorization is
+// left pending.
+func NewMock(outcome Outcome, delay time.Duration) Provider {
+	return &mockProvider{
+		outcome:      outcome,
+		delay:        delay,
+		transactions: make(map[string]*Transaction),
+	}
+}
+
+func (m *mockProvider) Name() string {
+	return "mock"
+}
+
+func (m *mockProvider) Authorize(ctx context.Context, request Request) (Transaction, error) {
+	err := m.wait(ctx)
+	if err != nil {
+		return Transaction{}, err
+	}
+	m.mu.Lock()
+	m.sequence++
+	transaction := &Transaction{
+		Reference:      fmt.Sprintf("MOCK-%06d", m.sequence),
+		OrderReference: request.Reference,
+		Status:         StatusAuthorized,
+		Amount:         request.Amount,
+	}
+	switch m.outcome {
+	case OutcomeDecline:
+		transaction.Status = StatusDeclined
+		transaction.Message = "declined by mock provider"
+		err = ErrDeclined
+	case OutcomeTimeout:
+		transaction.Status = StatusPending
+		err = ErrTimeout
+	}
+	m.transactions[transaction.Reference] = transaction
+	answer := *transaction
+	m.mu.Unlock()
+
+	if err == ErrTimeout {
+		<-ctx.Done()
+	}
+	return answer, err
+}
+
+func (m *mockProvider) Capture(ctx context.Context, reference string, amount model.Money) (Transaction, error) {
+	return m.transition(ctx, reference, StatusCaptured, StatusAuthorized)
+}
+
+func (m *mockProvider) Void(ctx context.Context, reference string) (Transaction, error) {
+	return m.transition(ctx, reference, StatusVoided, StatusPending, StatusAuthorized, StatusCaptured)
+}
+
+func (m *mockProvider) Refund(ctx context.Context, reference string, amount model.Money) (Transaction, error) {
+	return m.transition(ctx, reference, StatusRefunded, StatusCaptured)
+}
+
+func (m *mockProvider) Status(ctx context.Context, reference string) (Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	transaction, ok := m.transactions[reference]
+	if !ok {
+		return Transaction{}, ErrNotFound
+	}
+	return *transaction, nil
+}
+
+// Confirm settles the pending transaction of an order, standing in for a
+// provider's callback.
+func (m *mockProvider) Confirm(ctx context.Context, callback Callback) (Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, transaction := range m.transactions {
+		if transaction.OrderReference != callback.OrderReference {
+			continue
+		}
+		if transaction.Amount != callback.Amount {
+			return *transaction, ErrMismatch
+		}
+		if transaction.Status == StatusPending {
+			transaction.Status = StatusDeclined
+			if callback.Paid {
+				transaction.Status = StatusCaptured
+			}
+		}
+		return *transaction, nil
+	}
+	return Transaction{}, ErrNotFound
+}
+
+// transition moves a transaction to status to from any of the states in
+// from.
+func (m *mockProvider) transition(ctx context.Context, reference string,
+	to Status, from ...Status) (Transaction, error) {
+
+	err := m.wait(ctx)
+	if err != nil {
+		return Transaction{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	transaction, ok := m.transactions[reference]
+	if !ok {
+		return Transaction{}, ErrNotFound
+	}
+	for _, status := range from {
+		if transaction.Status == status {
+			transaction.Status = to
+			return *transaction, nil
+		}
+	}
+	return *transaction, ErrInvalidState
+}
+
+func (m *mockProvider) wait(ctx context.Context) error {
+	if m.delay <= 0 {
+		return nil
+	}
+	select {
+	case <-time.After(m.delay):
+		return nil
+	case <-ctx.Done():
+		return ErrTimeout
+	}
+}
@@ -0,0 +1,160 @@
+package gateway
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/qris"
+)
+
+// QRISStore keeps the codes a QRIS provider issues.
+type QRISStore interface {
+	CreateQRISCode(ctx context.Context, code model.QRISCode) (model.QRISCode, error)
+	GetQRISCode(ctx context.Context, reference string) (model.QRISCode, error)
+	GetQRISCodeByOrder(ctx context.Context, orderReference string) (model.QRISCode, error)
+	UpdateQRISCodeStatus(ctx context.Context, reference, from, to string) error
+}
+
+type qrisProvider struct {
+	merchant qris.Merchant
+	expiry   time.Duration
+	store    QRISStore
+}
+
+// NewQRIS is a provider taking e-wallet payments by dynamic QRIS codes.
+// Authorize issues a code for the exact amount, valid for expiry, and
+// leaves the payment pending until the acquirer confirms it. QRIS
+// payments are final: refunds are made through the acquirer.
+func NewQRIS(merchant qris.Merchant, expiry time.Duration, store QRISStore) Provider {
+	return &qrisProvider{merchant, expiry, store}
+}
+
+func (q *qrisProvider) Name() string {
+	return "qris"
+}
+
+func (q *qrisProvider) Authorize(ctx context.Context, request Request) (Transaction, error) {
+	if request.Currency != "IDR" {
+		return Transaction{}, ErrNotSupported
+	}
+	payload, err := qris.Payload(q.merchant, request.Amount.Major(), request.Reference)
+	if err != nil {
+		return Transaction{}, err
+	}
+	expiresAt := time.Now().UTC().Add(q.expiry).Truncate(time.Second)
+	code, err := q.store.CreateQRISCode(ctx, model.QRISCode{
+		Reference:      "QRIS-" + request.Reference,
+		OrderReference: request.Reference,
+		Amount:         request.Amount,
+		Payload:        payload,
+		Status:         model.QRISPending,
+		ExpiresAt:      &expiresAt,
+	})
+	if err != nil {
+		return Transaction{}, err
+	}
+	return transaction(code), nil
+}
+
+func (q *qrisProvider) Capture(ctx context.Context, reference string, amount model.Money) (Transaction, error) {
+	transaction, err := q.Status(ctx, reference)
+	if err != nil {
+		return transaction, err
+	}
+	if transaction.Status != StatusCaptured {
+		return transaction, ErrInvalidState
+	}
+	return transaction, nil
+}
+
+// Void cancels a code that has not been paid yet.
+func (q *qrisProvider) Void(ctx context.Context, reference string) (Transaction, error) {
+	err := q.store.UpdateQRISCodeStatus(ctx, reference, model.QRISPending, model.QRISCancelled)
+	if err == sql.ErrNoRows {
+		return Transaction{}, ErrInvalidState
+	}
+	if err != nil {
+		return Transaction{}, err
+	}
+	return q.Status(ctx, reference)
+}
+
+func (q *qrisProvider) Refund(ctx context.Context, reference string, amount model.Money) (Transaction, error) {
+	return Transaction{}, ErrNotSupported
+}
+
+// Status reports a code, expiring it when its time has passed unpaid.
+func (q *qrisProvider) Status(ctx context.Context, reference string) (Transaction, error) {
+	code, err := q.store.GetQRISCode(ctx, reference)
+	if err == sql.ErrNoRows {
+		return Transaction{}, ErrNotFound
+	}
+	if err != nil {
+		return Transaction{}, err
+	}
+	if code.Expired(time.Now()) {
+		err = q.store.UpdateQRISCodeStatus(ctx, reference, model.QRISPending, model.QRISExpired)
+		if err != nil && err != sql.ErrNoRows {
+			return Transaction{}, err
+		}
+		return q.Status(ctx, reference)
+	}
+	return transaction(code), nil
+}
+
+// Confirm records the acquirer's callback for the latest code of an
+// order. A payment arriving after the code expired is still taken, as
+// the customer has been charged. The amount must match the code.
+func (q *qrisProvider) Confirm(ctx context.Context, callback Callback) (Transaction, error) {
+	code, err := q.store.GetQRISCodeByOrder(ctx, callback.OrderReference)
+	if err == sql.ErrNoRows {
+		return Transaction{}, ErrNotFound
+	}
+	if err != nil {
+		return Transaction{}, err
+	}
+	if callback.Amount != code.Amount {
+		return transaction(code), ErrMismatch
+	}
+	status := model.QRISFailed
+	if callback.Paid {
+		status = model.QRISPaid
+	}
+	for _, from := range []string{model.QRISPending, model.QRISExpired} {
+		err = q.store.UpdateQRISCodeStatus(ctx, code.Reference, from, status)
+		if err != sql.ErrNoRows {
+			break
+		}
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return Transaction{}, err
+	}
+	code, err = q.store.GetQRISCode(ctx, code.Reference)
+	if err != nil {
+		return Transaction{}, err
+	}
+	return transaction(code), nil
+}
+
+func transaction(code model.QRISCode) Transaction {
+	status := StatusPending
+	switch code.Status {
+	case model.QRISPaid:
+		status = StatusCaptured
+	case model.QRISFailed:
+		status = StatusDeclined
+	case model.QRISExpired:
+		status = StatusExpired
+	case model.QRISCancelled:
+		status = StatusVoided
+	}
+	return Transaction{
+		Reference:      code.Reference,
+		OrderReference: code.OrderReference,
+		Status:         status,
+		Amount:         code.Amount,
+		Payload:        code.Payload,
+	}
+}
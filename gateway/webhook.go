@@ -0,0 +1,55 @@
+package gateway
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// Headers a signed webhook carries. The signature is the hex HMAC-SHA256,
+// keyed with the provider's secret, of the timestamp, a dot and the raw
+// body.
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+)
+
+// SignatureTolerance is how far a webhook's timestamp may be from now,
+// limiting how long a captured request can be replayed.
+const SignatureTolerance = 5 * time.Minute
+
+var (
+	ErrSignature = errors.New("gateway: invalid webhook signature")
+	ErrStale     = errors.New("gateway: webhook timestamp outside tolerance")
+)
+
+// Sign is the signature of a webhook body sent at timestamp, in unix
+// seconds.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature checks a webhook's signature and that it was sent
+// within SignatureTolerance of now.
+func VerifySignature(secret, timestamp, signature string, body []byte, now time.Time) error {
+	sent, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrSignature
+	}
+	expected := Sign(secret, sent, body)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrSignature
+	}
+	age := now.Sub(time.Unix(sent, 0))
+	if age > SignatureTolerance || age < -SignatureTolerance {
+		return ErrStale
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package gateway
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifySignature(t *testing.T) {
+	const secret = "whsec_test"
+	body := []byte(`{"id":"evt_1"}`)
+	sent := time.Unix(1710417600, 0)
+	// HMAC-SHA256 of "1710417600." and the body, computed independently.
+	signature := "63a8caa939c00277d2e24ba8d9a92e5474d69578881e146842627471a6ee1d60"
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		signature string
+		body      []byte
+		now       time.Time
+		err       error
+	}{
+		{"valid", secret, "1710417600", signature, body, sent, nil},
+		{"valid within tolerance", secret, "1710417600", signature, body, sent.Add(SignatureTolerance), nil},
+		{"clock behind", secret, "1710417600", signature, body, sent.Add(-SignatureTolerance), nil},
+		{"too old", secret, "1710417600", signature, body, sent.Add(SignatureTolerance + time.Second), ErrStale},
+		{"from the future", secret, "1710417600", signature, body, sent.Add(-SignatureTolerance - time.Second), ErrStale},
+		{"wrong secret", "whsec_other", "1710417600", signature, body, sent, ErrSignature},
+		{"altered body", secret, "1710417600", signature, []byte(`{"id":"evt_2"}`), sent, ErrSignature},
+		{"altered timestamp", secret, "1710417601", signature, body, sent, ErrSignature},
+		{"upper case signature", secret, "1710417600", strings.ToUpper(signature), body, sent, ErrSignature},
+		{"timestamp not a number", secret, "now", signature, body, sent, ErrSignature},
+		{"no signature", secret, "1710417600", "", body, sent, ErrSignature},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := VerifySignature(test.secret, test.timestamp, test.signature, test.body, test.now)
+			if err != test.err {
+				t.Errorf("VerifySignature error = %v, want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestSign(t *testing.T) {
+	got := Sign("whsec_test", 1710417600, []byte(`{"id":"evt_1"}`))
+	want := "63a8caa939c00277d2e24ba8d9a92e5474d69578881e146842627471a6ee1d60"
+	if got != want {
+		t.Errorf("Sign = %s, want %s", got, want)
+	}
+}
@@ -5,14 +5,26 @@ import (
 )
 
 type Cashier struct {
-	CashierId int64      `json:"cashierId,omitempty"`
-	Name      string     `json:"name,omitempty" validate:"required"`
-	Passcode  string     `json:"passcode,omitempty" validate:"required,len=6" `
-	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
-	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	CashierId  int64      `json:"cashierId,omitempty"`
+	Name       string     `json:"name,omitempty" validate:"required"`
+	Passcode   string     `json:"passcode,omitempty" validate:"required,len=6" `
+	Role       string     `json:"role,omitempty"`
+	UpdatedAt  *time.Time `json:"updatedAt,omitempty"`
+	CreatedAt  *time.Time `json:"createdAt,omitempty"`
+	ArchivedAt *time.Time `json:"archivedAt,omitempty"`
 }
 
 type ListCashier struct {
 	Cashiers []Cashier `json:"cashiers"`
 	Meta     Meta      `json:"meta"`
 }
+
+var CashierRole = map[string]bool{
+	"CASHIER": true,
+	"MANAGER": true,
+}
+
+const (
+	RoleCashier = "CASHIER"
+	RoleManager = "MANAGER"
+)
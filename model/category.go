@@ -5,8 +5,10 @@ import "time"
 type Category struct {
 	CategoryId int64      `json:"categoryId"`
 	Name       string     `json:"name" validate:"required"`
+	TaxClassId *int64     `json:"taxClassId,omitempty"`
 	UpdatedAt  *time.Time `json:"updatedAt,omitempty"`
 	CreatedAt  *time.Time `json:"createdAt,omitempty"`
+	ArchivedAt *time.Time `json:"archivedAt,omitempty"`
 }
 
 type ListCategory struct {
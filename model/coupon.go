@@ -0,0 +1,64 @@
+package model
+
+import "time"
+
+// Coupon unlocks its promotion for an order when its code is entered.
+// UsageLimit caps redemptions overall and PerCustomerLimit per customer;
+// zero means unlimited.
+type Coupon struct {
+	CouponId         int64      `json:"couponId"`
+	Code             string     `json:"code" validate:"required,max=32"`
+	PromotionId      int64      `json:"promotionId" validate:"required"`
+	UsageLimit       int        `json:"usageLimit"`
+	PerCustomerLimit int        `json:"perCustomerLimit"`
+	Redeemed         int        `json:"redeemed"`
+	StartedAt        *Timestamp `json:"startedAt,omitempty"`
+	ExpiredAt        *Timestamp `json:"expiredAt,omitempty"`
+	UpdatedAt        *time.Time `json:"updatedAt,omitempty"`
+	CreatedAt        *time.Time `json:"createdAt,omitempty"`
+	ArchivedAt       *time.Time `json:"archivedAt,omitempty"`
+}
+
+func (c Coupon) ActiveAt(t time.Time) bool {
+	if c.ArchivedAt != nil {
+		return false
+	}
+	if c.StartedAt != nil && t.Before(c.StartedAt.Time) {
+		return false
+	}
+	if c.ExpiredAt != nil && !t.Before(c.ExpiredAt.Time) {
+		return false
+	}
+	return c.UsageLimit == 0 || c.Redeemed < c.UsageLimit
+}
+
+type ListCoupon struct {
+	Coupons []Coupon `json:"coupons"`
+	Meta    Meta     `json:"meta"`
+}
+
+type CouponRedemption struct {
+	RedemptionId int64      `json:"redemptionId,omitempty"`
+	CouponId     int64      `json:"couponId"`
+	Code         string     `json:"code"`
+	PromotionId  int64      `json:"promotionId"`
+	OrderId      int64      `json:"orderId,omitempty"`
+	ReceiptId    string     `json:"receiptId,omitempty"`
+	CustomerId   *int64     `json:"customerId,omitempty"`
+	Amount       Money      `json:"amount"`
+	CreatedAt    *time.Time `json:"createdAt,omitempty"`
+}
+
+type CouponRedemptions struct {
+	TotalRedemptions int                       `json:"totalRedemptions"`
+	TotalAmount      Money                     `json:"totalAmount"`
+	Coupons          []CouponRedemptionSummary `json:"coupons"`
+	Redemptions      []CouponRedemption        `json:"redemptions"`
+}
+
+type CouponRedemptionSummary struct {
+	CouponId    int64  `json:"couponId"`
+	Code        string `json:"code"`
+	Redemptions int    `json:"redemptions"`
+	Amount      Money  `json:"amount"`
+}
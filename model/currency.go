@@ -0,0 +1,105 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Currency is a currency the store knows of. The store currency comes from
+// the configuration; foreign ones accepted as tender are kept in the
+// currencies table with their exchange rates.
+type Currency struct {
+	CurrencyId int64      `json:"currencyId,omitempty"`
+	Code       string     `json:"code" validate:"required,len=3,uppercase"`
+	Name       string     `json:"name,omitempty"`
+	Symbol     string     `json:"symbol"`
+	MinorUnits int        `json:"minorUnits" validate:"gte=0,lte=4"`
+	Thousands  string     `json:"thousands,omitempty"`
+	Decimal    string     `json:"decimal,omitempty"`
+	Rounding   Rounding   `json:"rounding,omitempty"`
+	UpdatedAt  *time.Time `json:"updatedAt,omitempty"`
+	CreatedAt  *time.Time `json:"createdAt,omitempty"`
+	ArchivedAt *time.Time `json:"archivedAt,omitempty"`
+}
+
+var Currencies = map[string]Currency{
+	"IDR": {Code: "IDR", Symbol: "Rp. ", MinorUnits: 0, Thousands: ",", Decimal: "."},
+	"USD": {Code: "USD", Symbol: "$", MinorUnits: 2, Thousands: ",", Decimal: "."},
+	"SGD": {Code: "SGD", Symbol: "S$", MinorUnits: 2, Thousands: ",", Decimal: "."},
+	"MYR": {Code: "MYR", Symbol: "RM", MinorUnits: 2, Thousands: ",", Decimal: "."},
+	"EUR": {Code: "EUR", Symbol: "€", MinorUnits: 2, Thousands: ".", Decimal: ","},
+	"JPY": {Code: "JPY", Symbol: "¥", MinorUnits: 0, Thousands: ",", Decimal: "."},
+}
+
+// ToStore converts an amount of the currency, in its major units, to the
+// store currency at rate, the store currency paid for one major unit.
+func (c Currency) ToStore(amount string, rate float64) (Money, error) {
+	minor, err := parseMinor(amount, c.MinorUnits, StoreCurrency.Rounding)
+	if err != nil {
+		return 0, err
+	}
+	shift := StoreCurrency.MinorUnits - c.MinorUnits
+	return Money(scale(minor, shift, rate, 1, StoreCurrency.Rounding)), nil
+}
+
+// Normalize writes an amount of the currency in major units with exactly
+// its minor units of decimals.
+func (c Currency) Normalize(amount string) (string, error) {
+	minor, err := parseMinor(amount, c.MinorUnits, StoreCurrency.Rounding)
+	if err != nil {
+		return "", err
+	}
+	return formatMinor(minor, c.MinorUnits), nil
+}
+
+// FromStore is what a store currency amount comes to in the currency at
+// rate, in its major units. It rounds up so a tender of that much always
+// covers the amount.
+func (c Currency) FromStore(amount Money, rate float64) string {
+	shift := c.MinorUnits - StoreCurrency.MinorUnits
+	return formatMinor(scale(int64(amount), shift, 1, rate, RoundUp), c.MinorUnits)
+}
+
+type ListCurrency struct {
+	Currencies []Currency `json:"currencies"`
+	Meta       Meta       `json:"meta"`
+}
+
+// ExchangeRate is what one major unit of a foreign currency is worth in
+// the store currency from EffectiveAt until the next rate takes effect.
+type ExchangeRate struct {
+	ExchangeRateId int64      `json:"exchangeRateId"`
+	CurrencyId     int64      `json:"currencyId"`
+	Code           string     `json:"code,omitempty"`
+	Rate           float64    `json:"rate" validate:"required,gt=0"`
+	EffectiveAt    *Timestamp `json:"effectiveAt,omitempty"`
+	CreatedAt      *time.Time `json:"createdAt,omitempty"`
+}
+
+type ListExchangeRate struct {
+	ExchangeRates []ExchangeRate `json:"exchangeRates"`
+	Meta          Meta           `json:"meta"`
+}
+
+// Tender is cash handed over in a foreign currency. Amount is in the
+// currency's major units; Rate is filled in with the rate it was taken at.
+type Tender struct {
+	Currency string      `json:"currency" validate:"required,len=3"`
+	Amount   json.Number `json:"amount" validate:"required"`
+	Rate     float64     `json:"rate,omitempty"`
+}
+
+// TenderTotal is what was taken in one foreign currency and what it was
+// worth in the store currency.
+type TenderTotal struct {
+	Currency   string `json:"currency"`
+	TotalOrder int    `json:"totalOrder"`
+	Amount     string `json:"amount"`
+	TotalPaid  Money  `json:"totalPaid"`
+}
+
+type TenderSummary struct {
+	From    *time.Time    `json:"from,omitempty"`
+	To      *time.Time    `json:"to,omitempty"`
+	Tenders []TenderTotal `json:"tenders"`
+}
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// Customer is a shopper known to the store. Phone is kept as digits only,
+// so it is found however it is typed at the till.
+type Customer struct {
+	CustomerId int64      `json:"customerId"`
+	Name       string     `json:"name" validate:"required,max=255"`
+	Phone      string     `json:"phone" validate:"max=32"`
+	Email      string     `json:"email" validate:"omitempty,email,max=255"`
+	Notes      string     `json:"notes" validate:"max=1000"`
+	Tags       []string   `json:"tags" validate:"max=10,dive,max=48"`
+	UpdatedAt  *time.Time `json:"updatedAt,omitempty"`
+	CreatedAt  *time.Time `json:"createdAt,omitempty"`
+	ArchivedAt *time.Time `json:"archivedAt,omitempty"`
+}
+
+type ListCustomer struct {
+	Customers []Customer `json:"customers"`
+	Meta      Meta       `json:"meta"`
+}
+
+// CustomerFilter narrows a customer search. Phone matches the end of the
+// stored number, so a local number finds one stored with its country
+// code; Query matches names and emails; Tag matches one of the tags.
+type CustomerFilter struct {
+	Query string
+	Phone string
+	Tag   string
+}
+
+// CustomerOrder is an order in a customer's purchase history.
+type CustomerOrder struct {
+	Order    Order                  `json:"order"`
+	Products []OrderedProductDetail `json:"products"`
+}
+
+// CustomerHistory is what a customer bought. The totals count paid
+// orders only, while Orders lists every order, newest first.
+type CustomerHistory struct {
+	Customer    Customer        `json:"customer"`
+	TotalOrder  int             `json:"totalOrder"`
+	TotalSpent  Money           `json:"totalSpent"`
+	LastOrderAt *time.Time      `json:"lastOrderAt,omitempty"`
+	Orders      []CustomerOrder `json:"orders"`
+	Meta        Meta            `json:"meta"`
+}
+
+// NormalizePhone keeps only the digits of a phone number.
+func NormalizePhone(phone string) string {
+	var digits strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	return digits.String()
+}
+
+// NormalizeTags trims tags and drops empty and repeated ones. Tags cannot
+// hold commas, which separate them in storage.
+func NormalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.TrimSpace(strings.ReplaceAll(tag, ",", " "))
+		if tag == "" || seen[strings.ToLower(tag)] {
+			continue
+		}
+		seen[strings.ToLower(tag)] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
@@ -0,0 +1,153 @@
+package model
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Discount is a product discount: Percent off the line for a PERCENT
+// discount, or Amount off each of the first Qty units once Qty are bought
+// for a BUY_N discount.
+type Discount struct {
+	DiscountID      int64      `json:"discountId,omitempty"`
+	Qty             int        `json:"qty" validate:"required"`
+	Type            string     `json:"type" validate:"required"`
+	Amount          Money      `json:"amount,omitempty"`
+	Percent         int        `json:"percent,omitempty"`
+	StartedAt       *Timestamp `json:"startedAt,omitempty"`
+	ExpiredAt       *Timestamp `json:"expiredAt,omitempty"`
+	ExpiredAtFormat string     `json:"expiratedAtFormat"`
+	StringFormat    string     `json:"stringFormat"`
+	UpdatedAt       *time.Time `json:"updatedAt,omitempty"`
+	CreatedAt       *time.Time `json:"createdAt,omitempty"`
+	ArchivedAt      *time.Time `json:"archivedAt,omitempty"`
+}
+
+// ActiveAt reports whether the discount may be applied at t: it must have
+// started, not be expired and not be archived.
+func (d Discount) ActiveAt(t time.Time) bool {
+	if d.ArchivedAt != nil {
+		return false
+	}
+	if d.StartedAt != nil && t.Before(d.StartedAt.Time) {
+		return false
+	}
+	if d.ExpiredAt != nil && !t.Before(d.ExpiredAt.Time) {
+		return false
+	}
+	return true
+}
+
+// UnmarshalJSON also accepts result, the field that held the percent or
+// the amount before the two were split, from older clients.
+func (d *Discount) UnmarshalJSON(data []byte) error {
+	type discount Discount
+	body := struct {
+		*discount
+		Result json.RawMessage `json:"result"`
+	}{discount: (*discount)(d)}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return err
+	}
+	return legacyValue(body.Result, d.Type == Percent, &d.Percent, &d.Amount)
+}
+
+// legacyValue decodes value, sent in the single field discounts had before
+// percentages and amounts were split, into percent or amount as isPercent
+// says. A field already set under its new name is kept.
+func legacyValue(value json.RawMessage, isPercent bool, percent *int, amount *Money) error {
+	if len(value) == 0 || bytes.Equal(value, []byte("null")) {
+		return nil
+	}
+	if isPercent {
+		if *percent != 0 {
+			return nil
+		}
+		return json.Unmarshal(value, percent)
+	}
+	if *amount != 0 {
+		return nil
+	}
+	return json.Unmarshal(value, amount)
+}
+
+type ListDiscount struct {
+	Discounts []Discount `json:"discounts"`
+	Meta      Meta       `json:"meta"`
+}
+
+type ProductDiscountRequest struct {
+	DiscountId int64 `json:"discountId" validate:"required"`
+}
+
+var DiscountType = map[string]bool{
+	"PERCENT": true,
+	"BUY_N":   true,
+}
+
+const Percent = "PERCENT"
+const BuyN = "BUY_N"
+
+// Timestamp is a point in time that decodes from either unix seconds, as
+// the discount API has always accepted, or an RFC 3339 string.
+type Timestamp struct {
+	time.Time
+}
+
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.UTC().Format(RFC3339MilliZ))
+}
+
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	var unix float64
+	if err := json.Unmarshal(data, &unix); err == nil {
+		t.Time = time.Unix(int64(unix), 0).UTC()
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	parsed, err := time.Parse(time.RFC3339, text)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed.UTC()
+	return nil
+}
+
+// ParseTimestamp reads a timestamp given as unix seconds or RFC3339, the
+// same forms accepted in JSON.
+func ParseTimestamp(value string) (Timestamp, error) {
+	if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return Timestamp{time.Unix(unix, 0).UTC()}, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return Timestamp{}, err
+	}
+	return Timestamp{parsed.UTC()}, nil
+}
+
+func (t *Timestamp) Scan(src interface{}) error {
+	switch value := src.(type) {
+	case time.Time:
+		t.Time = value
+		return nil
+	case nil:
+		t.Time = time.Time{}
+		return nil
+	}
+	return fmt.Errorf("model: cannot scan %T into Timestamp", src)
+}
+
+func (t Timestamp) Value() (driver.Value, error) {
+	return t.Time, nil
+}
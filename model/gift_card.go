@@ -0,0 +1,121 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// Stored-value card types. Gift cards are sold and can be reloaded, while
+// store credit is issued when an order is refunded to one.
+const (
+	GiftCardTypeGift   = "GIFT_CARD"
+	GiftCardTypeCredit = "STORE_CREDIT"
+)
+
+// Gift card ledger entry types.
+const (
+	GiftCardIssue   = "ISSUE"
+	GiftCardReload  = "RELOAD"
+	GiftCardRedeem  = "REDEEM"
+	GiftCardReverse = "REVERSE"
+)
+
+// GiftCard is a stored-value card known by its code. OrderId is the
+// refunded order store credit was issued for. A card past ExpiresAt keeps
+// its balance but can no longer be redeemed or reloaded.
+type GiftCard struct {
+	GiftCardId int64      `json:"giftCardId"`
+	Code       string     `json:"code"`
+	Type       string     `json:"type"`
+	CustomerId *int64     `json:"customerId,omitempty"`
+	OrderId    *int64     `json:"orderId,omitempty"`
+	Balance    Money      `json:"balance"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	UpdatedAt  *time.Time `json:"updatedAt,omitempty"`
+	CreatedAt  *time.Time `json:"createdAt,omitempty"`
+}
+
+// Expired tells whether the card has expired by now.
+func (c GiftCard) Expired(now time.Time) bool {
+	return c.ExpiresAt != nil && !c.ExpiresAt.After(now)
+}
+
+type ListGiftCard struct {
+	GiftCards []GiftCard `json:"giftCards"`
+	Meta      Meta       `json:"meta"`
+}
+
+// IssueGiftCardRequest sells a new gift card. Without ExpiresAt the card
+// expires after the store's gift card validity.
+type IssueGiftCardRequest struct {
+	Amount     Money      `json:"amount" validate:"required,gt=0"`
+	CustomerId *int64     `json:"customerId,omitempty"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+}
+
+type ReloadGiftCardRequest struct {
+	Amount Money `json:"amount" validate:"required,gt=0"`
+}
+
+// GiftCardEntry is a line of a card's ledger. Amount is positive when
+// credited and negative when debited, and Balance is what the card held
+// after it.
+type GiftCardEntry struct {
+	GiftCardEntryId int64      `json:"giftCardEntryId"`
+	GiftCardId      int64      `json:"giftCardId"`
+	OrderId         *int64     `json:"orderId,omitempty"`
+	Type            string     `json:"type"`
+	Amount          Money      `json:"amount"`
+	Balance         Money      `json:"balance"`
+	CreatedAt       *time.Time `json:"createdAt,omitempty"`
+}
+
+// GiftCardDetail is a card with its ledger, newest first.
+type GiftCardDetail struct {
+	GiftCard GiftCard        `json:"giftCard"`
+	Entries  []GiftCardEntry `json:"entries"`
+	Meta     Meta            `json:"meta"`
+}
+
+// GiftCardTender is part of an order paid from a card.
+type GiftCardTender struct {
+	Code   string `json:"code"`
+	Amount Money  `json:"amount"`
+}
+
+// GiftCardLiabilityLine is what the cards of one type still hold.
+type GiftCardLiabilityLine struct {
+	Type      string `json:"type"`
+	TotalCard int    `json:"totalCard"`
+	Balance   Money  `json:"balance"`
+}
+
+// GiftCardLiability is what the store owes on its cards. Outstanding
+// counts the cards that can still be redeemed, while the balances left on
+// expired cards show under Expired.
+type GiftCardLiability struct {
+	Types       []GiftCardLiabilityLine `json:"types"`
+	TotalCard   int                     `json:"totalCard"`
+	Outstanding Money                   `json:"outstanding"`
+	Expired     ReportCount             `json:"expired"`
+	GeneratedAt time.Time               `json:"generatedAt"`
+}
+
+// RefundRequest is the optional body of a refund. With StoreCredit the
+// amount paid is issued as store credit instead of going back through the
+// payment provider.
+type RefundRequest struct {
+	StoreCredit bool `json:"storeCredit"`
+}
+
+// NormalizeGiftCardCode upper-cases a card code and drops the spaces and
+// dashes it may be typed with.
+func NormalizeGiftCardCode(code string) string {
+	var normalized strings.Builder
+	for _, r := range strings.ToUpper(code) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			normalized.WriteRune(r)
+		}
+	}
+	return normalized.String()
+}
@@ -0,0 +1,56 @@
+package model
+
+import "time"
+
+// Points ledger entry types. Earned points are kept as lots, each with
+// what is left of it and when it expires; redemptions, reversals and
+// expiry take points from the lots expiring first.
+const (
+	PointsEarn    = "EARN"
+	PointsRedeem  = "REDEEM"
+	PointsReverse = "REVERSE"
+	PointsRestore = "RESTORE"
+	PointsExpire  = "EXPIRE"
+)
+
+// LoyaltyRule earns Points for every full Spend on the products of a
+// category. A rule without a category applies to products whose category
+// has no rule of its own. Lines sold at a discount earn nothing.
+type LoyaltyRule struct {
+	LoyaltyRuleId int64      `json:"loyaltyRuleId"`
+	CategoryId    *int64     `json:"categoryId"`
+	Spend         Money      `json:"spend" validate:"required,gt=0"`
+	Points        int64      `json:"points" validate:"required,gt=0"`
+	UpdatedAt     *time.Time `json:"updatedAt,omitempty"`
+	CreatedAt     *time.Time `json:"createdAt,omitempty"`
+	ArchivedAt    *time.Time `json:"archivedAt,omitempty"`
+}
+
+type ListLoyaltyRule struct {
+	LoyaltyRules []LoyaltyRule `json:"loyaltyRules"`
+	Meta         Meta          `json:"meta"`
+}
+
+// PointsEntry is a line of a customer's points ledger. Points are positive
+// when credited and negative when debited; Remaining is what is left of a
+// credit to spend.
+type PointsEntry struct {
+	PointsEntryId int64      `json:"pointsEntryId"`
+	CustomerId    int64      `json:"customerId"`
+	OrderId       *int64     `json:"orderId,omitempty"`
+	Type          string     `json:"type"`
+	Points        int64      `json:"points"`
+	Remaining     int64      `json:"remaining"`
+	ExpiresAt     *time.Time `json:"expiresAt,omitempty"`
+	CreatedAt     *time.Time `json:"createdAt,omitempty"`
+}
+
+// PointsBalance is a customer's points with the ledger behind them, newest
+// first. Value is what the balance is worth as a tender.
+type PointsBalance struct {
+	CustomerId int64         `json:"customerId"`
+	Balance    int64         `json:"balance"`
+	Value      Money         `json:"value"`
+	Entries    []PointsEntry `json:"entries"`
+	Meta       Meta          `json:"meta"`
+}
@@ -0,0 +1,76 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ManualDiscount is a discount given by hand at the till, on a single line
+// or on the whole order: Amount off for an AMOUNT discount, or Percent off
+// for a PERCENT one.
+type ManualDiscount struct {
+	Type    string `json:"type" validate:"required"`
+	Amount  Money  `json:"amount,omitempty"`
+	Percent int    `json:"percent,omitempty"`
+	Reason  string `json:"reason" validate:"required"`
+}
+
+// UnmarshalJSON also accepts value, the field that held the percent or the
+// amount before the two were split, from older clients.
+func (d *ManualDiscount) UnmarshalJSON(data []byte) error {
+	type manualDiscount ManualDiscount
+	body := struct {
+		*manualDiscount
+		Value json.RawMessage `json:"value"`
+	}{manualDiscount: (*manualDiscount)(d)}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return err
+	}
+	return legacyValue(body.Value, d.Type == Percent, &d.Percent, &d.Amount)
+}
+
+// ManagerApproval authorises manual discounts above the store limit.
+type ManagerApproval struct {
+	ManagerId int64  `json:"managerId" validate:"required"`
+	Passcode  string `json:"passcode" validate:"required"`
+}
+
+// OrderDiscount is a manual discount as recorded on an order, with the
+// amount it took off. Line discounts carry the product.
+type OrderDiscount struct {
+	OrderDiscountId int64      `json:"orderDiscountId,omitempty"`
+	OrderId         int64      `json:"orderId,omitempty"`
+	ReceiptId       string     `json:"receiptId,omitempty"`
+	ProductId       *int64     `json:"productId,omitempty"`
+	Type            string     `json:"type"`
+	Percent         int        `json:"percent,omitempty"`
+	Amount          Money      `json:"amount"`
+	Reason          string     `json:"reason"`
+	ApprovedBy      *int64     `json:"approvedBy,omitempty"`
+	Approver        string     `json:"approver,omitempty"`
+	CreatedAt       *time.Time `json:"createdAt,omitempty"`
+}
+
+// UnmarshalJSON also accepts value, which held the percent of a PERCENT
+// discount before it had a field of its own.
+func (d *OrderDiscount) UnmarshalJSON(data []byte) error {
+	type orderDiscount OrderDiscount
+	body := struct {
+		*orderDiscount
+		Value json.RawMessage `json:"value"`
+	}{orderDiscount: (*orderDiscount)(d)}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return err
+	}
+	if d.Type != Percent {
+		return nil
+	}
+	return legacyValue(body.Value, true, &d.Percent, &d.Amount)
+}
+
+type DiscountAudit struct {
+	TotalAmount Money           `json:"totalAmount"`
+	Total       int             `json:"total"`
+	Approved    int             `json:"approved"`
+	Discounts   []OrderDiscount `json:"discounts"`
+}
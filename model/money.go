@@ -0,0 +1,275 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in the minor units of the store currency, so a price
+// of Rp12.500 is 12500 and $12.50 is 1250. It is stored as such and shown
+// in JSON in major units.
+type Money int64
+
+// Rounding decides which way an amount falling between two units goes.
+type Rounding string
+
+const (
+	RoundHalfUp   Rounding = "HALF_UP"
+	RoundHalfEven Rounding = "HALF_EVEN"
+	RoundUp       Rounding = "UP"
+	RoundDown     Rounding = "DOWN"
+	// RoundNearest is kept for payment types created before the modes were
+	// named; it rounds half up.
+	RoundNearest Rounding = "NEAREST"
+)
+
+var RoundingMode = map[Rounding]bool{
+	RoundHalfUp:   true,
+	RoundHalfEven: true,
+	RoundUp:       true,
+	RoundDown:     true,
+	RoundNearest:  true,
+}
+
+// StoreCurrency is the currency every Money amount is in. It is set once
+// from the configuration at start up.
+var StoreCurrency = Currency{
+	Code:       "IDR",
+	Symbol:     "Rp. ",
+	MinorUnits: 0,
+	Thousands:  ",",
+	Decimal:    ".",
+	Rounding:   RoundHalfUp,
+}
+
+var ErrInvalidMoney = errors.New("model: invalid money amount")
+
+// Mul multiplies the amount by a quantity or factor, rounding with the
+// store currency's rounding mode.
+func (m Money) Mul(factor float64) Money {
+	return m.Scale(factor, 1)
+}
+
+// Percent is percent of the amount.
+func (m Money) Percent(percent float64) Money {
+	return m.Scale(percent, 100)
+}
+
+// Scale multiplies the amount by num/den, rounding with the store
+// currency's rounding mode.
+func (m Money) Scale(num, den float64) Money {
+	return m.ScaleRound(num, den, StoreCurrency.Rounding)
+}
+
+// ScaleRound multiplies the amount by num/den and rounds the result to a
+// whole minor unit with mode. The factors are taken as the shortest
+// decimals that represent them, so the result does not depend on floating
+// point error. A zero den gives zero.
+func (m Money) ScaleRound(num, den float64, mode Rounding) Money {
+	return Money(scale(int64(m), 0, num, den, mode))
+}
+
+// scale multiplies amount by num/den and by 10^shift, rounding once at the
+// end with mode. The shift moves an amount between currencies with
+// different minor units.
+func scale(amount int64, shift int, num, den float64, mode Rounding) int64 {
+	numerator, numScale := decimal(num)
+	denominator, denScale := decimal(den)
+	if denominator.Sign() == 0 {
+		return 0
+	}
+	numerator.Mul(numerator, big.NewInt(amount))
+	numerator.Mul(numerator, denScale)
+	denominator.Mul(denominator, numScale)
+	if shift > 0 {
+		numerator.Mul(numerator, pow10(shift))
+	} else {
+		denominator.Mul(denominator, pow10(-shift))
+	}
+	return divide(numerator, denominator, mode)
+}
+
+// RoundTo rounds the amount to a multiple of unit, as cash totals are
+// rounded to the smallest coin in circulation. A unit of one minor unit or
+// less leaves the amount exact.
+func (m Money) RoundTo(unit Money, mode Rounding) Money {
+	if unit <= 1 {
+		return m
+	}
+	return Money(divide(big.NewInt(int64(m)), big.NewInt(int64(unit)), mode)) * unit
+}
+
+// Format writes the amount in major units with the store currency's
+// separators, without its symbol.
+func (m Money) Format() string {
+	currency := StoreCurrency
+	digits := strconv.FormatInt(int64(m), 10)
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	if len(digits) <= currency.MinorUnits {
+		digits = strings.Repeat("0", currency.MinorUnits-len(digits)+1) + digits
+	}
+	whole, fraction := digits[:len(digits)-currency.MinorUnits], digits[len(digits)-currency.MinorUnits:]
+	var grouped strings.Builder
+	for index, digit := range whole {
+		if index > 0 && (len(whole)-index)%3 == 0 {
+			grouped.WriteString(currency.Thousands)
+		}
+		grouped.WriteRune(digit)
+	}
+	if fraction != "" {
+		grouped.WriteString(currency.Decimal)
+		grouped.WriteString(fraction)
+	}
+	return sign + grouped.String()
+}
+
+// String is the amount with the store currency's symbol, as printed on
+// receipts.
+func (m Money) String() string {
+	if m < 0 {
+		return "-" + StoreCurrency.Symbol + (-m).Format()
+	}
+	return StoreCurrency.Symbol + m.Format()
+}
+
+// Major is the amount in major units as a plain decimal, the form used in
+// JSON.
+func (m Money) Major() string {
+	return formatMinor(int64(m), StoreCurrency.MinorUnits)
+}
+
+func formatMinor(amount int64, units int) string {
+	digits := strconv.FormatInt(amount, 10)
+	if units == 0 {
+		return digits
+	}
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	if len(digits) <= units {
+		digits = strings.Repeat("0", units-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-units] + "." + digits[len(digits)-units:]
+}
+
+// ParseMoney reads an amount in major units, such as "12.50". Digits past
+// the currency's minor units are rounded with its rounding mode.
+func ParseMoney(value string) (Money, error) {
+	amount, err := parseMinor(value, StoreCurrency.MinorUnits, StoreCurrency.Rounding)
+	return Money(amount), err
+}
+
+func parseMinor(value string, units int, mode Rounding) (int64, error) {
+	value = strings.TrimSpace(value)
+	if strings.ContainsAny(value, "eE") {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, ErrInvalidMoney
+		}
+		value = strconv.FormatFloat(parsed, 'f', -1, 64)
+	}
+	numerator, ok := new(big.Int).SetString(strings.Replace(value, ".", "", 1), 10)
+	if !ok {
+		return 0, ErrInvalidMoney
+	}
+	var fraction int
+	if dot := strings.Index(value, "."); dot >= 0 {
+		fraction = len(value) - dot - 1
+	}
+	if fraction <= units {
+		numerator.Mul(numerator, pow10(units-fraction))
+		if !numerator.IsInt64() {
+			return 0, ErrInvalidMoney
+		}
+		return numerator.Int64(), nil
+	}
+	rounded := round(numerator, pow10(fraction-units), mode)
+	if !rounded.IsInt64() {
+		return 0, ErrInvalidMoney
+	}
+	return rounded.Int64(), nil
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.Major()), nil
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		var number json.Number
+		if err := json.Unmarshal(data, &number); err != nil {
+			return err
+		}
+		text = number.String()
+	}
+	parsed, err := ParseMoney(text)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// decimal returns value as the fraction num/den of its shortest decimal
+// representation.
+func decimal(value float64) (*big.Int, *big.Int) {
+	text := strconv.FormatFloat(value, 'f', -1, 64)
+	var fraction int
+	if dot := strings.Index(text, "."); dot >= 0 {
+		fraction = len(text) - dot - 1
+		text = text[:dot] + text[dot+1:]
+	}
+	num, _ := new(big.Int).SetString(text, 10)
+	return num, pow10(fraction)
+}
+
+func pow10(exponent int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil)
+}
+
+// divide is num/den rounded to an integer with mode, for results known to
+// fit in an int64.
+func divide(num, den *big.Int, mode Rounding) int64 {
+	return round(num, den, mode).Int64()
+}
+
+// round is num/den rounded to an integer with mode. Up and down are away
+// from and towards zero; the half modes go to the nearest integer and
+// differ only on ties.
+func round(num, den *big.Int, mode Rounding) *big.Int {
+	num, den = new(big.Int).Set(num), new(big.Int).Set(den)
+	if den.Sign() < 0 {
+		num.Neg(num)
+		den.Neg(den)
+	}
+	quotient, remainder := new(big.Int).QuoRem(num, den, new(big.Int))
+	if remainder.Sign() == 0 {
+		return quotient
+	}
+	away := big.NewInt(int64(num.Sign()))
+	switch mode {
+	case RoundDown:
+	case RoundUp:
+		quotient.Add(quotient, away)
+	default:
+		twice := remainder.Abs(remainder)
+		twice.Lsh(twice, 1)
+		compare := twice.Cmp(den)
+		if compare > 0 || (compare == 0 && (mode != RoundHalfEven || quotient.Bit(0) == 1)) {
+			quotient.Add(quotient, away)
+		}
+	}
+	return quotient
+}
@@ -0,0 +1,121 @@
+package model
+
+import "testing"
+
+func TestScaleRound(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount Money
+		num    float64
+		den    float64
+		mode   Rounding
+		want   Money
+	}{
+		{"exact", 1000, 11, 100, RoundHalfUp, 110},
+		{"half up", 1050, 1, 100, RoundHalfUp, 11},
+		{"half up negative", -1050, 1, 100, RoundHalfUp, -11},
+		{"half even down", 1050, 1, 100, RoundHalfEven, 10},
+		{"half even up", 1150, 1, 100, RoundHalfEven, 12},
+		{"up", 1001, 1, 100, RoundUp, 11},
+		{"up negative", -1001, 1, 100, RoundUp, -11},
+		{"down", 1099, 1, 100, RoundDown, 10},
+		{"nearest is half up", 1050, 1, 100, RoundNearest, 11},
+		{"inclusive tax", 10000, 11, 111, RoundHalfUp, 991},
+		{"decimal factor", 1000, 0.1, 1, RoundHalfUp, 100},
+		{"fractional quantity", 12500, 1.5, 1, RoundHalfUp, 18750},
+		{"zero den", 1000, 1, 0, RoundHalfUp, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.amount.ScaleRound(test.num, test.den, test.mode)
+			if got != test.want {
+				t.Errorf("%d.ScaleRound(%v, %v, %s) = %d, want %d",
+					test.amount, test.num, test.den, test.mode, got, test.want)
+			}
+		})
+	}
+}
+
+func TestPercent(t *testing.T) {
+	tests := []struct {
+		amount  Money
+		percent float64
+		want    Money
+	}{
+		{10000, 10, 1000},
+		{10005, 10, 1001},
+		{999, 33.3, 333},
+		{10000, 0, 0},
+		{10000, 100, 10000},
+	}
+	for _, test := range tests {
+		if got := test.amount.Percent(test.percent); got != test.want {
+			t.Errorf("%d.Percent(%v) = %d, want %d", test.amount, test.percent, got, test.want)
+		}
+	}
+}
+
+func TestRoundTo(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount Money
+		unit   Money
+		mode   Rounding
+		want   Money
+	}{
+		{"half up", 12450, 100, RoundHalfUp, 12500},
+		{"half up below", 12449, 100, RoundHalfUp, 12400},
+		{"half even", 12450, 100, RoundHalfEven, 12400},
+		{"up", 12401, 100, RoundUp, 12500},
+		{"down", 12499, 100, RoundDown, 12400},
+		{"negative", -12450, 100, RoundHalfUp, -12500},
+		{"already a multiple", 12500, 500, RoundUp, 12500},
+		{"unit of one", 12345, 1, RoundUp, 12345},
+		{"no unit", 12345, 0, RoundUp, 12345},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.amount.RoundTo(test.unit, test.mode)
+			if got != test.want {
+				t.Errorf("%d.RoundTo(%d, %s) = %d, want %d",
+					test.amount, test.unit, test.mode, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseMoney(t *testing.T) {
+	defer func(currency Currency) { StoreCurrency = currency }(StoreCurrency)
+	StoreCurrency = Currency{Code: "USD", Symbol: "$", MinorUnits: 2, Rounding: RoundHalfUp}
+
+	tests := []struct {
+		value string
+		want  Money
+		err   error
+	}{
+		{"12.50", 1250, nil},
+		{"12.5", 1250, nil},
+		{"12", 1200, nil},
+		{" 0.07 ", 7, nil},
+		{"-3.25", -325, nil},
+		{"12.345", 1235, nil},
+		{"12.344", 1234, nil},
+		{"1e2", 10000, nil},
+		{"", 0, ErrInvalidMoney},
+		{"12,50", 0, ErrInvalidMoney},
+		{"abc", 0, ErrInvalidMoney},
+		{"92233720368547758.07", 9223372036854775807, nil},
+		{"92233720368547758.08", 0, ErrInvalidMoney},
+		{"92233720368547758.075", 0, ErrInvalidMoney},
+	}
+	for _, test := range tests {
+		got, err := ParseMoney(test.value)
+		if err != test.err {
+			t.Errorf("ParseMoney(%q) error = %v, want %v", test.value, err, test.err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("ParseMoney(%q) = %d, want %d", test.value, got, test.want)
+		}
+	}
+}
@@ -1,10 +1,19 @@
 package model
 
-import "time"
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
 
 type OrderDetails struct {
 	Order          Order                  `json:"order"`
 	OrderedProduct []OrderedProductDetail `json:"products,omitempty"`
+	Promotions     []AppliedPromotion     `json:"promotions,omitempty"`
+	Coupons        []CouponRedemption     `json:"coupons,omitempty"`
+	Discounts      []OrderDiscount        `json:"manualDiscounts,omitempty"`
+	Taxes          []OrderTax             `json:"taxes,omitempty"`
+	Receipts       []ReceiptMessage       `json:"receipts,omitempty"`
 }
 
 type ListOrders struct {
@@ -12,51 +21,143 @@ type ListOrders struct {
 	Meta  Meta    `json:"meta"`
 }
 
+// Order states. Orders paid through a payment provider stay pending until
+// the provider confirms the payment; every other order is paid when it is
+// placed. Only paid orders count towards the reports.
+const (
+	OrderPaid           = "PAID"
+	OrderPendingPayment = "PENDING_PAYMENT"
+	OrderPaymentFailed  = "PAYMENT_FAILED"
+	OrderVoided         = "VOIDED"
+	OrderRefunded       = "REFUNDED"
+)
+
 type Order struct {
-	OrderId           int64      `json:"orderId"`
-	CashierID         *int64     `json:"cashiersId,omitempty"`
-	PaymentID         *int64     `json:"paymentTypesId"`
-	TotalPrice        int        `json:"totalPrice"`
-	TotalPaid         int        `json:"totalPaid"`
-	TotalReturn       int        `json:"totalReturn"`
-	ReceiptID         string     `json:"receiptId"`
-	ReceiptIDFilePath string     `json:"-"`
-	UpdatedAt         *time.Time `json:"updatedAt"`
-	CreatedAt         *time.Time `json:"createdAt"`
-	Cashier           *Cashier   `json:"cashier,omitempty"`
-	PaymentType       *Payment   `json:"payment_type,omitempty"`
+	OrderId           int64            `json:"orderId"`
+	CashierID         *int64           `json:"cashiersId,omitempty"`
+	PaymentID         *int64           `json:"paymentTypesId"`
+	TotalPrice        Money            `json:"totalPrice"`
+	TotalDiscount     Money            `json:"totalDiscount"`
+	ManualDiscount    Money            `json:"manualDiscount"`
+	TotalTax          Money            `json:"totalTax"`
+	TaxInclusive      bool             `json:"taxInclusive"`
+	ServiceCharge     Money            `json:"serviceCharge"`
+	Tip               Money            `json:"tip"`
+	Rounding          Money            `json:"rounding"`
+	TotalPaid         Money            `json:"totalPaid"`
+	TotalReturn       Money            `json:"totalReturn"`
+	Tender            *Tender          `json:"tender,omitempty"`
+	Status            string           `json:"status"`
+	PaymentProvider   string           `json:"paymentProvider,omitempty"`
+	PaymentReference  string           `json:"paymentReference,omitempty"`
+	ApprovalCode      string           `json:"approvalCode,omitempty"`
+	MaskedPAN         string           `json:"maskedPan,omitempty"`
+	TerminalID        string           `json:"terminalId,omitempty"`
+	ShiftId           *int64           `json:"shiftId,omitempty"`
+	CustomerId        *int64           `json:"customerId,omitempty"`
+	PointsEarned      int64            `json:"pointsEarned,omitempty"`
+	PointsRedeemed    int64            `json:"pointsRedeemed,omitempty"`
+	PointsAmount      Money            `json:"pointsAmount,omitempty"`
+	PointsExpireAt    *time.Time       `json:"-"`
+	GiftCardAmount    Money            `json:"giftCardAmount,omitempty"`
+	GiftCards         []GiftCardTender `json:"giftCards,omitempty"`
+	StoreCredit       *GiftCard        `json:"storeCredit,omitempty"`
+	ReceiptID         string           `json:"receiptId"`
+	ReceiptIDFilePath string           `json:"-"`
+	UpdatedAt         *time.Time       `json:"updatedAt"`
+	CreatedAt         *time.Time       `json:"createdAt"`
+	Cashier           *Cashier         `json:"cashier,omitempty"`
+	PaymentType       *Payment         `json:"payment_type,omitempty"`
 }
 
 type OrderedProductDetail struct {
 	ProductId        int64     `json:"productId"`
 	Name             string    `json:"name" validate:"required"`
-	Price            int       `json:"price" validate:"required"`
+	Price            Money     `json:"price" validate:"required"`
 	Discount         *Discount `json:"discount"`
-	Qty              int       `json:"qty" validate:"required"`
-	TotalNormalPrice int       `json:"totalNormalPrice"`
-	TotalFinalPrice  int       `json:"totalFinalPrice"`
+	Qty              float64   `json:"qty" validate:"required"`
+	Unit             string    `json:"unit"`
+	QtyFormat        string    `json:"qtyFormat"`
+	TotalNormalPrice Money     `json:"totalNormalPrice"`
+	TotalFinalPrice  Money     `json:"totalFinalPrice"`
+	TaxClassId       *int64    `json:"taxClassId,omitempty"`
+	TaxRate          float64   `json:"taxRate"`
+	TaxableAmount    Money     `json:"taxableAmount"`
+	TaxAmount        Money     `json:"taxAmount"`
+	CostPrice        Money     `json:"-"`
+	TotalCostPrice   Money     `json:"-"`
 	DiscountId       *int64    `json:"-"`
 }
 
 type SubOrderedProductDetail struct {
 	Product
-	Qty              int `json:"qty" validate:"required"`
-	TotalNormalPrice int `json:"totalNormalPrice"`
-	TotalFinalPrice  int `json:"totalFinalPrice"`
+	Qty              float64 `json:"qty" validate:"required"`
+	QtyFormat        string  `json:"qtyFormat"`
+	TotalNormalPrice Money   `json:"totalNormalPrice"`
+	TotalFinalPrice  Money   `json:"totalFinalPrice"`
+	TaxRate          float64 `json:"taxRate"`
+	TaxableAmount    Money   `json:"taxableAmount"`
+	TaxAmount        Money   `json:"taxAmount"`
 }
 
 type AddOrderRequest struct {
 	PaymentID      int64            `json:"paymentId" validate:"required"`
-	TotalPaid      int              `json:"totalPaid" validate:"required"`
+	CashierID      *int64           `json:"cashierId,omitempty"`
+	TotalPaid      Money            `json:"totalPaid" validate:"required"`
+	Tender         *Tender          `json:"tender,omitempty"`
+	Tip            Money            `json:"tip"`
+	OrderedProduct []OrderedProduct `json:"products"`
+	Coupons        []string         `json:"coupons,omitempty"`
+	CustomerId     *int64           `json:"customerId,omitempty"`
+	RedeemPoints   int64            `json:"redeemPoints,omitempty"`
+	GiftCards      []GiftCardTender `json:"giftCards,omitempty"`
+	Receipt        *ReceiptRequest  `json:"receipt,omitempty"`
+	ManualDiscount *ManualDiscount  `json:"manualDiscount,omitempty"`
+	Approval       *ManagerApproval `json:"approval,omitempty"`
+}
+
+// SubTotalRequest is the body of a subtotal request. A bare array of
+// products is accepted as well.
+type SubTotalRequest struct {
 	OrderedProduct []OrderedProduct `json:"products"`
+	PaymentID      *int64           `json:"paymentId,omitempty"`
+	Currency       string           `json:"currency,omitempty"`
+	Tip            Money            `json:"tip"`
+	Coupons        []string         `json:"coupons,omitempty"`
+	CustomerId     *int64           `json:"customerId,omitempty"`
+	ManualDiscount *ManualDiscount  `json:"manualDiscount,omitempty"`
+}
+
+func (r *SubTotalRequest) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		return json.Unmarshal(trimmed, &r.OrderedProduct)
+	}
+	type request SubTotalRequest
+	return json.Unmarshal(data, (*request)(r))
 }
 
 type OrderedProduct struct {
-	ProductId int64 `json:"productId" validate:"required"`
-	Qty       int   `json:"qty" validate:"required"`
+	ProductId      int64           `json:"productId" validate:"required"`
+	Qty            float64         `json:"qty" validate:"required"`
+	ManualDiscount *ManualDiscount `json:"manualDiscount,omitempty"`
 }
 
 type SubTotalOrder struct {
-	Subtotal       int                       `json:"subtotal"`
-	OrderedProduct []SubOrderedProductDetail `json:"products"`
+	Subtotal         Money                     `json:"subtotal"`
+	Discount         Money                     `json:"discount"`
+	ManualDiscount   Money                     `json:"manualDiscount"`
+	Tax              Money                     `json:"tax"`
+	TaxInclusive     bool                      `json:"taxInclusive"`
+	Total            Money                     `json:"total"`
+	ServiceCharge    Money                     `json:"serviceCharge"`
+	Tip              Money                     `json:"tip"`
+	Rounding         Money                     `json:"rounding"`
+	AmountDue        Money                     `json:"amountDue"`
+	Tender           *Tender                   `json:"tender,omitempty"`
+	OrderedProduct   []SubOrderedProductDetail `json:"products"`
+	Promotions       []AppliedPromotion        `json:"promotions"`
+	Coupons          []string                  `json:"coupons"`
+	Discounts        []OrderDiscount           `json:"manualDiscounts"`
+	Taxes            []OrderTax                `json:"taxes"`
+	ApprovalRequired bool                      `json:"approvalRequired"`
 }
@@ -3,12 +3,25 @@ package model
 import "time"
 
 type Payment struct {
-	PaymentId int64      `json:"paymentId"`
-	Name      string     `json:"name" validate:"required"`
-	Type      string     `json:"type" validate:"required"`
-	Logo      string     `json:"logo"`
-	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
-	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	PaymentId     int64    `json:"paymentId"`
+	Name          string   `json:"name" validate:"required"`
+	Type          string   `json:"type" validate:"required"`
+	Logo          string   `json:"logo"`
+	LogoThumbnail string   `json:"logoThumbnail,omitempty"`
+	RoundingUnit  Money    `json:"roundingUnit"`
+	RoundingMode  Rounding `json:"roundingMode,omitempty"`
+	// Provider names the payment provider that takes this tender, if any.
+	Provider   string     `json:"provider,omitempty"`
+	UpdatedAt  *time.Time `json:"updatedAt,omitempty"`
+	CreatedAt  *time.Time `json:"createdAt,omitempty"`
+	ArchivedAt *time.Time `json:"archivedAt,omitempty"`
+}
+
+// Round rounds an amount due with this payment type to its rounding unit,
+// for tenders such as cash where small coins are not in circulation. A
+// unit of zero or one leaves the amount exact.
+func (p Payment) Round(amount Money) Money {
+	return amount.RoundTo(p.RoundingUnit, p.RoundingMode)
 }
 
 type ListPayment struct {
@@ -16,6 +29,19 @@ type ListPayment struct {
 	Meta     Meta      `json:"meta"`
 }
 
+type Upload struct {
+	Url          string `json:"url"`
+	ThumbnailUrl string `json:"thumbnailUrl"`
+	ContentType  string `json:"contentType"`
+	Size         int    `json:"size"`
+}
+
+var UploadContentType = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
 var PaymentType = map[string]bool{
 	"CASH":     true,
 	"E-WALLET": true,
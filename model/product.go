@@ -4,8 +4,10 @@ import "time"
 
 type ProductCreateRequest struct {
 	Name       string    `json:"name" validate:"required"`
-	Stock      int       `json:"stock,omitempty" validate:"required"`
-	Price      int       `json:"price" validate:"required"`
+	Stock      float64   `json:"stock,omitempty" validate:"required"`
+	Price      Money     `json:"price" validate:"required"`
+	Unit       string    `json:"unit"`
+	Barcode    string    `json:"barcode,omitempty"`
 	Image      string    `json:"image,omitempty"`
 	CategoryId *int64    `json:"categoryId"`
 	Discount   *Discount `json:"discount"`
@@ -14,14 +16,20 @@ type ProductCreateRequest struct {
 type Product struct {
 	ProductId  int64      `json:"productId"`
 	Name       string     `json:"name" validate:"required"`
-	Stock      int        `json:"stock,omitempty" validate:"required"`
-	Price      int        `json:"price" validate:"required"`
+	Stock      float64    `json:"stock,omitempty" validate:"required"`
+	Price      Money      `json:"price" validate:"required"`
+	CostPrice  Money      `json:"costPrice,omitempty"`
+	Unit       string     `json:"unit,omitempty"`
+	Barcode    string     `json:"barcode,omitempty"`
 	Image      string     `json:"image,omitempty"`
+	Thumbnail  string     `json:"thumbnail,omitempty"`
 	SKU        string     `json:"sku,omitempty"`
 	UpdatedAt  *time.Time `json:"updatedAt,omitempty"`
 	CreatedAt  *time.Time `json:"createdAt,omitempty"`
+	ArchivedAt *time.Time `json:"archivedAt,omitempty"`
 	DiscountId *int64     `json:"discountId,omitempty"`
 	CategoryId *int64     `json:"categoryId,omitempty"`
+	TaxClassId *int64     `json:"taxClassId,omitempty"`
 	Discount   *Discount  `json:"discount"`
 	Category   *Category  `json:"category,omitempty"`
 }
@@ -29,8 +37,10 @@ type Product struct {
 type ProductCreateResponse struct {
 	ProductId  int64      `json:"productId"`
 	Name       string     `json:"name" validate:"required"`
-	Stock      int        `json:"stock,omitempty" validate:"required"`
-	Price      int        `json:"price" validate:"required"`
+	Stock      float64    `json:"stock,omitempty" validate:"required"`
+	Price      Money      `json:"price" validate:"required"`
+	Unit       string     `json:"unit,omitempty"`
+	Barcode    string     `json:"barcode,omitempty"`
 	Image      string     `json:"image,omitempty"`
 	SKU        string     `json:"sku,omitempty"`
 	UpdatedAt  *time.Time `json:"updatedAt,omitempty"`
@@ -38,25 +48,82 @@ type ProductCreateResponse struct {
 	CategoryId *int64     `json:"categoryId"`
 }
 
-type Discount struct {
-	DiscountID      int64       `json:"discountId,omitempty"`
-	Qty             int         `json:"qty" validate:"required"`
-	Type            string      `json:"type" validate:"required"`
-	Result          int         `json:"result"`
-	ExpiratedAt     interface{} `json:"expiredAt,omitempty"`
-	ExpiredAtFormat string      `json:"expiratedAtFormat"`
-	StringFormat    string      `json:"stringFormat"`
+// Stock movements booked for an order besides its sale: its products put
+// back on stock after its payment failed or it was voided or refunded, and
+// taken off again when a payment for it arrived after it failed.
+const (
+	StockReturned   = "RETURNED"
+	StockReinstated = "REINSTATED"
+)
+
+type GoodsReceipt struct {
+	GoodsReceiptId int64      `json:"goodsReceiptId"`
+	ProductId      int64      `json:"productId"`
+	Qty            float64    `json:"qty" validate:"required,gt=0"`
+	UnitCost       Money      `json:"unitCost" validate:"required,gt=0"`
+	Stock          float64    `json:"stock"`
+	CostPrice      Money      `json:"costPrice"`
+	CreatedAt      *time.Time `json:"createdAt,omitempty"`
+}
+
+type ProductImport struct {
+	Row          int
+	Product      Product
+	CategoryName string
+	// UnitCost is what each unit of stock added by the import cost.
+	UnitCost Money
+	// Columns holds the columns the file has; an existing product keeps
+	// what it has stored for the others.
+	Columns map[string]bool
+}
+
+type ProductImportResult struct {
+	DryRun  bool                 `json:"dryRun"`
+	Total   int                  `json:"total"`
+	Created int                  `json:"created"`
+	Updated int                  `json:"updated"`
+	Errors  []ProductImportError `json:"errors"`
 }
 
+type ProductImportError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column"`
+	Message string `json:"message"`
+}
+
+// ProductExportColumns heads an exported file. The same file imports
+// again: cost_price is only informative there, as stock an import adds is
+// costed from a unit_cost column instead.
+var ProductExportColumns = []string{
+	"sku",
+	"name",
+	"category",
+	"price",
+	"stock",
+	"cost_price",
+	"unit",
+	"barcode",
+	"discount_type",
+	"discount_qty",
+	"discount_amount",
+	"discount_percent",
+}
+
+const (
+	ImportFormatCSV  = "csv"
+	ImportFormatXLSX = "xlsx"
+)
+
 type ListProduct struct {
 	Products []Product `json:"products"`
 	Meta     Meta      `json:"meta"`
 }
 
-var DiscountType = map[string]bool{
-	"PERCENT": true,
-	"BUY_N":   true,
+var UnitType = map[string]bool{
+	"pcs": true,
+	"kg":  true,
+	"g":   true,
+	"l":   true,
 }
 
-const Percent = "PERCENT"
-const BuyN = "BUY_N"
+const UnitPcs = "pcs"
@@ -0,0 +1,212 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Promotion is a basket rule evaluated when an order is priced. Which fields
+// are used depends on the type:
+//
+//	FIXED_AMOUNT     Amount off every unit of ProductId or CategoryId, or
+//	                 off the basket when neither is set
+//	BUY_X_GET_Y      every BuyQty units of ProductId give GetQty units of
+//	                 GetProductId (ProductId when empty) Percent off, free
+//	                 when Percent is 0
+//	MIX_MATCH        any BuyQty units from CategoryId for a total of Amount
+//	SPEND_THRESHOLD  Amount or Percent, as ValueType says, off the basket
+//	                 once it reaches MinSpend
+//	TIERED           ProductId is sold at the unit price of the highest tier
+//	                 whose MinQty is reached
+//
+// A CouponOnly promotion is applied only through a coupon code. Windows
+// optionally restrict the promotion to recurring periods of the store's
+// local time; it applies when any of them is open.
+type Promotion struct {
+	PromotionId  int64             `json:"promotionId"`
+	Name         string            `json:"name" validate:"required"`
+	Type         string            `json:"type" validate:"required"`
+	Priority     int               `json:"priority"`
+	Stackable    bool              `json:"stackable"`
+	Exclusive    bool              `json:"exclusive"`
+	CouponOnly   bool              `json:"couponOnly"`
+	ProductId    *int64            `json:"productId,omitempty"`
+	CategoryId   *int64            `json:"categoryId,omitempty"`
+	GetProductId *int64            `json:"getProductId,omitempty"`
+	BuyQty       int               `json:"buyQty,omitempty"`
+	GetQty       int               `json:"getQty,omitempty"`
+	MinSpend     Money             `json:"minSpend,omitempty"`
+	ValueType    string            `json:"valueType,omitempty"`
+	Amount       Money             `json:"amount,omitempty"`
+	Percent      int               `json:"percent,omitempty"`
+	Tiers        []PromotionTier   `json:"tiers,omitempty"`
+	Windows      []PromotionWindow `json:"windows,omitempty"`
+	StartedAt    *Timestamp        `json:"startedAt,omitempty"`
+	ExpiredAt    *Timestamp        `json:"expiredAt,omitempty"`
+	UpdatedAt    *time.Time        `json:"updatedAt,omitempty"`
+	CreatedAt    *time.Time        `json:"createdAt,omitempty"`
+	ArchivedAt   *time.Time        `json:"archivedAt,omitempty"`
+}
+
+// UnmarshalJSON also accepts value, the field that held the percent or the
+// amount before the two were split, from older clients. TIERED promotions
+// never used it.
+func (p *Promotion) UnmarshalJSON(data []byte) error {
+	type promotion Promotion
+	body := struct {
+		*promotion
+		Value json.RawMessage `json:"value"`
+	}{promotion: (*promotion)(p)}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return err
+	}
+	if p.Type == PromotionTiered {
+		return nil
+	}
+	isPercent := p.Type == PromotionBuyXGetY ||
+		(p.Type == PromotionSpendThreshold && p.ValueType == ValuePercent)
+	return legacyValue(body.Value, isPercent, &p.Percent, &p.Amount)
+}
+
+type PromotionTier struct {
+	MinQty float64 `json:"minQty"`
+	Price  Money   `json:"price"`
+}
+
+// PromotionWindow is open on Days (every day when empty) from StartTime to
+// EndTime, both "15:04". A window whose EndTime is not after its StartTime
+// runs past midnight into the next day. Empty times span the whole day.
+type PromotionWindow struct {
+	Days      []string `json:"days,omitempty"`
+	StartTime string   `json:"startTime,omitempty"`
+	EndTime   string   `json:"endTime,omitempty"`
+}
+
+// OpenAt reports whether the window is open at t, read in t's location.
+func (w PromotionWindow) OpenAt(t time.Time) bool {
+	start, end := 0, minutesPerDay
+	if w.StartTime != "" {
+		start, _ = clockMinutes(w.StartTime)
+	}
+	if w.EndTime != "" {
+		end, _ = clockMinutes(w.EndTime)
+	}
+	minute := t.Hour()*60 + t.Minute()
+	if start < end {
+		return w.onDay(t.Weekday()) && minute >= start && minute < end
+	}
+	if minute >= start {
+		return w.onDay(t.Weekday())
+	}
+	return minute < end && w.onDay((t.Weekday()+6)%7)
+}
+
+func (w PromotionWindow) onDay(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, name := range w.Days {
+		if weekday, ok := Weekday[name]; ok && weekday == day {
+			return true
+		}
+	}
+	return false
+}
+
+// Valid reports whether the days and times of the window can be read.
+func (w PromotionWindow) Valid() bool {
+	for _, name := range w.Days {
+		if _, ok := Weekday[name]; !ok {
+			return false
+		}
+	}
+	if w.StartTime != "" {
+		if _, err := clockMinutes(w.StartTime); err != nil {
+			return false
+		}
+	}
+	if w.EndTime != "" {
+		if _, err := clockMinutes(w.EndTime); err != nil {
+			return false
+		}
+	}
+	return w.StartTime == "" || w.StartTime != w.EndTime
+}
+
+const minutesPerDay = 24 * 60
+
+func clockMinutes(clock string) (int, error) {
+	parsed, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, err
+	}
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
+
+var Weekday = map[string]time.Weekday{
+	"SUN": time.Sunday,
+	"MON": time.Monday,
+	"TUE": time.Tuesday,
+	"WED": time.Wednesday,
+	"THU": time.Thursday,
+	"FRI": time.Friday,
+	"SAT": time.Saturday,
+}
+
+// ActiveAt reports whether the promotion applies at t. Windows are read in
+// t's location, so t must be in the store's local time.
+func (p Promotion) ActiveAt(t time.Time) bool {
+	if p.ArchivedAt != nil {
+		return false
+	}
+	if p.StartedAt != nil && t.Before(p.StartedAt.Time) {
+		return false
+	}
+	if p.ExpiredAt != nil && !t.Before(p.ExpiredAt.Time) {
+		return false
+	}
+	if len(p.Windows) == 0 {
+		return true
+	}
+	for _, window := range p.Windows {
+		if window.OpenAt(t) {
+			return true
+		}
+	}
+	return false
+}
+
+type ListPromotion struct {
+	Promotions []Promotion `json:"promotions"`
+	Meta       Meta        `json:"meta"`
+}
+
+// AppliedPromotion is one itemised promotion on an order. Promotions that
+// discount products carry the product, basket promotions do not.
+type AppliedPromotion struct {
+	PromotionId int64  `json:"promotionId"`
+	Name        string `json:"name"`
+	ProductId   *int64 `json:"productId,omitempty"`
+	Amount      Money  `json:"amount"`
+}
+
+var PromotionType = map[string]bool{
+	"FIXED_AMOUNT":    true,
+	"BUY_X_GET_Y":     true,
+	"MIX_MATCH":       true,
+	"SPEND_THRESHOLD": true,
+	"TIERED":          true,
+}
+
+const (
+	PromotionFixedAmount    = "FIXED_AMOUNT"
+	PromotionBuyXGetY       = "BUY_X_GET_Y"
+	PromotionMixMatch       = "MIX_MATCH"
+	PromotionSpendThreshold = "SPEND_THRESHOLD"
+	PromotionTiered         = "TIERED"
+)
+
+const (
+	ValueAmount  = "AMOUNT"
+	ValuePercent = "PERCENT"
+)
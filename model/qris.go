@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+// States of a QRIS code.
+const (
+	QRISPending   = "PENDING"
+	QRISPaid      = "PAID"
+	QRISFailed    = "FAILED"
+	QRISExpired   = "EXPIRED"
+	QRISCancelled = "CANCELLED"
+)
+
+// QRISCode is a dynamic QRIS payload asking for the amount due on an
+// order, shown on the customer display until it is paid or expires.
+type QRISCode struct {
+	QRISCodeId     int64      `json:"qrisCodeId"`
+	Reference      string     `json:"reference"`
+	OrderReference string     `json:"receiptId"`
+	Amount         Money      `json:"amount"`
+	Payload        string     `json:"payload"`
+	Status         string     `json:"status"`
+	ExpiresAt      *time.Time `json:"expiresAt"`
+	PaidAt         *time.Time `json:"paidAt,omitempty"`
+	CreatedAt      *time.Time `json:"createdAt,omitempty"`
+}
+
+// Expired tells whether a pending code can no longer be paid at t.
+func (c QRISCode) Expired(t time.Time) bool {
+	return c.Status == QRISPending && c.ExpiresAt != nil && !t.Before(*c.ExpiresAt)
+}
+
+// QRISCallback is the acquirer's notice that the code for a bill was paid
+// or failed.
+type QRISCallback struct {
+	BillNumber string `json:"billNumber" validate:"required"`
+	Amount     Money  `json:"amount" validate:"required"`
+	Status     string `json:"status" validate:"required"`
+}
+
+const (
+	QRISFormatJSON = "json"
+	QRISFormatPNG  = "png"
+	QRISFormatSVG  = "svg"
+)
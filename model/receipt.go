@@ -0,0 +1,48 @@
+package model
+
+import "time"
+
+// Channels a receipt can be sent over.
+const (
+	ReceiptEmail = "EMAIL"
+	ReceiptSMS   = "SMS"
+)
+
+// Receipt outbox states. A message waits as pending until it is sent,
+// and fails once it has been tried as often as the store allows.
+const (
+	ReceiptPending = "PENDING"
+	ReceiptSent    = "SENT"
+	ReceiptFailed  = "FAILED"
+)
+
+// ReceiptRequest asks for an order's receipt to be sent to an email
+// address, a phone, or both. When neither is given the receipt goes to
+// the order's customer.
+type ReceiptRequest struct {
+	Email string `json:"email,omitempty" validate:"omitempty,email,max=255"`
+	Phone string `json:"phone,omitempty" validate:"max=32"`
+}
+
+// ReceiptMessage is a receipt in the outbox. Body is laid out when the
+// message is queued, so every attempt sends the same receipt.
+type ReceiptMessage struct {
+	ReceiptMessageId int64      `json:"receiptMessageId"`
+	OrderId          int64      `json:"orderId"`
+	Channel          string     `json:"channel"`
+	Recipient        string     `json:"recipient"`
+	Subject          string     `json:"subject,omitempty"`
+	Body             string     `json:"-"`
+	Status           string     `json:"status"`
+	Attempts         int        `json:"attempts"`
+	LastError        string     `json:"lastError,omitempty"`
+	NextAttemptAt    *time.Time `json:"nextAttemptAt,omitempty"`
+	SentAt           *time.Time `json:"sentAt,omitempty"`
+	UpdatedAt        *time.Time `json:"updatedAt,omitempty"`
+	CreatedAt        *time.Time `json:"createdAt,omitempty"`
+}
+
+type ListReceiptMessage struct {
+	ReceiptMessages []ReceiptMessage `json:"receiptMessages"`
+	Meta            Meta             `json:"meta"`
+}
@@ -1,13 +1,15 @@
 package model
 
 type Revenue struct {
-	TotalRevenue int               `json:"totalRevenue"`
-	PaymentType  []PaymentTypeItem `json:"paymentTypes"`
+	TotalRevenue  Money             `json:"totalRevenue"`
+	TotalRounding Money             `json:"totalRounding"`
+	PaymentType   []PaymentTypeItem `json:"paymentTypes"`
 }
 
 type PaymentTypeItem struct {
 	Payment
-	TotalAmount int `json:"totalAmount"`
+	TotalAmount   Money `json:"totalAmount"`
+	TotalRounding Money `json:"totalRounding"`
 }
 
 type Solds struct {
@@ -15,8 +17,45 @@ type Solds struct {
 }
 
 type SoldProduct struct {
-	ProductId   int64  `json:"productId"`
-	Name        string `json:"name"`
-	TotalQty    int    `json:"totalQty"`
-	TotalAmount int    `json:"totalAmount"`
+	ProductId   int64   `json:"productId"`
+	Name        string  `json:"name"`
+	TotalQty    float64 `json:"totalQty"`
+	TotalAmount Money   `json:"totalAmount"`
 }
+
+type Margins struct {
+	TotalRevenue  Money        `json:"totalRevenue"`
+	TotalCost     Money        `json:"totalCost"`
+	TotalMargin   Money        `json:"totalMargin"`
+	MarginPercent float64      `json:"marginPercent"`
+	Items         []MarginItem `json:"items"`
+}
+
+type MarginItem struct {
+	Id            int64   `json:"id"`
+	Name          string  `json:"name"`
+	TotalQty      float64 `json:"totalQty"`
+	TotalRevenue  Money   `json:"totalRevenue"`
+	TotalCost     Money   `json:"totalCost"`
+	TotalMargin   Money   `json:"totalMargin"`
+	MarginPercent float64 `json:"marginPercent"`
+}
+
+type InventoryValuation struct {
+	TotalValue Money              `json:"totalValue"`
+	Products   []InventoryProduct `json:"products"`
+}
+
+type InventoryProduct struct {
+	ProductId int64   `json:"productId"`
+	Name      string  `json:"name"`
+	Unit      string  `json:"unit"`
+	Stock     float64 `json:"stock"`
+	CostPrice Money   `json:"costPrice"`
+	Value     Money   `json:"value"`
+}
+
+const (
+	MarginByProduct  = "product"
+	MarginByCategory = "category"
+)
@@ -0,0 +1,67 @@
+package model
+
+import "time"
+
+// Sales report types. An X report is a snapshot of a shift that can be
+// taken any number of times; a Z report closes the day, covering every
+// order since the previous one, and is numbered and kept as generated.
+const (
+	ReportX = "X"
+	ReportZ = "Z"
+)
+
+// Formats a sales report is available in.
+const (
+	ReportFormatJSON = "json"
+	ReportFormatText = "text"
+	ReportFormatPDF  = "pdf"
+)
+
+// ReportCount is a number of orders and what they came to.
+type ReportCount struct {
+	TotalOrder int   `json:"totalOrder"`
+	Amount     Money `json:"amount"`
+}
+
+// ReportTender is what was collected with one payment type.
+type ReportTender struct {
+	PaymentId  int64  `json:"paymentId"`
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	TotalOrder int    `json:"totalOrder"`
+	Amount     Money  `json:"amount"`
+}
+
+// SalesReport totals the orders taken, including those refunded later.
+// GrossSales is the products at their list price and Discounts everything
+// taken off them, so NetSales excludes tax, service charge and tips, which
+// are shown on their own. Refunds are orders refunded in the period and
+// Voids orders whose payment was voided before it settled. CashOverShort
+// is what the counts of the closed shifts came to against what the drawer
+// should have held.
+type SalesReport struct {
+	Type           string         `json:"type"`
+	Number         int64          `json:"number,omitempty"`
+	ShiftId        *int64         `json:"shiftId,omitempty"`
+	From           *time.Time     `json:"from,omitempty"`
+	To             *time.Time     `json:"to,omitempty"`
+	TotalOrder     int            `json:"totalOrder"`
+	GrossSales     Money          `json:"grossSales"`
+	Discounts      Money          `json:"discounts"`
+	NetSales       Money          `json:"netSales"`
+	Tax            Money          `json:"tax"`
+	ServiceCharge  Money          `json:"serviceCharge"`
+	Tips           Money          `json:"tips"`
+	Rounding       Money          `json:"rounding"`
+	TotalCollected Money          `json:"totalCollected"`
+	Refunds        ReportCount    `json:"refunds"`
+	Voids          ReportCount    `json:"voids"`
+	Tenders        []ReportTender `json:"tenders"`
+	CashOverShort  Money          `json:"cashOverShort"`
+	GeneratedAt    *time.Time     `json:"generatedAt,omitempty"`
+}
+
+type ListZReport struct {
+	ZReports []SalesReport `json:"zReports"`
+	Meta     Meta          `json:"meta"`
+}
@@ -0,0 +1,97 @@
+package model
+
+import "time"
+
+// Shift states. The store has one cash drawer, so at most one shift is open
+// at a time and every order its cashier places meanwhile belongs to it.
+const (
+	ShiftOpen   = "OPEN"
+	ShiftClosed = "CLOSED"
+)
+
+// Cash movements recorded during a shift besides sales: cash put into the
+// drawer, cash paid out of it, and cash dropped from it into the safe.
+const (
+	MovementPayIn  = "PAY_IN"
+	MovementPayOut = "PAY_OUT"
+	MovementDrop   = "DROP"
+)
+
+var MovementType = map[string]bool{
+	MovementPayIn:  true,
+	MovementPayOut: true,
+	MovementDrop:   true,
+}
+
+type Shift struct {
+	ShiftId        int64      `json:"shiftId"`
+	CashierId      int64      `json:"cashierId"`
+	OpeningFloat   Money      `json:"openingFloat"`
+	Status         string     `json:"status"`
+	ClosedBy       *int64     `json:"closedBy,omitempty"`
+	ExpectedCash   Money      `json:"expectedCash"`
+	CountedCash    Money      `json:"countedCash"`
+	CashDifference Money      `json:"cashDifference"`
+	OpenedAt       *time.Time `json:"openedAt,omitempty"`
+	ClosedAt       *time.Time `json:"closedAt,omitempty"`
+}
+
+type OpenShiftRequest struct {
+	CashierId    int64 `json:"cashierId" validate:"required"`
+	OpeningFloat Money `json:"openingFloat" validate:"min=0"`
+}
+
+type ShiftMovement struct {
+	MovementId int64      `json:"movementId"`
+	ShiftId    int64      `json:"shiftId"`
+	CashierId  int64      `json:"cashierId" validate:"required"`
+	Type       string     `json:"type" validate:"required"`
+	Amount     Money      `json:"amount" validate:"required,gt=0"`
+	Reason     string     `json:"reason" validate:"max=255"`
+	CreatedAt  *time.Time `json:"createdAt,omitempty"`
+}
+
+// ShiftCount is what the cashier counted for one payment type at close.
+type ShiftCount struct {
+	PaymentId int64 `json:"paymentId" validate:"required"`
+	Counted   Money `json:"counted" validate:"min=0"`
+}
+
+// CloseShiftRequest is a blind count: the cashier does not see what the
+// drawer is expected to hold until the shift is closed. Payment types left
+// out count as zero.
+type CloseShiftRequest struct {
+	CashierId int64        `json:"cashierId" validate:"required"`
+	Counts    []ShiftCount `json:"counts" validate:"dive"`
+}
+
+// ShiftTender compares, for one payment type, what the shift should hold
+// with what was counted at close. For cash, Expected is the opening float
+// plus cash sales and pay-ins less pay-outs and drops; for any other tender
+// it is the sales taken with it. Expected and Counted are in the store
+// currency: cash taken in another currency is left out of them and listed
+// under Foreign in that currency's own units.
+type ShiftTender struct {
+	PaymentId  int64         `json:"paymentId"`
+	Name       string        `json:"name"`
+	Type       string        `json:"type"`
+	TotalOrder int           `json:"totalOrder"`
+	Sales      Money         `json:"sales"`
+	Expected   Money         `json:"expected"`
+	Counted    Money         `json:"counted"`
+	Difference Money         `json:"difference"`
+	Foreign    []TenderTotal `json:"foreign,omitempty"`
+}
+
+// ShiftDetail shows a shift with its movements. Tenders are only filled in
+// once the shift is closed, so the count stays blind.
+type ShiftDetail struct {
+	Shift     Shift           `json:"shift"`
+	Movements []ShiftMovement `json:"movements"`
+	Tenders   []ShiftTender   `json:"tenders,omitempty"`
+}
+
+type ListShift struct {
+	Shifts []Shift `json:"shifts"`
+	Meta   Meta    `json:"meta"`
+}
@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+// TaxClass is a tax rate products and categories can be assigned to, e.g.
+// PPN at 11 percent or an exempt class at 0. A product without a class of
+// its own is taxed by its category's class, and not at all without either.
+type TaxClass struct {
+	TaxClassId int64      `json:"taxClassId"`
+	Name       string     `json:"name" validate:"required"`
+	Rate       float64    `json:"rate" validate:"gte=0,lte=100"`
+	UpdatedAt  *time.Time `json:"updatedAt,omitempty"`
+	CreatedAt  *time.Time `json:"createdAt,omitempty"`
+	ArchivedAt *time.Time `json:"archivedAt,omitempty"`
+}
+
+type ListTaxClass struct {
+	TaxClasses []TaxClass `json:"taxClasses"`
+	Meta       Meta       `json:"meta"`
+}
+
+type TaxClassRequest struct {
+	TaxClassId *int64 `json:"taxClassId"`
+}
+
+// OrderTax is the tax of an order, or of a period, for one class and rate.
+type OrderTax struct {
+	TaxClassId    *int64  `json:"taxClassId,omitempty"`
+	Name          string  `json:"name"`
+	Rate          float64 `json:"rate"`
+	TaxableAmount Money   `json:"taxableAmount"`
+	TaxAmount     Money   `json:"taxAmount"`
+}
+
+type TaxSummary struct {
+	From         *time.Time `json:"from,omitempty"`
+	To           *time.Time `json:"to,omitempty"`
+	TotalTaxable Money      `json:"totalTaxable"`
+	TotalTax     Money      `json:"totalTax"`
+	Taxes        []OrderTax `json:"taxes"`
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// CashierTips is the service charge and tips taken by one cashier; orders
+// placed without a cashier are grouped under a nil CashierId.
+type CashierTips struct {
+	CashierId     *int64 `json:"cashierId"`
+	Name          string `json:"name"`
+	TotalOrder    int    `json:"totalOrder"`
+	ServiceCharge Money  `json:"serviceCharge"`
+	Tip           Money  `json:"tip"`
+}
+
+type TipReport struct {
+	From               *time.Time    `json:"from,omitempty"`
+	To                 *time.Time    `json:"to,omitempty"`
+	TotalServiceCharge Money         `json:"totalServiceCharge"`
+	TotalTip           Money         `json:"totalTip"`
+	Cashiers           []CashierTips `json:"cashiers"`
+}
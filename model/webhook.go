@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+const (
+	PaymentEventPaid   = "PAID"
+	PaymentEventFailed = "FAILED"
+)
+
+// PaymentEvent is the body of a payment provider's webhook: the payment
+// for the order with the receipt ID was made or failed. EventId is unique
+// per provider, so redelivered events are recognised.
+type PaymentEvent struct {
+	EventId        string `json:"eventId" validate:"required,max=128"`
+	OrderReference string `json:"receiptId" validate:"required"`
+	Amount         Money  `json:"amount" validate:"required"`
+	Status         string `json:"status" validate:"required"`
+}
+
+// PaymentEventRecord is a webhook as received, kept with its raw payload
+// for disputes. Result is the order state it left, or why it was not
+// applied.
+type PaymentEventRecord struct {
+	PaymentEventId int64      `json:"paymentEventId"`
+	Provider       string     `json:"provider"`
+	EventId        string     `json:"eventId"`
+	OrderReference string     `json:"receiptId"`
+	Status         string     `json:"status"`
+	Payload        string     `json:"payload"`
+	Signature      string     `json:"signature"`
+	Result         string     `json:"result"`
+	Duplicate      bool       `json:"duplicate,omitempty"`
+	CreatedAt      *time.Time `json:"createdAt,omitempty"`
+}
+
+type ListPaymentEvent struct {
+	PaymentEvents []PaymentEventRecord `json:"paymentEvents"`
+	Meta          Meta                 `json:"meta"`
+}
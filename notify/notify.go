@@ -0,0 +1,43 @@
+// Package notify delivers messages to customers: by email through an SMTP
+// server, and by text message through an SMS gateway.
+package notify
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotConfigured is returned when a message is sent over a channel the
+// store has no sender for.
+var ErrNotConfigured = errors.New("notify: channel not configured")
+
+// Message is addressed to an email address or a phone number. Text
+// messages have no subject.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers messages over one channel. Send returns once the message
+// is handed over, or with an error when it should be tried again later.
+type Sender interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// Notifier holds the senders of the channels the store can reach
+// customers on, by channel.
+type Notifier map[string]Sender
+
+func (n Notifier) Has(channel string) bool {
+	_, ok := n[channel]
+	return ok
+}
+
+func (n Notifier) Send(ctx context.Context, channel string, message Message) error {
+	sender, ok := n[channel]
+	if !ok {
+		return ErrNotConfigured
+	}
+	return sender.Send(ctx, message)
+}
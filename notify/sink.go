@@ -0,0 +1,119 @@
+package notify
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+// Sink is a stand-in SMTP server, for development without a mail server.
+// It takes every message and writes it to Out, or turns each one away with
+// a temporary failure when Reject is set, so retries can be watched.
+type Sink struct {
+	Out    io.Writer
+	Reject bool
+
+	mu sync.Mutex
+}
+
+func NewSink(out io.Writer, reject bool) *Sink {
+	return &Sink{Out: out, Reject: reject}
+}
+
+// Serve answers the connections on listener until it is closed.
+func (s *Sink) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *Sink) handle(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	reply := func(line string) bool {
+		return text.PrintfLine("%s", line) == nil
+	}
+	if !reply("220 pos-smtp-sink ESMTP") {
+		return
+	}
+	var from string
+	var to []string
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch {
+		case verb == "EHLO" || verb == "HELO":
+			from, to = "", nil
+			reply("250 pos-smtp-sink")
+		case verb == "MAIL":
+			from, to = argument(line), nil
+			reply("250 OK")
+		case verb == "RCPT":
+			to = append(to, argument(line))
+			reply("250 OK")
+		case verb == "DATA" && (from == "" || len(to) == 0):
+			reply("503 MAIL and RCPT first")
+		case verb == "DATA":
+			if !reply("354 End data with <CR><LF>.<CR><LF>") {
+				return
+			}
+			data, err := io.ReadAll(text.DotReader())
+			if err != nil {
+				return
+			}
+			if s.Reject {
+				log.Printf("smtp sink: rejected mail from %s to %s", from, strings.Join(to, ", "))
+				reply("451 Try again later")
+			} else {
+				s.write(from, to, data)
+				reply("250 OK")
+			}
+			from, to = "", nil
+		case verb == "RSET":
+			from, to = "", nil
+			reply("250 OK")
+		case verb == "NOOP":
+			reply("250 OK")
+		case verb == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func (s *Sink) write(from string, to []string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := bufio.NewWriter(s.Out)
+	fmt.Fprintf(out, "==== mail from %s to %s\n", from, strings.Join(to, ", "))
+	out.Write(data)
+	fmt.Fprintln(out, "====")
+	out.Flush()
+}
+
+// argument takes the address out of a MAIL FROM:<a> or RCPT TO:<a> line.
+func argument(line string) string {
+	address := line
+	if colon := strings.Index(line, ":"); colon >= 0 {
+		address = line[colon+1:]
+	}
+	address = strings.TrimSpace(address)
+	if end := strings.Index(address, ">"); strings.HasPrefix(address, "<") && end > 0 {
+		address = address[1:end]
+	}
+	return address
+}
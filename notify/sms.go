@@ -0,0 +1,69 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// SMS gateways a store can be set up with.
+const (
+	SMSGatewayLog  = "log"
+	SMSGatewayHTTP = "http"
+)
+
+// LogSMS writes text messages to the log instead of sending them, for
+// running without an SMS gateway.
+type LogSMS struct{}
+
+func (LogSMS) Send(ctx context.Context, message Message) error {
+	log.Printf("sms to %s: %s", message.To, message.Body)
+	return nil
+}
+
+// HTTPSMS hands text messages to an SMS gateway's HTTP API, posting them
+// as JSON with the token as a bearer token. Any 2xx answer means the
+// gateway took the message.
+type HTTPSMS struct {
+	url    string
+	token  string
+	sender string
+	client *http.Client
+}
+
+func NewHTTPSMS(url, token, sender string) *HTTPSMS {
+	return &HTTPSMS{url: url, token: token, sender: sender, client: &http.Client{}}
+}
+
+type smsRequest struct {
+	From string `json:"from,omitempty"`
+	To   string `json:"to"`
+	Text string `json:"text"`
+}
+
+func (s *HTTPSMS) Send(ctx context.Context, message Message) error {
+	payload, err := json.Marshal(smsRequest{From: s.sender, To: message.To, Text: message.Body})
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		request.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	response, err := s.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("notify: sms gateway answered %s", response.Status)
+	}
+	return nil
+}
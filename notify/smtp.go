@@ -0,0 +1,102 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTP sends email through an SMTP server, upgrading the connection with
+// STARTTLS when the server offers it and signing in when a username is
+// set.
+type SMTP struct {
+	addr     string
+	from     string
+	username string
+	password string
+}
+
+func NewSMTP(addr, from, username, password string) *SMTP {
+	return &SMTP{addr: addr, from: from, username: username, password: password}
+}
+
+func (s *SMTP) Send(ctx context.Context, message Message) error {
+	if strings.ContainsAny(message.To+message.Subject, "\r\n") {
+		return fmt.Errorf("notify: invalid header in message to %q", message.To)
+	}
+	host, _, err := net.SplitHostPort(s.addr)
+	if err != nil {
+		return err
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		err = client.StartTLS(&tls.Config{ServerName: host})
+		if err != nil {
+			return err
+		}
+	}
+	if s.username != "" {
+		err = client.Auth(smtp.PlainAuth("", s.username, s.password, host))
+		if err != nil {
+			return err
+		}
+	}
+	err = client.Mail(s.from)
+	if err != nil {
+		return err
+	}
+	err = client.Rcpt(message.To)
+	if err != nil {
+		return err
+	}
+	data, err := client.Data()
+	if err != nil {
+		return err
+	}
+	_, err = data.Write(s.compose(message))
+	if err != nil {
+		return err
+	}
+	err = data.Close()
+	if err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// compose writes out a plain text email, its body quoted-printable so any
+// character set survives the trip.
+func (s *SMTP) compose(message Message) []byte {
+	var mail strings.Builder
+	fmt.Fprintf(&mail, "From: %s\r\n", s.from)
+	fmt.Fprintf(&mail, "To: %s\r\n", message.To)
+	fmt.Fprintf(&mail, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&mail, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	mail.WriteString("MIME-Version: 1.0\r\n")
+	mail.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	mail.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	body := quotedprintable.NewWriter(&mail)
+	body.Write([]byte(strings.ReplaceAll(message.Body, "\n", "\r\n")))
+	body.Close()
+	return []byte(mail.String())
+}
@@ -0,0 +1,333 @@
+package promotion
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/saptaka/pos/model"
+)
+
+// Line is a basket line as seen by the promotions engine. Amount is the
+// current price of the line and is lowered as promotions are applied.
+type Line struct {
+	ProductId  int64
+	CategoryId *int64
+	UnitPrice  model.Money
+	Qty        float64
+	Amount     model.Money
+	Discounted bool
+}
+
+type Result struct {
+	Lines          []Line
+	Applied        []model.AppliedPromotion
+	LineDiscount   model.Money
+	BasketDiscount model.Money
+}
+
+// Apply evaluates promotions against the basket in priority order, highest
+// first. A promotion that is not stackable only touches lines no other
+// promotion or product discount has touched, and lines it discounts are
+// closed to every later promotion. An exclusive promotion that applies ends
+// the evaluation.
+func Apply(promotions []model.Promotion, lines []Line, now time.Time) Result {
+	result := Result{Lines: make([]Line, len(lines))}
+	copy(result.Lines, lines)
+
+	sorted := make([]model.Promotion, len(promotions))
+	copy(sorted, promotions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Priority != sorted[j].Priority {
+			return sorted[i].Priority > sorted[j].Priority
+		}
+		return sorted[i].PromotionId < sorted[j].PromotionId
+	})
+
+	state := engine{
+		lines:   result.Lines,
+		touched: make([]bool, len(lines)),
+		claimed: make([]bool, len(lines)),
+	}
+	for index, line := range lines {
+		state.touched[index] = line.Discounted
+	}
+
+	for _, promotion := range sorted {
+		if !promotion.ActiveAt(now) {
+			continue
+		}
+
+		lineAmounts, basketAmount := state.evaluate(promotion)
+		if len(lineAmounts) == 0 && basketAmount == 0 {
+			continue
+		}
+
+		indexes := make([]int, 0, len(lineAmounts))
+		for index := range lineAmounts {
+			indexes = append(indexes, index)
+		}
+		sort.Ints(indexes)
+		for _, index := range indexes {
+			amount := lineAmounts[index]
+			productId := state.lines[index].ProductId
+			state.lines[index].Amount -= amount
+			state.touched[index] = true
+			if !promotion.Stackable {
+				state.claimed[index] = true
+			}
+			result.LineDiscount += amount
+			result.Applied = append(result.Applied, model.AppliedPromotion{
+				PromotionId: promotion.PromotionId,
+				Name:        promotion.Name,
+				ProductId:   &productId,
+				Amount:      amount,
+			})
+		}
+		if basketAmount > 0 {
+			state.basketDiscount += basketAmount
+			if !promotion.Stackable {
+				state.basketClaimed = true
+			}
+			result.BasketDiscount += basketAmount
+			result.Applied = append(result.Applied, model.AppliedPromotion{
+				PromotionId: promotion.PromotionId,
+				Name:        promotion.Name,
+				Amount:      basketAmount,
+			})
+		}
+		state.applied = true
+
+		if promotion.Exclusive {
+			break
+		}
+	}
+
+	return result
+}
+
+type engine struct {
+	lines          []Line
+	touched        []bool
+	claimed        []bool
+	applied        bool
+	basketClaimed  bool
+	basketDiscount model.Money
+}
+
+func (e *engine) eligible(index int, promotion model.Promotion) bool {
+	if e.claimed[index] || e.basketClaimed || e.lines[index].Amount <= 0 {
+		return false
+	}
+	return promotion.Stackable || !e.touched[index]
+}
+
+func (e *engine) matches(line Line, productId, categoryId *int64) bool {
+	if productId != nil {
+		return line.ProductId == *productId
+	}
+	if categoryId != nil {
+		return line.CategoryId != nil && *line.CategoryId == *categoryId
+	}
+	return false
+}
+
+func (e *engine) basketTotal() model.Money {
+	var total model.Money
+	for _, line := range e.lines {
+		total += line.Amount
+	}
+	return total - e.basketDiscount
+}
+
+func (e *engine) evaluate(promotion model.Promotion) (map[int]model.Money, model.Money) {
+	lineAmounts := make(map[int]model.Money)
+	switch promotion.Type {
+	case model.PromotionFixedAmount:
+		if promotion.ProductId == nil && promotion.CategoryId == nil {
+			return lineAmounts, e.basketAmount(promotion, promotion.Amount)
+		}
+		for index, line := range e.lines {
+			if !e.eligible(index, promotion) ||
+				!e.matches(line, promotion.ProductId, promotion.CategoryId) {
+				continue
+			}
+			lineAmounts[index] = capAmount(promotion.Amount.Mul(line.Qty), line.Amount)
+		}
+
+	case model.PromotionBuyXGetY:
+		e.buyXGetY(promotion, lineAmounts)
+
+	case model.PromotionMixMatch:
+		e.mixMatch(promotion, lineAmounts)
+
+	case model.PromotionSpendThreshold:
+		total := e.basketTotal()
+		if total < promotion.MinSpend {
+			return lineAmounts, 0
+		}
+		amount := promotion.Amount
+		if promotion.ValueType == model.ValuePercent {
+			amount = total.Percent(float64(promotion.Percent))
+		}
+		return lineAmounts, e.basketAmount(promotion, amount)
+
+	case model.PromotionTiered:
+		for index, line := range e.lines {
+			if !e.eligible(index, promotion) || !e.matches(line, promotion.ProductId, nil) {
+				continue
+			}
+			tierPrice, ok := tierPrice(promotion.Tiers, line.Qty)
+			if !ok {
+				continue
+			}
+			amount := line.UnitPrice.Mul(line.Qty) - tierPrice.Mul(line.Qty)
+			if amount > 0 {
+				lineAmounts[index] = capAmount(amount, line.Amount)
+			}
+		}
+	}
+
+	for index, amount := range lineAmounts {
+		if amount <= 0 {
+			delete(lineAmounts, index)
+		}
+	}
+	return lineAmounts, 0
+}
+
+func (e *engine) basketAmount(promotion model.Promotion, amount model.Money) model.Money {
+	if e.basketClaimed || (!promotion.Stackable && e.applied) {
+		return 0
+	}
+	return capAmount(amount, e.basketTotal())
+}
+
+func (e *engine) buyXGetY(promotion model.Promotion, lineAmounts map[int]model.Money) {
+	if promotion.ProductId == nil || promotion.BuyQty < 1 || promotion.GetQty < 1 {
+		return
+	}
+	getProductId := promotion.ProductId
+	if promotion.GetProductId != nil {
+		getProductId = promotion.GetProductId
+	}
+	percent := promotion.Percent
+	if percent <= 0 || percent > 100 {
+		percent = 100
+	}
+
+	buyIndex, getIndex := -1, -1
+	for index, line := range e.lines {
+		if !e.eligible(index, promotion) {
+			continue
+		}
+		if line.ProductId == *promotion.ProductId {
+			buyIndex = index
+		}
+		if line.ProductId == *getProductId {
+			getIndex = index
+		}
+	}
+	if buyIndex < 0 || getIndex < 0 {
+		return
+	}
+
+	buyUnits := int(math.Floor(e.lines[buyIndex].Qty))
+	getUnits := int(math.Floor(e.lines[getIndex].Qty))
+	var freeUnits int
+	if buyIndex == getIndex {
+		freeUnits = buyUnits / (promotion.BuyQty + promotion.GetQty) * promotion.GetQty
+	} else {
+		freeUnits = buyUnits / promotion.BuyQty * promotion.GetQty
+		if freeUnits > getUnits {
+			freeUnits = getUnits
+		}
+	}
+	if freeUnits == 0 {
+		return
+	}
+
+	getLine := e.lines[getIndex]
+	amount := getLine.Amount.Scale(float64(freeUnits*percent), getLine.Qty*100)
+	lineAmounts[getIndex] = capAmount(amount, getLine.Amount)
+}
+
+// mixMatch groups any BuyQty whole units of the category, most expensive
+// first, and sells each group for Amount. The saving is spread over the
+// lines in proportion to what they contributed to the groups.
+func (e *engine) mixMatch(promotion model.Promotion, lineAmounts map[int]model.Money) {
+	if promotion.CategoryId == nil || promotion.BuyQty < 1 {
+		return
+	}
+	type unit struct {
+		index int
+		price float64
+	}
+	var units []unit
+	for index, line := range e.lines {
+		if !e.eligible(index, promotion) || !e.matches(line, nil, promotion.CategoryId) {
+			continue
+		}
+		unitPrice := float64(line.Amount) / line.Qty
+		for i := 0; i < int(math.Floor(line.Qty)); i++ {
+			units = append(units, unit{index, unitPrice})
+		}
+	}
+	groups := len(units) / promotion.BuyQty
+	if groups == 0 {
+		return
+	}
+	sort.SliceStable(units, func(i, j int) bool {
+		return units[i].price > units[j].price
+	})
+	units = units[:groups*promotion.BuyQty]
+
+	var groupedTotal float64
+	contributed := make(map[int]float64)
+	for _, unit := range units {
+		groupedTotal += unit.price
+		contributed[unit.index] += unit.price
+	}
+	saving := model.Money(math.Round(groupedTotal)) - promotion.Amount*model.Money(groups)
+	if saving <= 0 {
+		return
+	}
+
+	indexes := make([]int, 0, len(contributed))
+	for index := range contributed {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	remaining := saving
+	for position, index := range indexes {
+		amount := saving.Scale(contributed[index], groupedTotal)
+		if position == len(indexes)-1 {
+			amount = remaining
+		}
+		amount = capAmount(amount, e.lines[index].Amount)
+		lineAmounts[index] = amount
+		remaining -= amount
+	}
+}
+
+func tierPrice(tiers []model.PromotionTier, qty float64) (model.Money, bool) {
+	var price model.Money
+	var best float64 = -1
+	for _, tier := range tiers {
+		if qty >= tier.MinQty && tier.MinQty > best {
+			best = tier.MinQty
+			price = tier.Price
+		}
+	}
+	return price, best >= 0
+}
+
+func capAmount(amount, limit model.Money) model.Money {
+	if amount > limit {
+		return limit
+	}
+	if amount < 0 {
+		return 0
+	}
+	return amount
+}
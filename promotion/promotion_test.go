@@ -0,0 +1,122 @@
+package promotion
+
+import (
+	"testing"
+	"time"
+
+	"github.com/saptaka/pos/model"
+)
+
+func TestApply(t *testing.T) {
+	now := time.Date(2024, time.March, 14, 12, 0, 0, 0, time.UTC)
+	productId, otherProductId, categoryId := int64(1), int64(2), int64(7)
+	yesterday := &model.Timestamp{Time: now.AddDate(0, 0, -1)}
+
+	line := func(productId int64, unitPrice model.Money, qty float64) Line {
+		return Line{
+			ProductId:  productId,
+			CategoryId: &categoryId,
+			UnitPrice:  unitPrice,
+			Qty:        qty,
+			Amount:     unitPrice.Mul(qty),
+		}
+	}
+	discounted := line(productId, 10000, 2)
+	discounted.Discounted = true
+
+	tests := []struct {
+		name       string
+		promotions []model.Promotion
+		lines      []Line
+		wantLine   model.Money
+		wantBasket model.Money
+	}{
+		{"fixed amount off each unit",
+			[]model.Promotion{{Type: model.PromotionFixedAmount, ProductId: &productId, Amount: 1000}},
+			[]Line{line(productId, 10000, 2), line(otherProductId, 5000, 1)},
+			2000, 0},
+		{"fixed amount off the basket",
+			[]model.Promotion{{Type: model.PromotionFixedAmount, Amount: 5000}},
+			[]Line{line(productId, 10000, 2)},
+			0, 5000},
+		{"fixed amount capped at the line",
+			[]model.Promotion{{Type: model.PromotionFixedAmount, ProductId: &productId, Amount: 15000}},
+			[]Line{line(productId, 10000, 1)},
+			10000, 0},
+		{"buy two get one free",
+			[]model.Promotion{{Type: model.PromotionBuyXGetY, ProductId: &productId, BuyQty: 2, GetQty: 1}},
+			[]Line{line(productId, 10000, 3)},
+			10000, 0},
+		{"buy two get one half off",
+			[]model.Promotion{{Type: model.PromotionBuyXGetY, ProductId: &productId, BuyQty: 2, GetQty: 1, Percent: 50}},
+			[]Line{line(productId, 10000, 3)},
+			5000, 0},
+		{"buy one get another product free",
+			[]model.Promotion{{Type: model.PromotionBuyXGetY, ProductId: &productId, GetProductId: &otherProductId, BuyQty: 1, GetQty: 1}},
+			[]Line{line(productId, 10000, 2), line(otherProductId, 3000, 1)},
+			3000, 0},
+		{"buy x get y short of a group",
+			[]model.Promotion{{Type: model.PromotionBuyXGetY, ProductId: &productId, BuyQty: 2, GetQty: 1}},
+			[]Line{line(productId, 10000, 2)},
+			0, 0},
+		{"mix and match most expensive first",
+			[]model.Promotion{{Type: model.PromotionMixMatch, CategoryId: &categoryId, BuyQty: 3, Amount: 25000}},
+			[]Line{line(productId, 10000, 2), line(otherProductId, 12000, 2)},
+			9000, 0},
+		{"spend threshold percent",
+			[]model.Promotion{{Type: model.PromotionSpendThreshold, ValueType: model.ValuePercent, MinSpend: 50000, Percent: 10}},
+			[]Line{line(productId, 20000, 3)},
+			0, 6000},
+		{"spend threshold not reached",
+			[]model.Promotion{{Type: model.PromotionSpendThreshold, ValueType: model.ValueAmount, MinSpend: 50000, Amount: 5000}},
+			[]Line{line(productId, 20000, 2)},
+			0, 0},
+		{"tiered price",
+			[]model.Promotion{{Type: model.PromotionTiered, ProductId: &productId, Tiers: []model.PromotionTier{
+				{MinQty: 5, Price: 9500}, {MinQty: 10, Price: 9000}}}},
+			[]Line{line(productId, 10000, 12)},
+			12000, 0},
+		{"not stackable on a discounted line",
+			[]model.Promotion{{Type: model.PromotionFixedAmount, ProductId: &productId, Amount: 1000}},
+			[]Line{discounted},
+			0, 0},
+		{"stackable on a discounted line",
+			[]model.Promotion{{Type: model.PromotionFixedAmount, ProductId: &productId, Amount: 1000, Stackable: true}},
+			[]Line{discounted},
+			2000, 0},
+		{"exclusive ends the evaluation",
+			[]model.Promotion{
+				{PromotionId: 1, Type: model.PromotionFixedAmount, ProductId: &productId, Amount: 1000, Priority: 2, Exclusive: true},
+				{PromotionId: 2, Type: model.PromotionFixedAmount, Amount: 5000, Priority: 1, Stackable: true}},
+			[]Line{line(productId, 10000, 2)},
+			2000, 0},
+		{"higher priority claims the line",
+			[]model.Promotion{
+				{PromotionId: 1, Type: model.PromotionFixedAmount, ProductId: &productId, Amount: 1000, Priority: 1},
+				{PromotionId: 2, Type: model.PromotionFixedAmount, ProductId: &productId, Amount: 3000, Priority: 2}},
+			[]Line{line(productId, 10000, 1)},
+			3000, 0},
+		{"expired",
+			[]model.Promotion{{Type: model.PromotionFixedAmount, ProductId: &productId, Amount: 1000, ExpiredAt: yesterday}},
+			[]Line{line(productId, 10000, 2)},
+			0, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Apply(test.promotions, test.lines, now)
+			if got.LineDiscount != test.wantLine {
+				t.Errorf("LineDiscount = %d, want %d", got.LineDiscount, test.wantLine)
+			}
+			if got.BasketDiscount != test.wantBasket {
+				t.Errorf("BasketDiscount = %d, want %d", got.BasketDiscount, test.wantBasket)
+			}
+			var applied model.Money
+			for _, promotion := range got.Applied {
+				applied += promotion.Amount
+			}
+			if applied != test.wantLine+test.wantBasket {
+				t.Errorf("applied promotions total %d, want %d", applied, test.wantLine+test.wantBasket)
+			}
+		})
+	}
+}
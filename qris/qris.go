@@ -0,0 +1,168 @@
+package qris
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrFieldTooLong = errors.New("qris: field value longer than 99 characters")
+	ErrMalformed    = errors.New("qris: malformed payload")
+	ErrChecksum     = errors.New("qris: checksum mismatch")
+)
+
+// Merchant is the store as known to the QRIS network.
+type Merchant struct {
+	Name       string
+	City       string
+	PostalCode string
+	// ID is the National Merchant ID (NMID) issued with the QRIS
+	// registration.
+	ID string
+	// Acquirer is the reverse domain identifying the acquirer, such as
+	// ID.CO.BANKNAME.WWW, and PAN the merchant's account at it.
+	Acquirer string
+	PAN      string
+	// Category is the ISO 18245 merchant category code, and Criteria the
+	// merchant size: UMI, UKE, UME, UBE or URE.
+	Category string
+	Criteria string
+}
+
+// Field is a tag, length, value element of a payload.
+type Field struct {
+	Tag   string
+	Value string
+}
+
+// TLV encodes a field as its two digit tag, two digit length and value.
+// The length counts characters, not bytes, as EMVCo has it for the
+// alternate language template.
+func TLV(tag, value string) (string, error) {
+	length := utf8.RuneCountInString(value)
+	if length > 99 {
+		return "", ErrFieldTooLong
+	}
+	return fmt.Sprintf("%s%02d%s", tag, length, value), nil
+}
+
+// Encode writes fields as consecutive TLV elements, skipping empty ones.
+func Encode(fields ...Field) (string, error) {
+	var payload strings.Builder
+	for _, field := range fields {
+		if field.Value == "" {
+			continue
+		}
+		element, err := TLV(field.Tag, field.Value)
+		if err != nil {
+			return "", err
+		}
+		payload.WriteString(element)
+	}
+	return payload.String(), nil
+}
+
+// Decode reads consecutive TLV elements.
+func Decode(data string) ([]Field, error) {
+	var fields []Field
+	runes := []rune(data)
+	for len(runes) > 0 {
+		if len(runes) < 4 {
+			return nil, ErrMalformed
+		}
+		length, err := strconv.Atoi(string(runes[2:4]))
+		if err != nil || length < 0 || len(runes) < 4+length {
+			return nil, ErrMalformed
+		}
+		fields = append(fields, Field{Tag: string(runes[:2]), Value: string(runes[4 : 4+length])})
+		runes = runes[4+length:]
+	}
+	return fields, nil
+}
+
+// CRC16 is the CRC-16/CCITT-FALSE checksum EMVCo payloads end with:
+// polynomial 0x1021, initial value 0xFFFF.
+func CRC16(data string) uint16 {
+	crc := uint16(0xFFFF)
+	for index := 0; index < len(data); index++ {
+		crc ^= uint16(data[index]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// Payload is the dynamic EMVCo merchant presented QR payload asking for
+// amount, in rupiah as a plain decimal, for the bill with the given
+// number. The checksum covers everything up to and including its own tag
+// and length.
+func Payload(merchant Merchant, amount, billNumber string) (string, error) {
+	account, err := Encode(
+		Field{"00", merchant.Acquirer},
+		Field{"01", merchant.PAN},
+		Field{"02", merchant.ID},
+		Field{"03", merchant.Criteria},
+	)
+	if err != nil {
+		return "", err
+	}
+	national, err := Encode(
+		Field{"00", "ID.CO.QRIS.WWW"},
+		Field{"02", merchant.ID},
+		Field{"03", merchant.Criteria},
+	)
+	if err != nil {
+		return "", err
+	}
+	additional, err := Encode(Field{"01", billNumber})
+	if err != nil {
+		return "", err
+	}
+	payload, err := Encode(
+		Field{"00", "01"},
+		Field{"01", "12"},
+		Field{"26", account},
+		Field{"51", national},
+		Field{"52", merchant.Category},
+		Field{"53", "360"},
+		Field{"54", amount},
+		Field{"58", "ID"},
+		Field{"59", truncate(merchant.Name, 25)},
+		Field{"60", truncate(merchant.City, 15)},
+		Field{"61", merchant.PostalCode},
+		Field{"62", additional},
+	)
+	if err != nil {
+		return "", err
+	}
+	payload += "6304"
+	return payload + fmt.Sprintf("%04X", CRC16(payload)), nil
+}
+
+// Verify checks the checksum of a payload and decodes its fields.
+func Verify(payload string) ([]Field, error) {
+	if len(payload) < 8 || payload[len(payload)-8:len(payload)-4] != "6304" {
+		return nil, ErrMalformed
+	}
+	body, checksum := payload[:len(payload)-4], payload[len(payload)-4:]
+	if fmt.Sprintf("%04X", CRC16(body)) != strings.ToUpper(checksum) {
+		return nil, ErrChecksum
+	}
+	return Decode(payload)
+}
+
+func truncate(value string, length int) string {
+	runes := []rune(value)
+	if len(runes) > length {
+		return string(runes[:length])
+	}
+	return value
+}
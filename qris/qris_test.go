@@ -0,0 +1,211 @@
+package qris
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// emvcoSample is the merchant presented QR payload given as the example in
+// the EMVCo QR Code Specification for Payment Systems, Merchant-Presented
+// Mode, including its alternate language template in Chinese.
+const emvcoSample = "00020101021229300012D156000000000510A93FO3230Q31280012D156000000010308" +
+	"12345678520441115802CN5914BEST TRANSPORT6007BEIJING64200002ZH0104最佳运输" +
+	"0202北京540523.7253031565502016233030412340603***0708A60086670902ME91320016" +
+	"A0112233449988770708123456786304A13A"
+
+func TestCRC16(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want uint16
+	}{
+		{"empty", "", 0xFFFF},
+		// The check value of CRC-16/CCITT-FALSE in the CRC catalogue.
+		{"check value", "123456789", 0x29B1},
+		{"emvco sample", strings.TrimSuffix(emvcoSample, "A13A"), 0xA13A},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := CRC16(test.data); got != test.want {
+				t.Errorf("CRC16(%q) = %04X, want %04X", test.data, got, test.want)
+			}
+		})
+	}
+}
+
+func TestTLV(t *testing.T) {
+	tests := []struct {
+		name  string
+		tag   string
+		value string
+		want  string
+		err   error
+	}{
+		{"format indicator", "00", "01", "000201", nil},
+		{"merchant name", "59", "BEST TRANSPORT", "5914BEST TRANSPORT", nil},
+		{"empty value", "62", "", "6200", nil},
+		{"length in characters", "01", "最佳运输", "0104最佳运输", nil},
+		{"longest value", "59", strings.Repeat("A", 99), "5999" + strings.Repeat("A", 99), nil},
+		{"too long", "59", strings.Repeat("A", 100), "", ErrFieldTooLong},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := TLV(test.tag, test.value)
+			if err != test.err {
+				t.Fatalf("TLV(%q, %q) error = %v, want %v", test.tag, test.value, err, test.err)
+			}
+			if got != test.want {
+				t.Errorf("TLV(%q, %q) = %q, want %q", test.tag, test.value, got, test.want)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []Field
+		err  error
+	}{
+		{"empty", "", nil, nil},
+		{"alternate language template",
+			"0002ZH0104最佳运输0202北京",
+			[]Field{{"00", "ZH"}, {"01", "最佳运输"}, {"02", "北京"}}, nil},
+		{"short header", "000", nil, ErrMalformed},
+		{"length not a number", "00AB01", nil, ErrMalformed},
+		{"value cut short", "0005ABC", nil, ErrMalformed},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Decode(test.data)
+			if err != test.err {
+				t.Fatalf("Decode(%q) error = %v, want %v", test.data, err, test.err)
+			}
+			if !sameFields(got, test.want) {
+				t.Errorf("Decode(%q) = %v, want %v", test.data, got, test.want)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	want := []Field{
+		{"00", "01"},
+		{"01", "12"},
+		{"29", "0012D156000000000510A93FO3230Q"},
+		{"31", "0012D15600000001030812345678"},
+		{"52", "4111"},
+		{"58", "CN"},
+		{"59", "BEST TRANSPORT"},
+		{"60", "BEIJING"},
+		{"64", "0002ZH0104最佳运输0202北京"},
+		{"54", "23.72"},
+		{"53", "156"},
+		{"55", "01"},
+		{"62", "030412340603***0708A60086670902ME"},
+		{"91", "0016A011223344998877070812345678"},
+		{"63", "A13A"},
+	}
+	tests := []struct {
+		name    string
+		payload string
+		want    []Field
+		err     error
+	}{
+		{"emvco sample", emvcoSample, want, nil},
+		{"lower case checksum", strings.TrimSuffix(emvcoSample, "A13A") + "a13a", nil, nil},
+		{"wrong checksum", strings.TrimSuffix(emvcoSample, "A13A") + "A13B", nil, ErrChecksum},
+		{"altered amount", strings.Replace(emvcoSample, "23.72", "23.73", 1), nil, ErrChecksum},
+		{"no checksum", strings.TrimSuffix(emvcoSample, "6304A13A"), nil, ErrMalformed},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Verify(test.payload)
+			if err != test.err {
+				t.Fatalf("Verify error = %v, want %v", err, test.err)
+			}
+			if test.want != nil && !sameFields(got, test.want) {
+				t.Errorf("Verify = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+// TestEncodeSample encodes the fields of the EMVCo sample again and checks
+// the payload comes out byte for byte the same.
+func TestEncodeSample(t *testing.T) {
+	fields, err := Verify(emvcoSample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := Encode(fields[:len(fields)-1]...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload += "6304"
+	payload += fmt.Sprintf("%04X", CRC16(payload))
+	if payload != emvcoSample {
+		t.Errorf("Encode = %q, want %q", payload, emvcoSample)
+	}
+}
+
+func TestPayload(t *testing.T) {
+	merchant := Merchant{
+		Name:       "Toko Sejahtera Makmur Abadi Jaya",
+		City:       "Kota Jakarta Selatan",
+		PostalCode: "12190",
+		ID:         "ID1020021181745",
+		Acquirer:   "ID.CO.BANKNAME.WWW",
+		PAN:        "9360001234567890123",
+		Category:   "5411",
+		Criteria:   "UMI",
+	}
+	payload, err := Payload(merchant, "15000", "INV001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields, err := Verify(payload)
+	if err != nil {
+		t.Fatalf("Verify(%q) error = %v", payload, err)
+	}
+	values := make(map[string]string)
+	for _, field := range fields {
+		values[field.Tag] = field.Value
+	}
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{"00", "01"},
+		{"01", "12"},
+		{"26", "0018ID.CO.BANKNAME.WWW011993600012345678901230215ID10200211817450303UMI"},
+		{"51", "0014ID.CO.QRIS.WWW0215ID10200211817450303UMI"},
+		{"52", "5411"},
+		{"53", "360"},
+		{"54", "15000"},
+		{"58", "ID"},
+		{"59", "Toko Sejahtera Makmur Aba"},
+		{"60", "Kota Jakarta Se"},
+		{"61", "12190"},
+		{"62", "0106INV001"},
+	}
+	for _, test := range tests {
+		if values[test.tag] != test.want {
+			t.Errorf("tag %s = %q, want %q", test.tag, values[test.tag], test.want)
+		}
+	}
+}
+
+func sameFields(got, want []Field) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for index := range got {
+		if got[index] != want[index] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,50 @@
+package report
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/saptaka/pos/model"
+)
+
+// PDF lays out a report on an A4 page for filing.
+func PDF(storeName string, report model.SalesReport, location *time.Location) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(storeName, true)
+	if report.GeneratedAt != nil {
+		pdf.SetCreationDate(*report.GeneratedAt)
+	}
+	translate := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.AddPage()
+
+	width, _ := pdf.GetPageSize()
+	left, _, right, _ := pdf.GetMargins()
+	width -= left + right
+	for index, row := range lines(storeName, report, location) {
+		switch {
+		case row.label == "" && row.value == "":
+			y := pdf.GetY() + 2
+			pdf.Line(left, y, left+width, y)
+			pdf.Ln(4)
+		case row.value == "":
+			size := 11.0
+			if index < 2 {
+				size = 14
+			}
+			pdf.SetFont("Helvetica", "B", size)
+			pdf.CellFormat(width, 7, translate(row.label), "", 1, "C", false, 0, "")
+		default:
+			pdf.SetFont("Helvetica", "", 10)
+			pdf.CellFormat(width/2, 6, translate(row.label), "", 0, "L", false, 0, "")
+			pdf.CellFormat(width/2, 6, translate(row.value), "", 1, "R", false, 0, "")
+		}
+	}
+
+	var file bytes.Buffer
+	err := pdf.Output(&file)
+	if err != nil {
+		return nil, err
+	}
+	return file.Bytes(), nil
+}
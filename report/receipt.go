@@ -0,0 +1,78 @@
+package report
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/utils"
+)
+
+// Receipt lays out an order's receipt as the receipt printer would print
+// it, showing times in location.
+func Receipt(storeName string, order model.Order, products []model.OrderedProductDetail,
+	location *time.Location) []byte {
+
+	rows := []line{
+		{label: storeName},
+		{label: "RECEIPT " + order.ReceiptID},
+		{"Date", formatTime(order.CreatedAt, location)},
+		{},
+	}
+	for _, product := range products {
+		rows = append(rows, line{
+			fmt.Sprintf("%s %s %s", product.Name, utils.FormatQty(product.Qty), product.Unit),
+			product.TotalFinalPrice.String(),
+		})
+	}
+	rows = append(rows, line{})
+	if discount := order.TotalDiscount + order.ManualDiscount; discount != 0 {
+		rows = append(rows, line{"Discount", (-discount).String()})
+	}
+	taxLabel := "Tax"
+	if order.TaxInclusive {
+		taxLabel = "Tax (included)"
+	}
+	rows = append(rows, line{taxLabel, order.TotalTax.String()})
+	if order.ServiceCharge != 0 {
+		rows = append(rows, line{"Service charge", order.ServiceCharge.String()})
+	}
+	if order.Tip != 0 {
+		rows = append(rows, line{"Tip", order.Tip.String()})
+	}
+	if order.Rounding != 0 {
+		rows = append(rows, line{"Rounding", order.Rounding.String()})
+	}
+	rows = append(rows, line{"TOTAL", receiptTotal(order).String()}, line{})
+	if order.PointsAmount != 0 {
+		rows = append(rows, line{fmt.Sprintf("Points (%d)", order.PointsRedeemed), order.PointsAmount.String()})
+	}
+	if order.GiftCardAmount != 0 {
+		rows = append(rows, line{"Gift cards", order.GiftCardAmount.String()})
+	}
+	paid := "Paid"
+	if order.PaymentType != nil && order.PaymentType.Name != "" {
+		paid = order.PaymentType.Name
+	}
+	rows = append(rows,
+		line{paid, order.TotalPaid.String()},
+		line{"Change", order.TotalReturn.String()},
+	)
+	if order.PointsEarned != 0 {
+		rows = append(rows, line{"Points earned", strconv.FormatInt(order.PointsEarned, 10)})
+	}
+	return render(append(rows, line{}, line{label: "Thank you"}))
+}
+
+// ReceiptSummary is a receipt short enough for a text message.
+func ReceiptSummary(storeName string, order model.Order, location *time.Location) string {
+	return fmt.Sprintf("%s receipt %s, %s: total %s, paid %s, change %s. Thank you!",
+		storeName, order.ReceiptID, formatTime(order.CreatedAt, location),
+		receiptTotal(order), order.TotalPaid, order.TotalReturn)
+}
+
+// receiptTotal is what the order came to, however it was tendered.
+func receiptTotal(order model.Order) model.Money {
+	return order.TotalPrice + order.ServiceCharge + order.Tip + order.Rounding
+}
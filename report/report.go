@@ -0,0 +1,72 @@
+// Package report lays out sales reports for printing, as plain text for
+// the receipt printer and as PDF, and order receipts as plain text.
+package report
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/saptaka/pos/model"
+)
+
+// Width is how many characters a line of the text layout holds, that of
+// an 80mm receipt printer.
+const Width = 42
+
+// line is one row of a report: a label with its value, a heading when it
+// has no value, or a rule when both are empty.
+type line struct {
+	label string
+	value string
+}
+
+const timeLayout = "2006-01-02 15:04"
+
+// lines lays out a report, showing times in location.
+func lines(storeName string, report model.SalesReport, location *time.Location) []line {
+	title := "X REPORT"
+	if report.Type == model.ReportZ {
+		title = fmt.Sprintf("Z REPORT #%06d", report.Number)
+	}
+	rows := []line{{label: storeName}, {label: title}}
+	if report.ShiftId != nil {
+		rows = append(rows, line{"Shift", fmt.Sprint(*report.ShiftId)})
+	}
+	rows = append(rows,
+		line{"From", formatTime(report.From, location)},
+		line{"To", formatTime(report.To, location)},
+		line{"Printed", formatTime(report.GeneratedAt, location)},
+		line{},
+		line{"Orders", fmt.Sprint(report.TotalOrder)},
+		line{"Gross sales", report.GrossSales.String()},
+		line{"Discounts", (-report.Discounts).String()},
+		line{"Net sales", report.NetSales.String()},
+		line{"Tax", report.Tax.String()},
+		line{"Service charge", report.ServiceCharge.String()},
+		line{"Tips", report.Tips.String()},
+		line{"Rounding", report.Rounding.String()},
+		line{"Total collected", report.TotalCollected.String()},
+		line{},
+		line{fmt.Sprintf("Refunds (%d)", report.Refunds.TotalOrder), (-report.Refunds.Amount).String()},
+		line{fmt.Sprintf("Voids (%d)", report.Voids.TotalOrder), report.Voids.Amount.String()},
+		line{},
+		line{label: "TENDERS"},
+	)
+	for _, tender := range report.Tenders {
+		rows = append(rows, line{fmt.Sprintf("%s (%d)", tender.Name, tender.TotalOrder), tender.Amount.String()})
+	}
+	return append(rows,
+		line{},
+		line{"Cash over/short", report.CashOverShort.String()},
+	)
+}
+
+func formatTime(t *time.Time, location *time.Location) string {
+	if t == nil {
+		return "-"
+	}
+	if location != nil {
+		return t.In(location).Format(timeLayout)
+	}
+	return t.Format(timeLayout)
+}
@@ -0,0 +1,55 @@
+package report
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/saptaka/pos/model"
+)
+
+// Text lays out a report Width characters wide, headings centred and
+// values aligned right.
+func Text(storeName string, report model.SalesReport, location *time.Location) []byte {
+	return render(lines(storeName, report, location))
+}
+
+func render(rows []line) []byte {
+	var text strings.Builder
+	for _, row := range rows {
+		switch {
+		case row.label == "" && row.value == "":
+			text.WriteString(strings.Repeat("-", Width))
+		case row.value == "":
+			text.WriteString(centre(row.label))
+		default:
+			text.WriteString(justify(row.label, row.value))
+		}
+		text.WriteString("\n")
+	}
+	return []byte(text.String())
+}
+
+func centre(text string) string {
+	text = clip(text, Width)
+	return strings.Repeat(" ", (Width-utf8.RuneCountInString(text))/2) + text
+}
+
+func justify(label, value string) string {
+	label = clip(label, Width-utf8.RuneCountInString(value)-1)
+	padding := Width - utf8.RuneCountInString(label) - utf8.RuneCountInString(value)
+	if padding < 1 {
+		padding = 1
+	}
+	return label + strings.Repeat(" ", padding) + value
+}
+
+func clip(text string, length int) string {
+	if length < 0 {
+		length = 0
+	}
+	if utf8.RuneCountInString(text) <= length {
+		return text
+	}
+	return string([]rune(text)[:length])
+}
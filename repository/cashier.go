@@ -11,16 +11,17 @@ type CashierRepo interface {
 	GetCashierByID(ctx context.Context, id int64) (model.Cashier, error)
 	GetCashiers(ctx context.Context, limit, skip int) ([]model.Cashier, error)
 	UpdateCashier(ctx context.Context, cashier model.Cashier) error
-	CreateCashier(ctx context.Context, name, passcode string) (model.Cashier, error)
+	CreateCashier(ctx context.Context, name, passcode, role string) (model.Cashier, error)
 	DeleteCashier(ctx context.Context, id int64) error
 	GetPasscodeById(ctx context.Context, id int64) (string, error)
+	RestoreCashier(ctx context.Context, id int64) error
 }
 
 func (r repo) GetCashierByID(ctx context.Context, id int64) (model.Cashier, error) {
 	var cashier model.Cashier
-	query := "SELECT id, name FROM cashiers WHERE id=?"
+	query := "SELECT id, name, role, archived_at FROM cashiers WHERE id=?"
 	rows := r.db.QueryRowContext(ctx, query, id)
-	err := rows.Scan(&cashier.CashierId, &cashier.Name)
+	err := rows.Scan(&cashier.CashierId, &cashier.Name, &cashier.Role, &cashier.ArchivedAt)
 	if err != nil {
 		return cashier, err
 	}
@@ -30,7 +31,15 @@ func (r repo) GetCashierByID(ctx context.Context, id int64) (model.Cashier, erro
 
 func (r repo) GetCashiers(ctx context.Context,
 	limit, skip int) ([]model.Cashier, error) {
-	query := "SELECT id, name FROM cashiers "
+	return r.getCashiers(ctx, limit, skip, false)
+}
+
+func (r repo) getCashiers(ctx context.Context,
+	limit, skip int, includeArchived bool) ([]model.Cashier, error) {
+	query := "SELECT id, name, role, archived_at FROM cashiers "
+	if !includeArchived {
+		query += " WHERE archived_at IS NULL "
+	}
 	var rows *sql.Rows
 	var err error
 	if limit > 0 {
@@ -49,7 +58,7 @@ func (r repo) GetCashiers(ctx context.Context,
 	var cashiers []model.Cashier
 	for rows.Next() {
 		var cashier model.Cashier
-		err := rows.Scan(&cashier.CashierId, &cashier.Name)
+		err := rows.Scan(&cashier.CashierId, &cashier.Name, &cashier.Role, &cashier.ArchivedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -63,11 +72,13 @@ func (r repo) UpdateCashier(ctx context.Context,
 	query := `UPDATE cashiers 
 		SET name=?, 
 			passcode=?, 
+			role=COALESCE(NULLIF(?, ''), role),
 			updated_at=CURRENT_TIMESTAMP() 
 		WHERE id=?`
 	_, err := r.db.ExecContext(ctx, query,
 		cashierDetail.Name,
 		cashierDetail.Passcode,
+		cashierDetail.Role,
 		cashierDetail.CashierId)
 	if err != nil {
 		return err
@@ -76,16 +87,16 @@ func (r repo) UpdateCashier(ctx context.Context,
 	return err
 }
 
-func (r repo) CreateCashier(ctx context.Context, name, passcode string) (model.Cashier, error) {
+func (r repo) CreateCashier(ctx context.Context, name, passcode, role string) (model.Cashier, error) {
 	var cashier model.Cashier
 	insertQuery := `INSERT INTO 
-		cashiers (name,passcode) 
-	VALUES (?,?);`
+		cashiers (name,passcode,role) 
+	VALUES (?,?,?);`
 	stmt, err := r.db.PrepareContext(ctx, insertQuery)
 	if err != nil {
 		return cashier, err
 	}
-	res, err := stmt.Exec(name, passcode)
+	res, err := stmt.Exec(name, passcode, role)
 	if err != nil {
 		return cashier, err
 	}
@@ -96,6 +107,7 @@ func (r repo) CreateCashier(ctx context.Context, name, passcode string) (model.C
 	selectQuery := `SELECT id, 
 					name,
 					passcode, 
+					role,
 					updated_at, 
 					created_at
 					FROM cashiers 
@@ -106,18 +118,34 @@ func (r repo) CreateCashier(ctx context.Context, name, passcode string) (model.C
 		&cashier.CashierId,
 		&cashier.Name,
 		&cashier.Passcode,
+		&cashier.Role,
 		&cashier.UpdatedAt,
 		&cashier.CreatedAt)
 	return cashier, err
 
 }
 
+// DeleteCashier archives the cashier, unless they still have a shift open.
 func (r repo) DeleteCashier(ctx context.Context, id int64) error {
-	_, err := r.GetCashierByID(ctx, id)
+	cashier, err := r.GetCashierByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if cashier.ArchivedAt != nil {
+		return nil
+	}
+
+	var openShifts int
+	countQuery := "SELECT COUNT(*) FROM shifts WHERE cashier_id=? AND status=?"
+	err = r.db.QueryRowContext(ctx, countQuery, id, model.ShiftOpen).Scan(&openShifts)
 	if err != nil {
 		return err
 	}
-	query := "DELETE FROM cashiers WHERE id=?"
+	if openShifts > 0 {
+		return ErrReferenced
+	}
+
+	query := "UPDATE cashiers SET archived_at=CURRENT_TIMESTAMP() WHERE id=? AND archived_at IS NULL"
 	_, err = r.db.ExecContext(ctx, query, id)
 	if err != nil {
 		return err
@@ -128,7 +156,7 @@ func (r repo) DeleteCashier(ctx context.Context, id int64) error {
 
 func (r repo) GetPasscodeById(ctx context.Context, id int64) (string, error) {
 	var passcode string
-	query := "SELECT passcode FROM cashiers WHERE id=?"
+	query := "SELECT passcode FROM cashiers WHERE id=? AND archived_at IS NULL"
 	rows := r.db.QueryRowContext(ctx, query, id)
 	err := rows.Scan(&passcode)
 	if err != nil {
@@ -137,3 +165,13 @@ func (r repo) GetPasscodeById(ctx context.Context, id int64) (string, error) {
 
 	return passcode, nil
 }
+
+func (r repo) RestoreCashier(ctx context.Context, id int64) error {
+	_, err := r.GetCashierByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	query := "UPDATE cashiers SET archived_at=NULL WHERE id=?"
+	_, err = r.db.ExecContext(ctx, query, id)
+	return err
+}
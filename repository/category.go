@@ -13,13 +13,14 @@ type CategoryRepo interface {
 	UpdateCategory(ctx context.Context, category model.Category) error
 	CreateCategory(ctx context.Context, name string) (model.Category, error)
 	DeleteCategory(ctx context.Context, id int64) error
+	RestoreCategory(ctx context.Context, id int64) error
 }
 
 func (r repo) GetCategoryByID(ctx context.Context, id int64) (model.Category, error) {
 	var category model.Category
-	query := "SELECT id, name FROM categories WHERE id=?"
+	query := "SELECT id, name, tax_class_id, archived_at FROM categories WHERE id=?"
 	rows := r.db.QueryRowContext(ctx, query, id)
-	err := rows.Scan(&category.CategoryId, &category.Name)
+	err := rows.Scan(&category.CategoryId, &category.Name, &category.TaxClassId, &category.ArchivedAt)
 	if err != nil {
 		return category, err
 	}
@@ -29,7 +30,15 @@ func (r repo) GetCategoryByID(ctx context.Context, id int64) (model.Category, er
 
 func (r repo) GetCategories(ctx context.Context,
 	limit, skip int) ([]model.Category, error) {
-	query := "SELECT id, name FROM categories "
+	return r.getCategories(ctx, limit, skip, false)
+}
+
+func (r repo) getCategories(ctx context.Context,
+	limit, skip int, includeArchived bool) ([]model.Category, error) {
+	query := "SELECT id, name, tax_class_id, archived_at FROM categories "
+	if !includeArchived {
+		query += " WHERE archived_at IS NULL "
+	}
 	var rows *sql.Rows
 	var err error
 	if limit > 0 {
@@ -47,7 +56,7 @@ func (r repo) GetCategories(ctx context.Context,
 	var categories []model.Category
 	for rows.Next() {
 		var category model.Category
-		err := rows.Scan(&category.CategoryId, &category.Name)
+		err := rows.Scan(&category.CategoryId, &category.Name, &category.TaxClassId, &category.ArchivedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -108,11 +117,25 @@ func (r repo) CreateCategory(ctx context.Context, name string) (model.Category,
 
 func (r repo) DeleteCategory(ctx context.Context, id int64) error {
 
-	_, err := r.GetCategoryByID(ctx, id)
+	category, err := r.GetCategoryByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if category.ArchivedAt != nil {
+		return nil
+	}
+
+	var activeProducts int
+	countQuery := "SELECT COUNT(*) FROM products WHERE category_id=? AND archived_at IS NULL"
+	err = r.db.QueryRowContext(ctx, countQuery, id).Scan(&activeProducts)
 	if err != nil {
 		return err
 	}
-	query := "DELETE FROM categories WHERE id=?"
+	if activeProducts > 0 {
+		return ErrReferenced
+	}
+
+	query := "UPDATE categories SET archived_at=CURRENT_TIMESTAMP() WHERE id=?"
 	_, err = r.db.ExecContext(ctx, query, id)
 	if err != nil {
 		return err
@@ -120,3 +143,13 @@ func (r repo) DeleteCategory(ctx context.Context, id int64) error {
 
 	return err
 }
+
+func (r repo) RestoreCategory(ctx context.Context, id int64) error {
+	_, err := r.GetCategoryByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	query := "UPDATE categories SET archived_at=NULL WHERE id=?"
+	_, err = r.db.ExecContext(ctx, query, id)
+	return err
+}
@@ -0,0 +1,376 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/saptaka/pos/model"
+)
+
+type CouponRepo interface {
+	GetCouponByID(ctx context.Context, id int64) (model.Coupon, error)
+	GetCouponByCode(ctx context.Context, code string) (model.Coupon, error)
+	GetCoupons(ctx context.Context, limit, skip int) ([]model.Coupon, error)
+	CreateCoupon(ctx context.Context, coupon model.Coupon) (model.Coupon, error)
+	UpdateCoupon(ctx context.Context, coupon model.Coupon) error
+	DeleteCoupon(ctx context.Context, id int64) error
+	CountCouponRedemptions(ctx context.Context, couponId, customerId int64) (int, error)
+	GetCouponRedemptions(ctx context.Context, couponId int64) ([]model.CouponRedemption, error)
+	GetCouponRedemptionsByOrderId(ctx context.Context, orderId int64) ([]model.CouponRedemption, error)
+}
+
+const couponColumns = `
+			id,
+			code,
+			promotion_id,
+			usage_limit,
+			per_customer_limit,
+			redeemed,
+			started_at,
+			expired_at,
+			updated_at,
+			created_at,
+			archived_at`
+
+func scanCoupon(row rowScanner) (model.Coupon, error) {
+	var coupon model.Coupon
+	err := row.Scan(
+		&coupon.CouponId,
+		&coupon.Code,
+		&coupon.PromotionId,
+		&coupon.UsageLimit,
+		&coupon.PerCustomerLimit,
+		&coupon.Redeemed,
+		&coupon.StartedAt,
+		&coupon.ExpiredAt,
+		&coupon.UpdatedAt,
+		&coupon.CreatedAt,
+		&coupon.ArchivedAt,
+	)
+	return coupon, err
+}
+
+func (r repo) GetCouponByID(ctx context.Context, id int64) (model.Coupon, error) {
+	query := fmt.Sprintf("SELECT %s FROM coupons WHERE id=?", couponColumns)
+	return scanCoupon(r.db.QueryRowContext(ctx, query, id))
+}
+
+func (r repo) GetCouponByCode(ctx context.Context, code string) (model.Coupon, error) {
+	query := fmt.Sprintf("SELECT %s FROM coupons WHERE code=?", couponColumns)
+	return scanCoupon(r.db.QueryRowContext(ctx, query, code))
+}
+
+func (r repo) GetCoupons(ctx context.Context, limit, skip int) ([]model.Coupon, error) {
+	query := fmt.Sprintf(`SELECT %s FROM coupons
+		WHERE archived_at IS NULL
+		ORDER BY id ASC`, couponColumns)
+	var rows *sql.Rows
+	var err error
+	if limit > 0 {
+		query += " limit ? offset ?;"
+		rows, err = r.db.QueryContext(ctx, query, limit, skip)
+	} else {
+		rows, err = r.db.QueryContext(ctx, query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	coupons := make([]model.Coupon, 0)
+	for rows.Next() {
+		coupon, err := scanCoupon(rows)
+		if err != nil {
+			return nil, err
+		}
+		coupons = append(coupons, coupon)
+	}
+	return coupons, rows.Err()
+}
+
+func (r repo) CreateCoupon(ctx context.Context, coupon model.Coupon) (model.Coupon, error) {
+	query := `INSERT INTO coupons (
+			code, promotion_id, usage_limit, per_customer_limit, started_at, expired_at)
+		VALUES (?,?,?,?,?,?);`
+	result, err := r.db.ExecContext(ctx, query,
+		coupon.Code,
+		coupon.PromotionId,
+		coupon.UsageLimit,
+		coupon.PerCustomerLimit,
+		coupon.StartedAt,
+		coupon.ExpiredAt,
+	)
+	if err != nil {
+		return coupon, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return coupon, err
+	}
+	return r.GetCouponByID(ctx, id)
+}
+
+func (r repo) UpdateCoupon(ctx context.Context, coupon model.Coupon) error {
+	query := `UPDATE coupons
+		SET code=?, promotion_id=?, usage_limit=?, per_customer_limit=?,
+			started_at=?, expired_at=?, updated_at=CURRENT_TIMESTAMP()
+		WHERE id=? AND archived_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query,
+		coupon.Code,
+		coupon.PromotionId,
+		coupon.UsageLimit,
+		coupon.PerCustomerLimit,
+		coupon.StartedAt,
+		coupon.ExpiredAt,
+		coupon.CouponId,
+	)
+	if err != nil {
+		return err
+	}
+	rowAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r repo) DeleteCoupon(ctx context.Context, id int64) error {
+	query := "UPDATE coupons SET archived_at=CURRENT_TIMESTAMP() WHERE id=? AND archived_at IS NULL"
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	rowAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// redeemCoupons records the redemptions of an order within its transaction.
+// The coupon rows are locked and their limits checked again, so concurrent
+// orders cannot redeem a coupon past its limits.
+func (r repo) redeemCoupons(ctx context.Context, tx *sql.Tx, orderId int64,
+	redemptions []model.CouponRedemption) error {
+
+	for _, redemption := range redemptions {
+		var coupon model.Coupon
+		err := tx.QueryRowContext(ctx,
+			`SELECT usage_limit, per_customer_limit, redeemed, archived_at
+			FROM coupons WHERE id=? FOR UPDATE`, redemption.CouponId).Scan(
+			&coupon.UsageLimit,
+			&coupon.PerCustomerLimit,
+			&coupon.Redeemed,
+			&coupon.ArchivedAt,
+		)
+		if err == sql.ErrNoRows {
+			return ErrCouponUnavailable
+		}
+		if err != nil {
+			return err
+		}
+		if coupon.ArchivedAt != nil ||
+			(coupon.UsageLimit > 0 && coupon.Redeemed >= coupon.UsageLimit) {
+			return ErrCouponUnavailable
+		}
+		if coupon.PerCustomerLimit > 0 {
+			if redemption.CustomerId == nil {
+				return ErrCouponUnavailable
+			}
+			var redeemed int
+			err := tx.QueryRowContext(ctx,
+				`SELECT COUNT(*) FROM coupon_redemptions
+				WHERE coupon_id=? AND customer_id=? AND released_at IS NULL`,
+				redemption.CouponId, *redemption.CustomerId).Scan(&redeemed)
+			if err != nil {
+				return err
+			}
+			if redeemed >= coupon.PerCustomerLimit {
+				return ErrCouponUnavailable
+			}
+		}
+
+		_, err = tx.ExecContext(ctx,
+			"UPDATE coupons SET redeemed=redeemed+1 WHERE id=?", redemption.CouponId)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO coupon_redemptions (coupon_id, promotion_id, order_id, customer_id, amount)
+			VALUES (?,?,?,?,?)`,
+			redemption.CouponId,
+			redemption.PromotionId,
+			orderId,
+			redemption.CustomerId,
+			redemption.Amount,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r repo) CountCouponRedemptions(ctx context.Context, couponId, customerId int64) (int, error) {
+	var redeemed int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM coupon_redemptions
+		WHERE coupon_id=? AND customer_id=? AND released_at IS NULL`,
+		couponId, customerId).Scan(&redeemed)
+	return redeemed, err
+}
+
+func (r repo) GetCouponRedemptions(ctx context.Context, couponId int64) ([]model.CouponRedemption, error) {
+	query := `
+	SELECT coupon_redemptions.id,
+		coupon_redemptions.coupon_id,
+		coupons.code,
+		coupon_redemptions.promotion_id,
+		coupon_redemptions.order_id,
+		orders.receipt_id,
+		coupon_redemptions.customer_id,
+		coupon_redemptions.amount,
+		coupon_redemptions.created_at
+	FROM coupon_redemptions
+	JOIN coupons ON coupons.id = coupon_redemptions.coupon_id
+	LEFT JOIN orders ON orders.id = coupon_redemptions.order_id
+	WHERE coupon_redemptions.released_at IS NULL
+	`
+	var args []interface{}
+	if couponId != 0 {
+		query += " AND coupon_redemptions.coupon_id=?"
+		args = append(args, couponId)
+	}
+	query += " ORDER BY coupon_redemptions.id ASC"
+	return r.queryCouponRedemptions(ctx, query, args...)
+}
+
+func (r repo) GetCouponRedemptionsByOrderId(ctx context.Context, orderId int64) ([]model.CouponRedemption, error) {
+	query := `
+	SELECT coupon_redemptions.id,
+		coupon_redemptions.coupon_id,
+		coupons.code,
+		coupon_redemptions.promotion_id,
+		coupon_redemptions.order_id,
+		orders.receipt_id,
+		coupon_redemptions.customer_id,
+		coupon_redemptions.amount,
+		coupon_redemptions.created_at
+	FROM coupon_redemptions
+	JOIN coupons ON coupons.id = coupon_redemptions.coupon_id
+	LEFT JOIN orders ON orders.id = coupon_redemptions.order_id
+	WHERE coupon_redemptions.order_id=? AND coupon_redemptions.released_at IS NULL
+	ORDER BY coupon_redemptions.id ASC
+	`
+	return r.queryCouponRedemptions(ctx, query, orderId)
+}
+
+func (r repo) queryCouponRedemptions(ctx context.Context, query string,
+	args ...interface{}) ([]model.CouponRedemption, error) {
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	redemptions := make([]model.CouponRedemption, 0)
+	for rows.Next() {
+		var redemption model.CouponRedemption
+		var receiptId sql.NullString
+		err := rows.Scan(
+			&redemption.RedemptionId,
+			&redemption.CouponId,
+			&redemption.Code,
+			&redemption.PromotionId,
+			&redemption.OrderId,
+			&receiptId,
+			&redemption.CustomerId,
+			&redemption.Amount,
+			&redemption.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		redemption.ReceiptId = receiptId.String
+		redemptions = append(redemptions, redemption)
+	}
+	return redemptions, rows.Err()
+}
+
+// releaseCoupons gives each coupon an order redeemed its use back once the
+// order did not go through, within the order's transaction. The
+// redemptions are kept, marked released, so reclaimCoupons can redeem
+// them again.
+func releaseCoupons(ctx context.Context, tx *sql.Tx, orderId int64) error {
+	rows, err := tx.QueryContext(ctx, `SELECT coupon_id FROM coupon_redemptions
+		WHERE order_id=? AND released_at IS NULL FOR UPDATE`, orderId)
+	if err != nil {
+		return err
+	}
+	var couponIds []int64
+	for rows.Next() {
+		var couponId int64
+		err = rows.Scan(&couponId)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		couponIds = append(couponIds, couponId)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, couponId := range couponIds {
+		_, err = tx.ExecContext(ctx,
+			"UPDATE coupons SET redeemed=redeemed-1 WHERE id=? AND redeemed>0", couponId)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE coupon_redemptions SET released_at=CURRENT_TIMESTAMP()
+		WHERE order_id=? AND released_at IS NULL`, orderId)
+	return err
+}
+
+// reclaimCoupons redeems again the coupons released from an order whose
+// payment arrived after it failed, checking their limits as redeemCoupons
+// does; a coupon used up meanwhile fails with ErrCouponUnavailable.
+func (r repo) reclaimCoupons(ctx context.Context, tx *sql.Tx, orderId int64) error {
+	rows, err := tx.QueryContext(ctx, `SELECT coupon_id, promotion_id, customer_id, amount
+		FROM coupon_redemptions WHERE order_id=? AND released_at IS NOT NULL FOR UPDATE`, orderId)
+	if err != nil {
+		return err
+	}
+	var redemptions []model.CouponRedemption
+	for rows.Next() {
+		var redemption model.CouponRedemption
+		err = rows.Scan(&redemption.CouponId, &redemption.PromotionId,
+			&redemption.CustomerId, &redemption.Amount)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		redemptions = append(redemptions, redemption)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"DELETE FROM coupon_redemptions WHERE order_id=? AND released_at IS NOT NULL", orderId)
+	if err != nil {
+		return err
+	}
+	return r.redeemCoupons(ctx, tx, orderId, redemptions)
+}
@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/saptaka/pos/model"
+)
+
+type CurrencyRepo interface {
+	GetCurrencyByID(ctx context.Context, id int64) (model.Currency, error)
+	GetCurrencyByCode(ctx context.Context, code string) (model.Currency, error)
+	GetCurrencies(ctx context.Context, limit, skip int) ([]model.Currency, error)
+	CreateCurrency(ctx context.Context, currency model.Currency) (model.Currency, error)
+	UpdateCurrency(ctx context.Context, currency model.Currency) error
+	DeleteCurrency(ctx context.Context, id int64) error
+	GetExchangeRates(ctx context.Context, currencyId int64, limit, skip int) ([]model.ExchangeRate, error)
+	CreateExchangeRate(ctx context.Context, rate model.ExchangeRate) (model.ExchangeRate, error)
+	GetEffectiveRate(ctx context.Context, currencyId int64, at time.Time) (model.ExchangeRate, error)
+	GetTenderSummary(ctx context.Context, from, to *time.Time) ([]model.TenderTotal, error)
+}
+
+const currencyColumns = "id, code, name, symbol, minor_units, updated_at, created_at, archived_at"
+
+func scanCurrency(row rowScanner) (model.Currency, error) {
+	var currency model.Currency
+	err := row.Scan(
+		&currency.CurrencyId,
+		&currency.Code,
+		&currency.Name,
+		&currency.Symbol,
+		&currency.MinorUnits,
+		&currency.UpdatedAt,
+		&currency.CreatedAt,
+		&currency.ArchivedAt,
+	)
+	return currency, err
+}
+
+func (r repo) GetCurrencyByID(ctx context.Context, id int64) (model.Currency, error) {
+	query := "SELECT " + currencyColumns + " FROM currencies WHERE id=?"
+	return scanCurrency(r.db.QueryRowContext(ctx, query, id))
+}
+
+func (r repo) GetCurrencyByCode(ctx context.Context, code string) (model.Currency, error) {
+	query := "SELECT " + currencyColumns + " FROM currencies WHERE code=?"
+	return scanCurrency(r.db.QueryRowContext(ctx, query, code))
+}
+
+func (r repo) GetCurrencies(ctx context.Context, limit, skip int) ([]model.Currency, error) {
+	query := "SELECT " + currencyColumns + " FROM currencies WHERE archived_at IS NULL ORDER BY code ASC"
+	var rows *sql.Rows
+	var err error
+	if limit > 0 {
+		query += " limit ? offset ?;"
+		rows, err = r.db.QueryContext(ctx, query, limit, skip)
+	} else {
+		rows, err = r.db.QueryContext(ctx, query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	currencies := make([]model.Currency, 0)
+	for rows.Next() {
+		currency, err := scanCurrency(rows)
+		if err != nil {
+			return nil, err
+		}
+		currencies = append(currencies, currency)
+	}
+	return currencies, rows.Err()
+}
+
+func (r repo) CreateCurrency(ctx context.Context, currency model.Currency) (model.Currency, error) {
+	query := "INSERT INTO currencies (code, name, symbol, minor_units) VALUES (?,?,?,?)"
+	result, err := r.db.ExecContext(ctx, query,
+		currency.Code, currency.Name, currency.Symbol, currency.MinorUnits)
+	if err != nil {
+		return currency, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return currency, err
+	}
+	return r.GetCurrencyByID(ctx, id)
+}
+
+func (r repo) UpdateCurrency(ctx context.Context, currency model.Currency) error {
+	query := `UPDATE currencies
+		SET name=?, symbol=?, minor_units=?, updated_at=CURRENT_TIMESTAMP()
+		WHERE id=? AND archived_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query,
+		currency.Name, currency.Symbol, currency.MinorUnits, currency.CurrencyId)
+	if err != nil {
+		return err
+	}
+	rowAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r repo) DeleteCurrency(ctx context.Context, id int64) error {
+	query := "UPDATE currencies SET archived_at=CURRENT_TIMESTAMP() WHERE id=? AND archived_at IS NULL"
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	rowAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetExchangeRates lists the rates entered for a currency, the latest
+// effective first.
+func (r repo) GetExchangeRates(ctx context.Context, currencyId int64,
+	limit, skip int) ([]model.ExchangeRate, error) {
+
+	query := `SELECT exchange_rates.id, currency_id, currencies.code, rate, effective_at, exchange_rates.created_at
+		FROM exchange_rates
+		JOIN currencies ON currencies.id = exchange_rates.currency_id
+		WHERE currency_id=?
+		ORDER BY effective_at DESC, exchange_rates.id DESC`
+	var rows *sql.Rows
+	var err error
+	if limit > 0 {
+		query += " limit ? offset ?;"
+		rows, err = r.db.QueryContext(ctx, query, currencyId, limit, skip)
+	} else {
+		rows, err = r.db.QueryContext(ctx, query, currencyId)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := make([]model.ExchangeRate, 0)
+	for rows.Next() {
+		var rate model.ExchangeRate
+		err := rows.Scan(
+			&rate.ExchangeRateId,
+			&rate.CurrencyId,
+			&rate.Code,
+			&rate.Rate,
+			&rate.EffectiveAt,
+			&rate.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+	return rates, rows.Err()
+}
+
+func (r repo) CreateExchangeRate(ctx context.Context, rate model.ExchangeRate) (model.ExchangeRate, error) {
+	query := "INSERT INTO exchange_rates (currency_id, rate, effective_at) VALUES (?,?,?)"
+	result, err := r.db.ExecContext(ctx, query, rate.CurrencyId, rate.Rate, rate.EffectiveAt)
+	if err != nil {
+		return rate, err
+	}
+	rate.ExchangeRateId, err = result.LastInsertId()
+	return rate, err
+}
+
+// GetEffectiveRate is the rate of a currency in effect at the given time:
+// the latest one to have taken effect by then.
+func (r repo) GetEffectiveRate(ctx context.Context, currencyId int64, at time.Time) (model.ExchangeRate, error) {
+	query := `SELECT exchange_rates.id, currency_id, currencies.code, rate, effective_at, exchange_rates.created_at
+		FROM exchange_rates
+		JOIN currencies ON currencies.id = exchange_rates.currency_id
+		WHERE currency_id=? AND effective_at <= ?
+		ORDER BY effective_at DESC, exchange_rates.id DESC
+		LIMIT 1`
+	var rate model.ExchangeRate
+	err := r.db.QueryRowContext(ctx, query, currencyId, at).Scan(
+		&rate.ExchangeRateId,
+		&rate.CurrencyId,
+		&rate.Code,
+		&rate.Rate,
+		&rate.EffectiveAt,
+		&rate.CreatedAt,
+	)
+	return rate, err
+}
+
+// GetTenderSummary totals the orders paid in a foreign currency per
+// currency over orders placed between from and to, either of which may be
+// left open. Amount is the foreign total in major units.
+func (r repo) GetTenderSummary(ctx context.Context, from, to *time.Time) ([]model.TenderTotal, error) {
+	query := `
+	SELECT tender_currency,
+		COUNT(id),
+		CAST(COALESCE(SUM(CAST(tender_amount AS DECIMAL(18,4))), 0) AS CHAR),
+		COALESCE(SUM(total_paid), 0)
+	FROM orders
+	WHERE tender_currency <> '' AND status = 'PAID'`
+	var args []interface{}
+	if from != nil {
+		query += " AND created_at >= ?"
+		args = append(args, *from)
+	}
+	if to != nil {
+		query += " AND created_at < ?"
+		args = append(args, *to)
+	}
+	query += `
+	GROUP BY tender_currency
+	ORDER BY tender_currency ASC`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tenders := make([]model.TenderTotal, 0)
+	for rows.Next() {
+		var tender model.TenderTotal
+		err := rows.Scan(
+			&tender.Currency,
+			&tender.TotalOrder,
+			&tender.Amount,
+			&tender.TotalPaid,
+		)
+		if err != nil {
+			return nil, err
+		}
+		tenders = append(tenders, tender)
+	}
+	return tenders, rows.Err()
+}
@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/saptaka/pos/model"
+)
+
+type CustomerRepo interface {
+	GetCustomerByID(ctx context.Context, id int64) (model.Customer, error)
+	GetCustomerByPhone(ctx context.Context, phone string) (model.Customer, error)
+	GetCustomers(ctx context.Context, limit, skip int, filter model.CustomerFilter) ([]model.Customer, error)
+	CreateCustomer(ctx context.Context, customer model.Customer) (model.Customer, error)
+	UpdateCustomer(ctx context.Context, customer model.Customer) error
+	DeleteCustomer(ctx context.Context, id int64) error
+	RestoreCustomer(ctx context.Context, id int64) error
+	GetCustomerOrders(ctx context.Context, customerId int64, limit, skip int) ([]model.Order, error)
+	GetCustomerSpend(ctx context.Context, customerId int64) (int, model.Money, *time.Time, error)
+}
+
+const customerColumns = "id, name, phone, email, notes, tags, updated_at, created_at, archived_at"
+
+func scanCustomer(row rowScanner) (model.Customer, error) {
+	var customer model.Customer
+	var tags string
+	err := row.Scan(
+		&customer.CustomerId,
+		&customer.Name,
+		&customer.Phone,
+		&customer.Email,
+		&customer.Notes,
+		&tags,
+		&customer.UpdatedAt,
+		&customer.CreatedAt,
+		&customer.ArchivedAt,
+	)
+	customer.Tags = make([]string, 0)
+	if tags != "" {
+		customer.Tags = strings.Split(tags, ",")
+	}
+	return customer, err
+}
+
+func (r repo) GetCustomerByID(ctx context.Context, id int64) (model.Customer, error) {
+	query := "SELECT " + customerColumns + " FROM customers WHERE id=?"
+	return scanCustomer(r.db.QueryRowContext(ctx, query, id))
+}
+
+// GetCustomerByPhone finds the active customer with exactly this number.
+func (r repo) GetCustomerByPhone(ctx context.Context, phone string) (model.Customer, error) {
+	query := "SELECT " + customerColumns + " FROM customers WHERE phone=? AND archived_at IS NULL LIMIT 1"
+	return scanCustomer(r.db.QueryRowContext(ctx, query, phone))
+}
+
+func (r repo) GetCustomers(ctx context.Context, limit, skip int,
+	filter model.CustomerFilter) ([]model.Customer, error) {
+
+	query := "SELECT " + customerColumns + " FROM customers WHERE archived_at IS NULL"
+	var args []interface{}
+	if filter.Phone != "" {
+		query += " AND phone LIKE ?"
+		args = append(args, "%"+filter.Phone)
+	}
+	if filter.Query != "" {
+		query += " AND (name LIKE ? OR email LIKE ?)"
+		args = append(args, "%"+filter.Query+"%", "%"+filter.Query+"%")
+	}
+	if filter.Tag != "" {
+		query += " AND FIND_IN_SET(?, tags) > 0"
+		args = append(args, filter.Tag)
+	}
+	query += " ORDER BY name ASC, id ASC"
+	if limit > 0 {
+		query += " limit ? offset ?;"
+		args = append(args, limit, skip)
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	customers := make([]model.Customer, 0)
+	for rows.Next() {
+		customer, err := scanCustomer(rows)
+		if err != nil {
+			return nil, err
+		}
+		customers = append(customers, customer)
+	}
+	return customers, rows.Err()
+}
+
+func (r repo) CreateCustomer(ctx context.Context, customer model.Customer) (model.Customer, error) {
+	query := "INSERT INTO customers (name, phone, email, notes, tags) VALUES (?,?,?,?,?)"
+	result, err := r.db.ExecContext(ctx, query, customer.Name, customer.Phone,
+		customer.Email, customer.Notes, strings.Join(customer.Tags, ","))
+	if err != nil {
+		return customer, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return customer, err
+	}
+	return r.GetCustomerByID(ctx, id)
+}
+
+func (r repo) UpdateCustomer(ctx context.Context, customer model.Customer) error {
+	query := `UPDATE customers
+		SET name=?, phone=?, email=?, notes=?, tags=?, updated_at=CURRENT_TIMESTAMP()
+		WHERE id=? AND archived_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, customer.Name, customer.Phone, customer.Email,
+		customer.Notes, strings.Join(customer.Tags, ","), customer.CustomerId)
+	if err != nil {
+		return err
+	}
+	rowAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r repo) DeleteCustomer(ctx context.Context, id int64) error {
+	query := "UPDATE customers SET archived_at=CURRENT_TIMESTAMP() WHERE id=? AND archived_at IS NULL"
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	rowAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// RestoreCustomer brings back an archived customer, unless an active one
+// has taken the phone number since.
+func (r repo) RestoreCustomer(ctx context.Context, id int64) error {
+	customer, err := r.GetCustomerByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if customer.ArchivedAt == nil {
+		return nil
+	}
+	if customer.Phone != "" {
+		_, err = r.GetCustomerByPhone(ctx, customer.Phone)
+		if err == nil {
+			return ErrReferenced
+		}
+		if err != sql.ErrNoRows {
+			return err
+		}
+	}
+	query := "UPDATE customers SET archived_at=NULL WHERE id=?"
+	_, err = r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// GetCustomerOrders lists a customer's orders, newest first.
+func (r repo) GetCustomerOrders(ctx context.Context, customerId int64,
+	limit, skip int) ([]model.Order, error) {
+
+	query := `
+	SELECT id, payment_type_id, cashier_id, total_price, total_discount, manual_discount,
+		total_tax, tip, total_paid, total_return, status, receipt_id, created_at
+	FROM orders
+	WHERE customer_id=?
+	ORDER BY id DESC`
+	args := []interface{}{customerId}
+	if limit > 0 {
+		query += " limit ? offset ?;"
+		args = append(args, limit, skip)
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := make([]model.Order, 0)
+	for rows.Next() {
+		order := model.Order{CustomerId: &customerId}
+		err := rows.Scan(
+			&order.OrderId,
+			&order.PaymentID,
+			&order.CashierID,
+			&order.TotalPrice,
+			&order.TotalDiscount,
+			&order.ManualDiscount,
+			&order.TotalTax,
+			&order.Tip,
+			&order.TotalPaid,
+			&order.TotalReturn,
+			&order.Status,
+			&order.ReceiptID,
+			&order.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	return orders, rows.Err()
+}
+
+// GetCustomerSpend counts a customer's paid orders, what they came to net
+// of change, and when the last one was placed.
+func (r repo) GetCustomerSpend(ctx context.Context,
+	customerId int64) (int, model.Money, *time.Time, error) {
+
+	var totalOrder int
+	var totalSpent model.Money
+	var lastOrderAt *time.Time
+	query := `
+	SELECT COUNT(id), COALESCE(SUM(total_paid - total_return), 0), MAX(created_at)
+	FROM orders
+	WHERE customer_id=? AND status = 'PAID'`
+	err := r.db.QueryRowContext(ctx, query, customerId).Scan(&totalOrder, &totalSpent, &lastOrderAt)
+	return totalOrder, totalSpent, lastOrderAt, err
+}
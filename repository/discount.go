@@ -0,0 +1,276 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/saptaka/pos/model"
+)
+
+type DiscountRepo interface {
+	GetDiscountByID(ctx context.Context, id int64) (model.Discount, error)
+	GetDiscounts(ctx context.Context, limit, skip int) ([]model.Discount, error)
+	CreateDiscount(ctx context.Context, discount model.Discount) (model.Discount, error)
+	UpdateDiscount(ctx context.Context, discount model.Discount) error
+	DeleteDiscount(ctx context.Context, id int64) error
+	AttachDiscount(ctx context.Context, productId, discountId int64) error
+	DetachDiscount(ctx context.Context, productId int64) error
+}
+
+func (r repo) GetDiscountByID(ctx context.Context, id int64) (model.Discount, error) {
+	var discount model.Discount
+	query := `SELECT
+			id,
+			qty,
+			types,
+			amount,
+			result,
+			started_at,
+			expired_at,
+			expired_at_format,
+			string_format,
+			updated_at,
+			created_at,
+			archived_at
+			FROM discounts
+			WHERE id=?
+			`
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&discount.DiscountID,
+		&discount.Qty,
+		&discount.Type,
+		&discount.Amount,
+		&discount.Percent,
+		&discount.StartedAt,
+		&discount.ExpiredAt,
+		&discount.ExpiredAtFormat,
+		&discount.StringFormat,
+		&discount.UpdatedAt,
+		&discount.CreatedAt,
+		&discount.ArchivedAt,
+	)
+	return discount, err
+}
+
+func (r repo) GetDiscounts(ctx context.Context, limit, skip int) ([]model.Discount, error) {
+	query := `SELECT
+			id,
+			qty,
+			types,
+			amount,
+			result,
+			started_at,
+			expired_at,
+			expired_at_format,
+			string_format,
+			updated_at,
+			created_at
+			FROM discounts
+			WHERE archived_at IS NULL
+			ORDER BY id ASC`
+	var rows *sql.Rows
+	var err error
+	if limit > 0 {
+		query += " limit ? offset ?;"
+		rows, err = r.db.QueryContext(ctx, query, limit, skip)
+	} else {
+		rows, err = r.db.QueryContext(ctx, query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	discounts := make([]model.Discount, 0)
+	for rows.Next() {
+		var discount model.Discount
+		err := rows.Scan(
+			&discount.DiscountID,
+			&discount.Qty,
+			&discount.Type,
+			&discount.Amount,
+			&discount.Percent,
+			&discount.StartedAt,
+			&discount.ExpiredAt,
+			&discount.ExpiredAtFormat,
+			&discount.StringFormat,
+			&discount.UpdatedAt,
+			&discount.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		discounts = append(discounts, discount)
+	}
+	return discounts, rows.Err()
+}
+
+func (r repo) CreateDiscount(ctx context.Context, discount model.Discount) (model.Discount, error) {
+	formatDiscount(&discount, 0)
+	query := `INSERT INTO
+	discounts (
+		qty,
+		types,
+		amount,
+		result,
+		started_at,
+		expired_at,
+		expired_at_format,
+		string_format)
+	VALUES (?,?,?,?,?,?,?,?);`
+
+	stmt, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		return discount, err
+	}
+
+	result, err := stmt.Exec(
+		discount.Qty,
+		discount.Type,
+		discount.Amount,
+		discount.Percent,
+		discount.StartedAt,
+		discount.ExpiredAt,
+		discount.ExpiredAtFormat,
+		discount.StringFormat,
+	)
+	if err != nil {
+		return discount, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return discount, err
+	}
+
+	return r.GetDiscountByID(ctx, id)
+}
+
+func (r repo) UpdateDiscount(ctx context.Context, discount model.Discount) error {
+	formatDiscount(&discount, 0)
+	query := `UPDATE discounts
+		SET qty=?,
+			types=?,
+			amount=?,
+			result=?,
+			started_at=?,
+			expired_at=?,
+			expired_at_format=?,
+			string_format=?,
+			updated_at=CURRENT_TIMESTAMP()
+		WHERE id=? AND archived_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query,
+		discount.Qty,
+		discount.Type,
+		discount.Amount,
+		discount.Percent,
+		discount.StartedAt,
+		discount.ExpiredAt,
+		discount.ExpiredAtFormat,
+		discount.StringFormat,
+		discount.DiscountID,
+	)
+	if err != nil {
+		return err
+	}
+	rowAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteDiscount archives the discount, keeping it resolvable from past
+// ordered products, and detaches it from every product.
+func (r repo) DeleteDiscount(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		"UPDATE discounts SET archived_at=CURRENT_TIMESTAMP() WHERE id=? AND archived_at IS NULL", id)
+	if err != nil {
+		return err
+	}
+	rowAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE products SET discount_id=NULL, updated_at=CURRENT_TIMESTAMP() WHERE discount_id=?", id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r repo) AttachDiscount(ctx context.Context, productId, discountId int64) error {
+	discount, err := r.GetDiscountByID(ctx, discountId)
+	if err != nil {
+		return err
+	}
+	if discount.ArchivedAt != nil {
+		return sql.ErrNoRows
+	}
+	query := `UPDATE products
+		SET discount_id=?, updated_at=CURRENT_TIMESTAMP()
+		WHERE id=? AND archived_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, discountId, productId)
+	if err != nil {
+		return err
+	}
+	rowAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r repo) DetachDiscount(ctx context.Context, productId int64) error {
+	_, err := r.GetProductByID(ctx, productId)
+	if err != nil {
+		return err
+	}
+	query := `UPDATE products
+		SET discount_id=NULL, updated_at=CURRENT_TIMESTAMP()
+		WHERE id=?`
+	_, err = r.db.ExecContext(ctx, query, productId)
+	return err
+}
+
+// formatDiscount regenerates the human readable discount descriptions.
+// Percentage discounts include the discounted price when the product price
+// is known.
+func formatDiscount(discount *model.Discount, price model.Money) {
+	if discount.Type == model.BuyN {
+		discount.StringFormat = fmt.Sprintf("Buy %d only %s",
+			discount.Qty, discount.Amount)
+	} else {
+		discountResult := fmt.Sprint(discount.Percent, "%")
+		if price > 0 {
+			discountPrice := price - price.Percent(float64(discount.Percent))
+			discount.StringFormat = fmt.Sprintf("Discount %s %s",
+				discountResult, discountPrice)
+		} else {
+			discount.StringFormat = fmt.Sprintf("Discount %s", discountResult)
+		}
+	}
+
+	discount.ExpiredAtFormat = ""
+	if discount.ExpiredAt != nil {
+		discount.ExpiredAtFormat = discount.ExpiredAt.Format("02 Jan 2006")
+	}
+}
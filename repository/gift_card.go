@@ -0,0 +1,342 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/saptaka/pos/model"
+)
+
+// ErrGiftCardUnavailable is returned when a card has expired or no longer
+// holds the amount taken from it by the time its order is stored.
+var ErrGiftCardUnavailable = errors.New("gift card is no longer available")
+
+type GiftCardRepo interface {
+	GetGiftCardByID(ctx context.Context, id int64) (model.GiftCard, error)
+	GetGiftCardByCode(ctx context.Context, code string) (model.GiftCard, error)
+	GetGiftCards(ctx context.Context, limit, skip int, customerId *int64) ([]model.GiftCard, error)
+	CreateGiftCard(ctx context.Context, card model.GiftCard) (model.GiftCard, error)
+	ReloadGiftCard(ctx context.Context, id int64, amount model.Money, now time.Time) error
+	GetGiftCardEntries(ctx context.Context, id int64, limit, skip int) ([]model.GiftCardEntry, error)
+	GetGiftCardLiability(ctx context.Context, now time.Time) (model.GiftCardLiability, error)
+}
+
+const giftCardColumns = "id, code, type, customer_id, order_id, balance, expires_at, updated_at, created_at"
+
+func scanGiftCard(row rowScanner) (model.GiftCard, error) {
+	var card model.GiftCard
+	err := row.Scan(
+		&card.GiftCardId,
+		&card.Code,
+		&card.Type,
+		&card.CustomerId,
+		&card.OrderId,
+		&card.Balance,
+		&card.ExpiresAt,
+		&card.UpdatedAt,
+		&card.CreatedAt,
+	)
+	return card, err
+}
+
+func (r repo) GetGiftCardByID(ctx context.Context, id int64) (model.GiftCard, error) {
+	query := "SELECT " + giftCardColumns + " FROM gift_cards WHERE id=?"
+	return scanGiftCard(r.db.QueryRowContext(ctx, query, id))
+}
+
+func (r repo) GetGiftCardByCode(ctx context.Context, code string) (model.GiftCard, error) {
+	query := "SELECT " + giftCardColumns + " FROM gift_cards WHERE code=?"
+	return scanGiftCard(r.db.QueryRowContext(ctx, query, code))
+}
+
+func (r repo) GetGiftCards(ctx context.Context, limit, skip int, customerId *int64) ([]model.GiftCard, error) {
+	query := "SELECT " + giftCardColumns + " FROM gift_cards"
+	var args []interface{}
+	if customerId != nil {
+		query += " WHERE customer_id=?"
+		args = append(args, *customerId)
+	}
+	query += " ORDER BY id DESC"
+	if limit > 0 {
+		query += " limit ? offset ?;"
+		args = append(args, limit, skip)
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cards := make([]model.GiftCard, 0)
+	for rows.Next() {
+		card, err := scanGiftCard(rows)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, card)
+	}
+	return cards, rows.Err()
+}
+
+// CreateGiftCard issues a card with its opening balance.
+func (r repo) CreateGiftCard(ctx context.Context, card model.GiftCard) (model.GiftCard, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return card, err
+	}
+	defer tx.Rollback()
+
+	id, err := insertGiftCard(ctx, tx, card)
+	if err != nil {
+		return card, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return card, err
+	}
+	return r.GetGiftCardByID(ctx, id)
+}
+
+// ReloadGiftCard adds amount to a card that has not expired by now; an
+// expired card is left as it is and ErrGiftCardUnavailable is returned.
+func (r repo) ReloadGiftCard(ctx context.Context, id int64, amount model.Money, now time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `UPDATE gift_cards
+		SET balance=balance+?, updated_at=CURRENT_TIMESTAMP()
+		WHERE id=? AND (expires_at IS NULL OR expires_at > ?)`, amount, id, now)
+	if err != nil {
+		return err
+	}
+	rowAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowAffected == 0 {
+		return ErrGiftCardUnavailable
+	}
+	err = giftCardEntry(ctx, tx, id, nil, model.GiftCardReload, amount)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r repo) GetGiftCardEntries(ctx context.Context, id int64, limit, skip int) ([]model.GiftCardEntry, error) {
+	query := `SELECT id, gift_card_id, order_id, type, amount, balance, created_at
+		FROM gift_card_ledger WHERE gift_card_id=? ORDER BY id DESC`
+	args := []interface{}{id}
+	if limit > 0 {
+		query += " limit ? offset ?;"
+		args = append(args, limit, skip)
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]model.GiftCardEntry, 0)
+	for rows.Next() {
+		var entry model.GiftCardEntry
+		err := rows.Scan(
+			&entry.GiftCardEntryId,
+			&entry.GiftCardId,
+			&entry.OrderId,
+			&entry.Type,
+			&entry.Amount,
+			&entry.Balance,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// GetGiftCardLiability totals the balances left on the cards by type, the
+// expired ones apart.
+func (r repo) GetGiftCardLiability(ctx context.Context, now time.Time) (model.GiftCardLiability, error) {
+	liability := model.GiftCardLiability{
+		Types:       make([]model.GiftCardLiabilityLine, 0),
+		GeneratedAt: now,
+	}
+	rows, err := r.db.QueryContext(ctx, `
+	SELECT type, COUNT(id), COALESCE(SUM(balance), 0)
+	FROM gift_cards
+	WHERE balance > 0 AND (expires_at IS NULL OR expires_at > ?)
+	GROUP BY type
+	ORDER BY type ASC`, now)
+	if err != nil {
+		return liability, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var line model.GiftCardLiabilityLine
+		err := rows.Scan(&line.Type, &line.TotalCard, &line.Balance)
+		if err != nil {
+			return liability, err
+		}
+		liability.Types = append(liability.Types, line)
+		liability.TotalCard += line.TotalCard
+		liability.Outstanding += line.Balance
+	}
+	if err := rows.Err(); err != nil {
+		return liability, err
+	}
+
+	err = r.db.QueryRowContext(ctx, `
+	SELECT COUNT(id), COALESCE(SUM(balance), 0)
+	FROM gift_cards
+	WHERE balance > 0 AND expires_at <= ?`, now).Scan(
+		&liability.Expired.TotalOrder, &liability.Expired.Amount)
+	return liability, err
+}
+
+// insertGiftCard stores a new card and the entry for its opening balance.
+func insertGiftCard(ctx context.Context, tx *sql.Tx, card model.GiftCard) (int64, error) {
+	result, err := tx.ExecContext(ctx, `INSERT INTO gift_cards
+		(code, type, customer_id, order_id, balance, expires_at)
+		VALUES (?,?,?,?,?,?)`,
+		card.Code, card.Type, card.CustomerId, card.OrderId, card.Balance, card.ExpiresAt)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return id, giftCardEntry(ctx, tx, id, card.OrderId, model.GiftCardIssue, card.Balance)
+}
+
+// giftCardEntry records a change of amount to a card along with the
+// balance it left.
+func giftCardEntry(ctx context.Context, tx *sql.Tx, id int64, orderId *int64,
+	entryType string, amount model.Money) error {
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO gift_card_ledger (gift_card_id, order_id, type, amount, balance)
+		SELECT id, ?, ?, ?, balance FROM gift_cards WHERE id=?`, orderId, entryType, amount, id)
+	return err
+}
+
+// redeemGiftCards takes an order's card tenders off the cards within its
+// transaction. A card that has expired or no longer holds the amount fails
+// the order with ErrGiftCardUnavailable.
+func redeemGiftCards(ctx context.Context, tx *sql.Tx, orderId int64,
+	tenders []model.GiftCardTender, now time.Time) error {
+
+	for _, tender := range tenders {
+		var id int64
+		err := tx.QueryRowContext(ctx, `SELECT id FROM gift_cards
+			WHERE code=? AND balance >= ? AND (expires_at IS NULL OR expires_at > ?)
+			FOR UPDATE`, tender.Code, tender.Amount, now).Scan(&id)
+		if err == sql.ErrNoRows {
+			return ErrGiftCardUnavailable
+		}
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE gift_cards
+			SET balance=balance-?, updated_at=CURRENT_TIMESTAMP() WHERE id=?`, tender.Amount, id)
+		if err != nil {
+			return err
+		}
+		err = giftCardEntry(ctx, tx, id, &orderId, model.GiftCardRedeem, -tender.Amount)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reverseGiftCards puts what an order still holds of its cards back on
+// them, whether or not they have expired since.
+func reverseGiftCards(ctx context.Context, tx *sql.Tx, orderId int64) error {
+	return moveGiftCardTenders(ctx, tx, orderId, `
+		SELECT gift_card_id, -SUM(amount) FROM gift_card_ledger
+		WHERE order_id=? AND type IN ('REDEEM', 'REVERSE')
+		GROUP BY gift_card_id HAVING -SUM(amount) > 0`,
+		func(id int64, amount model.Money) error {
+			_, err := tx.ExecContext(ctx, `UPDATE gift_cards
+				SET balance=balance+?, updated_at=CURRENT_TIMESTAMP() WHERE id=?`, amount, id)
+			if err != nil {
+				return err
+			}
+			return giftCardEntry(ctx, tx, id, &orderId, model.GiftCardReverse, amount)
+		})
+}
+
+// reclaimGiftCards takes what reverseGiftCards gave back off the cards
+// again once a payment for the order arrived after it failed. A card that
+// has expired or no longer holds the amount fails it with
+// ErrGiftCardUnavailable.
+func reclaimGiftCards(ctx context.Context, tx *sql.Tx, orderId int64, now time.Time) error {
+	return moveGiftCardTenders(ctx, tx, orderId, `
+		SELECT gift_card_id, SUM(amount) FROM gift_card_ledger
+		WHERE order_id=? AND type='REVERSE'
+		GROUP BY gift_card_id`,
+		func(id int64, amount model.Money) error {
+			err := tx.QueryRowContext(ctx, `SELECT id FROM gift_cards
+				WHERE id=? AND balance >= ? AND (expires_at IS NULL OR expires_at > ?)
+				FOR UPDATE`, id, amount, now).Scan(&id)
+			if err == sql.ErrNoRows {
+				return ErrGiftCardUnavailable
+			}
+			if err != nil {
+				return err
+			}
+			_, err = tx.ExecContext(ctx, `UPDATE gift_cards
+				SET balance=balance-?, updated_at=CURRENT_TIMESTAMP() WHERE id=?`, amount, id)
+			if err != nil {
+				return err
+			}
+			return giftCardEntry(ctx, tx, id, &orderId, model.GiftCardRedeem, -amount)
+		})
+}
+
+// moveGiftCardTenders runs move for each card and amount the query finds
+// for the order.
+func moveGiftCardTenders(ctx context.Context, tx *sql.Tx, orderId int64, query string,
+	move func(id int64, amount model.Money) error) error {
+
+	rows, err := tx.QueryContext(ctx, query, orderId)
+	if err != nil {
+		return err
+	}
+	type tender struct {
+		giftCardId int64
+		amount     model.Money
+	}
+	var tenders []tender
+	for rows.Next() {
+		var t tender
+		err := rows.Scan(&t.giftCardId, &t.amount)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		tenders = append(tenders, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, t := range tenders {
+		err := move(t.giftCardId, t.amount)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
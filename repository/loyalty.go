@@ -0,0 +1,357 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/saptaka/pos/model"
+)
+
+// ErrInsufficientPoints is returned when an order redeems more points than
+// the customer has by the time it is stored.
+var ErrInsufficientPoints = errors.New("not enough loyalty points")
+
+type LoyaltyRepo interface {
+	GetLoyaltyRuleByID(ctx context.Context, id int64) (model.LoyaltyRule, error)
+	GetLoyaltyRules(ctx context.Context) ([]model.LoyaltyRule, error)
+	CreateLoyaltyRule(ctx context.Context, rule model.LoyaltyRule) (model.LoyaltyRule, error)
+	UpdateLoyaltyRule(ctx context.Context, rule model.LoyaltyRule) error
+	DeleteLoyaltyRule(ctx context.Context, id int64) error
+	GetPointsBalance(ctx context.Context, customerId int64) (int64, error)
+	GetPointsEntries(ctx context.Context, customerId int64, limit, skip int) ([]model.PointsEntry, error)
+}
+
+const loyaltyRuleColumns = "id, category_id, spend, points, updated_at, created_at, archived_at"
+
+func scanLoyaltyRule(row rowScanner) (model.LoyaltyRule, error) {
+	var rule model.LoyaltyRule
+	err := row.Scan(
+		&rule.LoyaltyRuleId,
+		&rule.CategoryId,
+		&rule.Spend,
+		&rule.Points,
+		&rule.UpdatedAt,
+		&rule.CreatedAt,
+		&rule.ArchivedAt,
+	)
+	return rule, err
+}
+
+func (r repo) GetLoyaltyRuleByID(ctx context.Context, id int64) (model.LoyaltyRule, error) {
+	query := "SELECT " + loyaltyRuleColumns + " FROM loyalty_rules WHERE id=?"
+	return scanLoyaltyRule(r.db.QueryRowContext(ctx, query, id))
+}
+
+func (r repo) GetLoyaltyRules(ctx context.Context) ([]model.LoyaltyRule, error) {
+	query := "SELECT " + loyaltyRuleColumns + " FROM loyalty_rules WHERE archived_at IS NULL ORDER BY id ASC"
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := make([]model.LoyaltyRule, 0)
+	for rows.Next() {
+		rule, err := scanLoyaltyRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+func (r repo) CreateLoyaltyRule(ctx context.Context, rule model.LoyaltyRule) (model.LoyaltyRule, error) {
+	query := "INSERT INTO loyalty_rules (category_id, spend, points) VALUES (?,?,?)"
+	result, err := r.db.ExecContext(ctx, query, rule.CategoryId, rule.Spend, rule.Points)
+	if err != nil {
+		return rule, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return rule, err
+	}
+	return r.GetLoyaltyRuleByID(ctx, id)
+}
+
+func (r repo) UpdateLoyaltyRule(ctx context.Context, rule model.LoyaltyRule) error {
+	query := `UPDATE loyalty_rules
+		SET category_id=?, spend=?, points=?, updated_at=CURRENT_TIMESTAMP()
+		WHERE id=? AND archived_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, rule.CategoryId, rule.Spend, rule.Points, rule.LoyaltyRuleId)
+	if err != nil {
+		return err
+	}
+	rowAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r repo) DeleteLoyaltyRule(ctx context.Context, id int64) error {
+	query := "UPDATE loyalty_rules SET archived_at=CURRENT_TIMESTAMP() WHERE id=? AND archived_at IS NULL"
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	rowAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetPointsBalance expires the customer's lapsed points and answers with
+// what is left.
+func (r repo) GetPointsBalance(ctx context.Context, customerId int64) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	err = expirePoints(ctx, tx, customerId, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+	var balance int64
+	err = tx.QueryRowContext(ctx,
+		"SELECT COALESCE(SUM(points), 0) FROM loyalty_ledger WHERE customer_id=?", customerId).Scan(&balance)
+	if err != nil {
+		return 0, err
+	}
+	return balance, tx.Commit()
+}
+
+func (r repo) GetPointsEntries(ctx context.Context, customerId int64,
+	limit, skip int) ([]model.PointsEntry, error) {
+
+	query := `SELECT id, customer_id, order_id, type, points, remaining, expires_at, created_at
+		FROM loyalty_ledger WHERE customer_id=? ORDER BY id DESC`
+	args := []interface{}{customerId}
+	if limit > 0 {
+		query += " limit ? offset ?;"
+		args = append(args, limit, skip)
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]model.PointsEntry, 0)
+	for rows.Next() {
+		var entry model.PointsEntry
+		err := rows.Scan(
+			&entry.PointsEntryId,
+			&entry.CustomerId,
+			&entry.OrderId,
+			&entry.Type,
+			&entry.Points,
+			&entry.Remaining,
+			&entry.ExpiresAt,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// expirePoints writes off what is left of the customer's lots that expired
+// by now.
+func expirePoints(ctx context.Context, tx *sql.Tx, customerId int64, now time.Time) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO loyalty_ledger (customer_id, order_id, type, points, remaining, expires_at)
+		SELECT customer_id, order_id, 'EXPIRE', -remaining, 0, expires_at
+		FROM loyalty_ledger
+		WHERE customer_id=? AND remaining > 0 AND expires_at <= ?`, customerId, now)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE loyalty_ledger SET remaining=0
+		WHERE customer_id=? AND remaining > 0 AND expires_at <= ?`, customerId, now)
+	return err
+}
+
+// takePoints debits up to points from the customer's lots, those of the
+// order first and then those expiring soonest. It answers with how many
+// points it found and when the first lot it drew on expires.
+func takePoints(ctx context.Context, tx *sql.Tx, customerId int64, orderId int64,
+	points int64) (int64, *time.Time, error) {
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, remaining, expires_at FROM loyalty_ledger
+		WHERE customer_id=? AND remaining > 0
+		ORDER BY order_id <=> ? DESC, expires_at IS NULL, expires_at, id
+		FOR UPDATE`, customerId, orderId)
+	if err != nil {
+		return 0, nil, err
+	}
+	type lot struct {
+		id        int64
+		remaining int64
+		expiresAt *time.Time
+	}
+	var lots []lot
+	for rows.Next() {
+		var l lot
+		err := rows.Scan(&l.id, &l.remaining, &l.expiresAt)
+		if err != nil {
+			rows.Close()
+			return 0, nil, err
+		}
+		lots = append(lots, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, nil, err
+	}
+
+	var taken int64
+	var expiresAt *time.Time
+	for _, l := range lots {
+		if taken == points {
+			break
+		}
+		take := l.remaining
+		if take > points-taken {
+			take = points - taken
+		}
+		_, err := tx.ExecContext(ctx, "UPDATE loyalty_ledger SET remaining=remaining-? WHERE id=?", take, l.id)
+		if err != nil {
+			return 0, nil, err
+		}
+		if taken == 0 {
+			expiresAt = l.expiresAt
+		}
+		taken += take
+	}
+	return taken, expiresAt, nil
+}
+
+// applyPoints books an order's points: the redemption, which fails with
+// ErrInsufficientPoints when the customer no longer has them, and what the
+// order earned.
+func applyPoints(ctx context.Context, tx *sql.Tx, orderId int64, order model.Order) error {
+	if order.CustomerId == nil || (order.PointsRedeemed == 0 && order.PointsEarned == 0) {
+		return nil
+	}
+	customerId := *order.CustomerId
+	insert := `INSERT INTO loyalty_ledger (customer_id, order_id, type, points, remaining, expires_at)
+		VALUES (?,?,?,?,?,?)`
+	if order.PointsRedeemed > 0 {
+		err := expirePoints(ctx, tx, customerId, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+		taken, expiresAt, err := takePoints(ctx, tx, customerId, 0, order.PointsRedeemed)
+		if err != nil {
+			return err
+		}
+		// Points taken back from an order after they were spent leave the
+		// balance below what the lots hold.
+		var balance int64
+		err = tx.QueryRowContext(ctx,
+			"SELECT COALESCE(SUM(points), 0) FROM loyalty_ledger WHERE customer_id=?", customerId).Scan(&balance)
+		if err != nil {
+			return err
+		}
+		if taken < order.PointsRedeemed || balance < order.PointsRedeemed {
+			return ErrInsufficientPoints
+		}
+		_, err = tx.ExecContext(ctx, insert, customerId, orderId, model.PointsRedeem,
+			-order.PointsRedeemed, 0, expiresAt)
+		if err != nil {
+			return err
+		}
+	}
+	if order.PointsEarned > 0 {
+		_, err := tx.ExecContext(ctx, insert, customerId, orderId, model.PointsEarn,
+			order.PointsEarned, order.PointsEarned, order.PointsExpireAt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reversePoints undoes an order's points once its payment failed, was
+// voided or refunded: the points it earned are taken back, even when
+// already spent, and those it redeemed are given back to expire as the
+// points they were taken from. Only what is still booked for the order is
+// undone, so an order paid again after a failure reverses once more.
+func reversePoints(ctx context.Context, tx *sql.Tx, orderId int64) error {
+	var customerId *int64
+	var earned, redeemed int64
+	err := tx.QueryRowContext(ctx, `
+		SELECT MAX(customer_id),
+			COALESCE(SUM(IF(type IN ('EARN', 'REVERSE'), points, 0)), 0),
+			COALESCE(-SUM(IF(type IN ('REDEEM', 'RESTORE'), points, 0)), 0)
+		FROM loyalty_ledger WHERE order_id=?`, orderId).
+		Scan(&customerId, &earned, &redeemed)
+	if err != nil {
+		return err
+	}
+	if customerId == nil {
+		return nil
+	}
+	insert := `INSERT INTO loyalty_ledger (customer_id, order_id, type, points, remaining, expires_at)
+		VALUES (?,?,?,?,?,?)`
+	if earned > 0 {
+		_, _, err = takePoints(ctx, tx, *customerId, orderId, earned)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, insert, *customerId, orderId, model.PointsReverse, -earned, 0, nil)
+		if err != nil {
+			return err
+		}
+	}
+	if redeemed > 0 {
+		var expiresAt *time.Time
+		err = tx.QueryRowContext(ctx, `SELECT expires_at FROM loyalty_ledger
+			WHERE order_id=? AND type='REDEEM' ORDER BY id DESC LIMIT 1`, orderId).Scan(&expiresAt)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, insert, *customerId, orderId, model.PointsRestore,
+			redeemed, redeemed, expiresAt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reapplyPoints books an order's points again once a payment for it
+// arrived after reversePoints undid them. The points earned expire as they
+// were first meant to; a customer who no longer has the points redeemed
+// fails it with ErrInsufficientPoints.
+func reapplyPoints(ctx context.Context, tx *sql.Tx, orderId int64) error {
+	var order model.Order
+	err := tx.QueryRowContext(ctx,
+		"SELECT customer_id, points_earned, points_redeemed FROM orders WHERE id=?", orderId).
+		Scan(&order.CustomerId, &order.PointsEarned, &order.PointsRedeemed)
+	if err != nil || order.CustomerId == nil {
+		return err
+	}
+	err = tx.QueryRowContext(ctx, `SELECT expires_at FROM loyalty_ledger
+		WHERE order_id=? AND type='EARN' ORDER BY id ASC LIMIT 1`, orderId).Scan(&order.PointsExpireAt)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	return applyPoints(ctx, tx, orderId, order)
+}
@@ -6,19 +6,21 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/utils"
 )
 
 type OrderRepo interface {
 	GetOrder(ctx context.Context, limit, skip int) ([]model.Order, error)
 	GetOrderByID(ctx context.Context, id int64) (model.Order, error)
 	GetOrderByReceiptID(ctx context.Context, receiptId string) (model.Order, error)
-	CreateOrder(ctx context.Context,
-		orderRequest model.Order) (model.Order, error)
+	CreateOrder(ctx context.Context, details model.OrderDetails) (model.Order, error)
 	DownloadReceipt(ctx context.Context, id int64) (string, error)
 	GetDownloadStatus(ctx context.Context, id int64) (bool, error)
-	CreateOrderedProduct(ctx context.Context, id int64, orderRequest []model.OrderedProductDetail) error
+	UpdateOrderStatus(ctx context.Context, id int64, from []string, order model.Order) error
+	ReinstateOrder(ctx context.Context, id int64, order model.Order) error
 	GetOrderedProductByOrderId(ctx context.Context,
 		id int64) ([]model.OrderedProductDetail, error)
 }
@@ -38,6 +40,7 @@ func (r repo) GetOrder(ctx context.Context, limit, skip int) ([]model.Order, err
 			total_price,
 			total_paid,
 			total_return,
+			status,
 			receipt_id,
 			created_at,
 			updated_at 
@@ -67,6 +70,7 @@ func (r repo) GetOrder(ctx context.Context, limit, skip int) ([]model.Order, err
 				&order.TotalPrice,
 				&order.TotalPaid,
 				&order.TotalReturn,
+				&order.Status,
 				&order.ReceiptID,
 				&order.CreatedAt,
 				&order.UpdatedAt,
@@ -82,7 +86,7 @@ func (r repo) GetOrder(ctx context.Context, limit, skip int) ([]model.Order, err
 
 	cashierChan := make(chan []model.Cashier)
 	go func(cashierChanData chan []model.Cashier) {
-		cashiers, err := r.GetCashiers(ctx, 0, 0)
+		cashiers, err := r.getCashiers(ctx, 0, 0, true)
 		if err != nil {
 			cashierChan <- make([]model.Cashier, 0)
 			return
@@ -93,7 +97,7 @@ func (r repo) GetOrder(ctx context.Context, limit, skip int) ([]model.Order, err
 
 	paymentChan := make(chan []model.Payment)
 	go func(paymentChanData chan []model.Payment) {
-		payments, err := r.GetPayments(ctx, 0, 0)
+		payments, err := r.getPayments(ctx, 0, 0, true)
 		if err != nil {
 			paymentChanData <- make([]model.Payment, 0)
 			return
@@ -140,6 +144,28 @@ func (r repo) GetOrderByID(ctx context.Context, id int64) (model.Order, error) {
 		payment_type_id,
 		cashier_id,
 		total_price,
+		total_discount,
+		manual_discount,
+		total_tax,
+		tax_inclusive,
+		service_charge,
+		tip,
+		rounding,
+		tender_currency,
+		tender_amount,
+		exchange_rate,
+		status,
+		payment_provider,
+		payment_reference,
+		approval_code,
+		masked_pan,
+		terminal_id,
+		shift_id,
+		customer_id,
+		points_earned,
+		points_redeemed,
+		points_amount,
+		gift_card_amount,
 		total_paid,
 		total_return,
 		receipt_id,
@@ -151,11 +177,34 @@ func (r repo) GetOrderByID(ctx context.Context, id int64) (model.Order, error) {
 
 	rows := r.db.QueryRowContext(ctx, querySelect, id)
 	var order model.Order
+	var tender model.Tender
 	err := rows.Scan(
 		&order.OrderId,
 		&order.PaymentID,
 		&order.CashierID,
 		&order.TotalPrice,
+		&order.TotalDiscount,
+		&order.ManualDiscount,
+		&order.TotalTax,
+		&order.TaxInclusive,
+		&order.ServiceCharge,
+		&order.Tip,
+		&order.Rounding,
+		&tender.Currency,
+		&tender.Amount,
+		&tender.Rate,
+		&order.Status,
+		&order.PaymentProvider,
+		&order.PaymentReference,
+		&order.ApprovalCode,
+		&order.MaskedPAN,
+		&order.TerminalID,
+		&order.ShiftId,
+		&order.CustomerId,
+		&order.PointsEarned,
+		&order.PointsRedeemed,
+		&order.PointsAmount,
+		&order.GiftCardAmount,
 		&order.TotalPaid,
 		&order.TotalReturn,
 		&order.ReceiptID,
@@ -165,6 +214,9 @@ func (r repo) GetOrderByID(ctx context.Context, id int64) (model.Order, error) {
 	if err != nil {
 		return order, err
 	}
+	if tender.Currency != "" {
+		order.Tender = &tender
+	}
 
 	cashierChan := make(chan model.Cashier)
 	if order.CashierID != nil {
@@ -215,6 +267,28 @@ func (r repo) GetOrderByReceiptID(ctx context.Context, receiptId string) (model.
 		payment_type_id,
 		cashier_id,
 		total_price,
+		total_discount,
+		manual_discount,
+		total_tax,
+		tax_inclusive,
+		service_charge,
+		tip,
+		rounding,
+		tender_currency,
+		tender_amount,
+		exchange_rate,
+		status,
+		payment_provider,
+		payment_reference,
+		approval_code,
+		masked_pan,
+		terminal_id,
+		shift_id,
+		customer_id,
+		points_earned,
+		points_redeemed,
+		points_amount,
+		gift_card_amount,
 		total_paid,
 		total_return,
 		receipt_id,
@@ -225,11 +299,34 @@ func (r repo) GetOrderByReceiptID(ctx context.Context, receiptId string) (model.
 
 	rows := r.db.QueryRowContext(ctx, querySelect, receiptId)
 	var order model.Order
+	var tender model.Tender
 	err := rows.Scan(
 		&order.OrderId,
 		&order.PaymentID,
 		&order.CashierID,
 		&order.TotalPrice,
+		&order.TotalDiscount,
+		&order.ManualDiscount,
+		&order.TotalTax,
+		&order.TaxInclusive,
+		&order.ServiceCharge,
+		&order.Tip,
+		&order.Rounding,
+		&tender.Currency,
+		&tender.Amount,
+		&tender.Rate,
+		&order.Status,
+		&order.PaymentProvider,
+		&order.PaymentReference,
+		&order.ApprovalCode,
+		&order.MaskedPAN,
+		&order.TerminalID,
+		&order.ShiftId,
+		&order.CustomerId,
+		&order.PointsEarned,
+		&order.PointsRedeemed,
+		&order.PointsAmount,
+		&order.GiftCardAmount,
 		&order.TotalPaid,
 		&order.TotalReturn,
 		&order.ReceiptID,
@@ -238,6 +335,9 @@ func (r repo) GetOrderByReceiptID(ctx context.Context, receiptId string) (model.
 	if err != nil {
 		return order, err
 	}
+	if tender.Currency != "" {
+		order.Tender = &tender
+	}
 
 	cashierChan := make(chan model.Cashier)
 	if order.CashierID != nil {
@@ -279,17 +379,69 @@ func (r repo) GetOrderByReceiptID(ctx context.Context, receiptId string) (model.
 	return order, nil
 }
 
-func (r repo) CreateOrder(ctx context.Context, orderRequest model.Order) (model.Order, error) {
-
-	query := `INSERT INTO orders(payment_type_id, total_price, total_paid, total_return, created_at, receipt_id)
-			VALUES (?,?,?,?,?,?);`
-	stmt, err := r.db.PrepareContext(ctx, query)
+// CreateOrder stores the order together with its ordered products, applied
+// promotions, manual discounts and coupon redemptions and takes the
+// products ordered off stock in one transaction; when a coupon has
+// run out in the meantime nothing is stored and ErrCouponUnavailable is
+// returned, as is ErrGiftCardUnavailable for a gift card that no longer
+// covers its tender and ErrOutOfStock for a product sold out. The order
+// belongs to the shift its cashier has open, which stays open until the
+// order is stored; without one nothing is stored and ErrNoOpenShift is
+// returned.
+func (r repo) CreateOrder(ctx context.Context, details model.OrderDetails) (model.Order, error) {
+	orderRequest := details.Order
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return orderRequest, err
+	}
+	defer tx.Rollback()
+
+	var shiftId int64
+	err = tx.QueryRowContext(ctx,
+		"SELECT id FROM shifts WHERE status='OPEN' AND cashier_id=? LOCK IN SHARE MODE",
+		orderRequest.CashierID).Scan(&shiftId)
+	if err == sql.ErrNoRows {
+		return orderRequest, ErrNoOpenShift
+	}
 	if err != nil {
 		return orderRequest, err
 	}
-	res, err := stmt.Exec(
+	orderRequest.ShiftId = &shiftId
+
+	var tender model.Tender
+	if orderRequest.Tender != nil {
+		tender = *orderRequest.Tender
+	}
+	query := `INSERT INTO orders(payment_type_id, cashier_id, total_price, total_discount, manual_discount, total_tax, tax_inclusive,
+				service_charge, tip, rounding, tender_currency, tender_amount, exchange_rate,
+				status, payment_provider, payment_reference, shift_id, customer_id,
+				points_earned, points_redeemed, points_amount, gift_card_amount,
+				total_paid, total_return, created_at, receipt_id)
+			VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?);`
+	res, err := tx.ExecContext(ctx, query,
 		orderRequest.PaymentID,
+		orderRequest.CashierID,
 		orderRequest.TotalPrice,
+		orderRequest.TotalDiscount,
+		orderRequest.ManualDiscount,
+		orderRequest.TotalTax,
+		orderRequest.TaxInclusive,
+		orderRequest.ServiceCharge,
+		orderRequest.Tip,
+		orderRequest.Rounding,
+		tender.Currency,
+		tender.Amount,
+		tender.Rate,
+		orderRequest.Status,
+		orderRequest.PaymentProvider,
+		orderRequest.PaymentReference,
+		orderRequest.ShiftId,
+		orderRequest.CustomerId,
+		orderRequest.PointsEarned,
+		orderRequest.PointsRedeemed,
+		orderRequest.PointsAmount,
+		orderRequest.GiftCardAmount,
 		orderRequest.TotalPaid,
 		orderRequest.TotalReturn,
 		orderRequest.CreatedAt,
@@ -302,6 +454,40 @@ func (r repo) CreateOrder(ctx context.Context, orderRequest model.Order) (model.
 	if err != nil {
 		return orderRequest, err
 	}
+
+	err = createOrderedProducts(ctx, tx, id, details.OrderedProduct)
+	if err != nil {
+		return orderRequest, err
+	}
+	err = createOrderPromotions(ctx, tx, id, details.Promotions)
+	if err != nil {
+		return orderRequest, err
+	}
+	err = createOrderDiscounts(ctx, tx, id, details.Discounts)
+	if err != nil {
+		return orderRequest, err
+	}
+	err = takeStock(ctx, tx, details.OrderedProduct)
+	if err != nil {
+		return orderRequest, err
+	}
+	err = r.redeemCoupons(ctx, tx, id, details.Coupons)
+	if err != nil {
+		return orderRequest, err
+	}
+	err = applyPoints(ctx, tx, id, orderRequest)
+	if err != nil {
+		return orderRequest, err
+	}
+	err = redeemGiftCards(ctx, tx, id, orderRequest.GiftCards, time.Now().UTC())
+	if err != nil {
+		return orderRequest, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return orderRequest, err
+	}
+
 	orderRequest.OrderId = id
 	if orderRequest.CashierID == nil {
 		cashierId := int64(0)
@@ -311,6 +497,118 @@ func (r repo) CreateOrder(ctx context.Context, orderRequest model.Order) (model.
 	return orderRequest, nil
 }
 
+// UpdateOrderStatus moves an order in one of the states in from to the
+// status and payment details of order. It returns sql.ErrNoRows when the
+// order is not in any of those states, so concurrent confirmations of the
+// same payment apply once. A payment that failed or was voided gives back
+// the coupons the order redeemed, and one that failed, was voided or
+// refunded puts the products back on stock and undoes the order's loyalty
+// points and gift card tenders. A refund to store credit issues the
+// order's StoreCredit card.
+func (r repo) UpdateOrderStatus(ctx context.Context, id int64, from []string, order model.Order) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = setOrderStatus(ctx, tx, id, from, order)
+	if err != nil {
+		return err
+	}
+	if order.Status == model.OrderPaymentFailed || order.Status == model.OrderVoided {
+		err = releaseCoupons(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+	}
+	if order.Status == model.OrderPaymentFailed || order.Status == model.OrderVoided ||
+		order.Status == model.OrderRefunded {
+		err = restoreStock(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		err = reversePoints(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		err = reverseGiftCards(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+	}
+	if order.Status == model.OrderRefunded && order.StoreCredit != nil {
+		_, err = insertGiftCard(ctx, tx, *order.StoreCredit)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ReinstateOrder pays an order whose payment failed once the payment
+// arrives after all. What the failure gave back, the products' stock, the
+// coupon uses, the loyalty points and the gift card tenders, is taken again
+// in the same transaction; when any of it is gone by now nothing changes
+// and ErrOutOfStock, ErrCouponUnavailable, ErrInsufficientPoints or
+// ErrGiftCardUnavailable is returned. It returns sql.ErrNoRows when the
+// order is no longer failed.
+func (r repo) ReinstateOrder(ctx context.Context, id int64, order model.Order) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	order.Status = model.OrderPaid
+	err = setOrderStatus(ctx, tx, id, []string{model.OrderPaymentFailed}, order)
+	if err != nil {
+		return err
+	}
+	err = retakeStock(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	err = r.reclaimCoupons(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	err = reapplyPoints(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	err = reclaimGiftCards(ctx, tx, id, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// setOrderStatus moves an order in one of the states in from to the status
+// and payment details of order, or returns sql.ErrNoRows.
+func setOrderStatus(ctx context.Context, tx *sql.Tx, id int64, from []string, order model.Order) error {
+	query := `UPDATE orders SET status=?, payment_reference=?, approval_code=?, masked_pan=?, terminal_id=?,
+		updated_at=CURRENT_TIMESTAMP()
+		WHERE id=? AND status IN (?` + strings.Repeat(",?", len(from)-1) + ")"
+	args := []interface{}{order.Status, order.PaymentReference,
+		order.ApprovalCode, order.MaskedPAN, order.TerminalID, id}
+	for _, status := range from {
+		args = append(args, status)
+	}
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	rowAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
 func (r repo) DownloadReceipt(ctx context.Context, id int64) (string, error) {
 	order, err := r.GetOrderByID(ctx, id)
 	if err != nil {
@@ -352,19 +650,27 @@ func (r repo) GetDownloadStatus(ctx context.Context, id int64) (bool, error) {
 	return true, nil
 }
 
-func (r repo) CreateOrderedProduct(ctx context.Context, id int64, orderRequest []model.OrderedProductDetail) error {
+func createOrderedProducts(ctx context.Context, tx *sql.Tx, id int64,
+	orderRequest []model.OrderedProductDetail) error {
 	if len(orderRequest) == 0 {
-		return sql.ErrNoRows
+		return nil
 	}
 	query := `INSERT INTO ordered_products(
 		product_id,
 		order_id,
 		qty,
+		unit_product,
 		price_product,
 		name_product,
 		total_normal_price,
 		total_final_price,
-		discount_id)
+		cost_price,
+		total_cost_price,
+		discount_id,
+		tax_class_id,
+		tax_rate,
+		taxable_amount,
+		tax_amount)
 		VALUES %s;`
 	var values []interface{}
 	for _, item := range orderRequest {
@@ -372,27 +678,26 @@ func (r repo) CreateOrderedProduct(ctx context.Context, id int64, orderRequest [
 			item.ProductId,
 			id,
 			item.Qty,
+			item.Unit,
 			item.Price,
 			item.Name,
 			item.TotalNormalPrice,
 			item.TotalFinalPrice,
+			item.CostPrice,
+			item.TotalCostPrice,
 			item.DiscountId,
+			item.TaxClassId,
+			item.TaxRate,
+			item.TaxableAmount,
+			item.TaxAmount,
 		)
 	}
-	template := "(?,?,?,?,?,?,?,?)"
+	template := "(?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)"
 	if len(orderRequest) > 1 {
-		template += strings.Repeat(",(?,?,?,?,?,?,?,?)", len(orderRequest)-1)
-	}
-	query = fmt.Sprintf(query, template)
-	stmt, err := r.db.PrepareContext(ctx, query)
-	if err != nil {
-		return err
-	}
-	_, err = stmt.Exec(values...)
-	if err != nil {
-		return err
+		template += strings.Repeat(",(?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)", len(orderRequest)-1)
 	}
-	return nil
+	_, err := tx.ExecContext(ctx, fmt.Sprintf(query, template), values...)
+	return err
 }
 
 func (r repo) GetOrderedProductByOrderId(ctx context.Context,
@@ -401,11 +706,16 @@ func (r repo) GetOrderedProductByOrderId(ctx context.Context,
 	query := `
 	SELECT product_id,
 		qty,
+		unit_product,
 		total_normal_price,
 		total_final_price,
 		discount_id,
 		price_product,
-		name_product 
+		name_product,
+		tax_class_id,
+		tax_rate,
+		taxable_amount,
+		tax_amount
 	FROM ordered_products
 	WHERE order_id=?
 	`
@@ -418,11 +728,16 @@ func (r repo) GetOrderedProductByOrderId(ctx context.Context,
 		var orderedProduct model.OrderedProductDetail
 		err := rows.Scan(&orderedProduct.ProductId,
 			&orderedProduct.Qty,
+			&orderedProduct.Unit,
 			&orderedProduct.TotalNormalPrice,
 			&orderedProduct.TotalFinalPrice,
 			&orderedProduct.DiscountId,
 			&orderedProduct.Price,
 			&orderedProduct.Name,
+			&orderedProduct.TaxClassId,
+			&orderedProduct.TaxRate,
+			&orderedProduct.TaxableAmount,
+			&orderedProduct.TaxAmount,
 		)
 		if err != nil {
 			return nil, err
@@ -434,6 +749,8 @@ func (r repo) GetOrderedProductByOrderId(ctx context.Context,
 			}
 			orderedProduct.Discount = &discount
 		}
+		orderedProduct.QtyFormat = utils.FormatUnitPrice(orderedProduct.Qty,
+			orderedProduct.Unit, orderedProduct.Price)
 		orderedProducts = append(orderedProducts, orderedProduct)
 	}
 
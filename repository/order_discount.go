@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/saptaka/pos/model"
+)
+
+type OrderDiscountRepo interface {
+	GetOrderDiscounts(ctx context.Context, orderId int64) ([]model.OrderDiscount, error)
+	GetDiscountAudit(ctx context.Context, from, to *time.Time) ([]model.OrderDiscount, error)
+}
+
+func createOrderDiscounts(ctx context.Context, tx *sql.Tx, orderId int64,
+	discounts []model.OrderDiscount) error {
+	if len(discounts) == 0 {
+		return nil
+	}
+	query := `INSERT INTO order_discounts(
+		order_id,
+		product_id,
+		types,
+		value,
+		amount,
+		reason,
+		approved_by)
+		VALUES %s;`
+	var values []interface{}
+	for _, discount := range discounts {
+		values = append(values,
+			orderId,
+			discount.ProductId,
+			discount.Type,
+			discount.Percent,
+			discount.Amount,
+			discount.Reason,
+			discount.ApprovedBy,
+		)
+	}
+	template := "(?,?,?,?,?,?,?)" + strings.Repeat(",(?,?,?,?,?,?,?)", len(discounts)-1)
+	_, err := tx.ExecContext(ctx, fmt.Sprintf(query, template), values...)
+	return err
+}
+
+const orderDiscountQuery = `
+	SELECT order_discounts.id,
+		order_discounts.order_id,
+		orders.receipt_id,
+		order_discounts.product_id,
+		order_discounts.types,
+		order_discounts.value,
+		order_discounts.amount,
+		order_discounts.reason,
+		order_discounts.approved_by,
+		cashiers.name,
+		order_discounts.created_at
+	FROM order_discounts
+	LEFT JOIN orders ON orders.id = order_discounts.order_id
+	LEFT JOIN cashiers ON cashiers.id = order_discounts.approved_by
+	`
+
+func (r repo) GetOrderDiscounts(ctx context.Context, orderId int64) ([]model.OrderDiscount, error) {
+	query := orderDiscountQuery + `
+	WHERE order_discounts.order_id=?
+	ORDER BY order_discounts.id ASC`
+	return r.queryOrderDiscounts(ctx, query, orderId)
+}
+
+// GetDiscountAudit lists the manual discounts given between from and to,
+// either of which may be left open.
+func (r repo) GetDiscountAudit(ctx context.Context, from, to *time.Time) ([]model.OrderDiscount, error) {
+	query := orderDiscountQuery + " WHERE 1=1"
+	var args []interface{}
+	if from != nil {
+		query += " AND order_discounts.created_at >= ?"
+		args = append(args, *from)
+	}
+	if to != nil {
+		query += " AND order_discounts.created_at < ?"
+		args = append(args, *to)
+	}
+	query += " ORDER BY order_discounts.id ASC"
+	return r.queryOrderDiscounts(ctx, query, args...)
+}
+
+func (r repo) queryOrderDiscounts(ctx context.Context, query string,
+	args ...interface{}) ([]model.OrderDiscount, error) {
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	discounts := make([]model.OrderDiscount, 0)
+	for rows.Next() {
+		var discount model.OrderDiscount
+		var receiptId, approver sql.NullString
+		err := rows.Scan(
+			&discount.OrderDiscountId,
+			&discount.OrderId,
+			&receiptId,
+			&discount.ProductId,
+			&discount.Type,
+			&discount.Percent,
+			&discount.Amount,
+			&discount.Reason,
+			&discount.ApprovedBy,
+			&approver,
+			&discount.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		discount.ReceiptId = receiptId.String
+		discount.Approver = approver.String
+		discounts = append(discounts, discount)
+	}
+	return discounts, rows.Err()
+}
@@ -13,14 +13,17 @@ type PaymentRepo interface {
 	UpdatePayment(ctx context.Context, payment model.Payment) error
 	CreatePayment(ctx context.Context, payment model.Payment) (model.Payment, error)
 	DeletePayment(ctx context.Context, id int) error
+	UpdatePaymentLogo(ctx context.Context, id int64, logo, thumbnail string) error
+	RestorePayment(ctx context.Context, id int64) error
 }
 
 func (r repo) GetPaymentByID(ctx context.Context, id int64) (model.Payment, error) {
 	var payment model.Payment
-	query := "SELECT id, name, types,logo FROM payments WHERE id=?"
+	query := "SELECT id, name, types, logo, logo_thumbnail, rounding_unit, rounding_mode, provider, archived_at FROM payments WHERE id=?"
 	rows := r.db.QueryRowContext(ctx, query, id)
 	err := rows.Scan(&payment.PaymentId, &payment.Name,
-		&payment.Type, &payment.Logo)
+		&payment.Type, &payment.Logo, &payment.LogoThumbnail,
+		&payment.RoundingUnit, &payment.RoundingMode, &payment.Provider, &payment.ArchivedAt)
 
 	if err != nil {
 		return payment, err
@@ -31,7 +34,15 @@ func (r repo) GetPaymentByID(ctx context.Context, id int64) (model.Payment, erro
 
 func (r repo) GetPayments(ctx context.Context,
 	limit, skip int) ([]model.Payment, error) {
-	query := "SELECT id, name, types, logo FROM payments "
+	return r.getPayments(ctx, limit, skip, false)
+}
+
+func (r repo) getPayments(ctx context.Context,
+	limit, skip int, includeArchived bool) ([]model.Payment, error) {
+	query := "SELECT id, name, types, logo, logo_thumbnail, rounding_unit, rounding_mode, provider, archived_at FROM payments "
+	if !includeArchived {
+		query += " WHERE archived_at IS NULL "
+	}
 	var rows *sql.Rows
 	var err error
 	if limit > 0 {
@@ -53,7 +64,12 @@ func (r repo) GetPayments(ctx context.Context,
 		err := rows.Scan(&payment.PaymentId,
 			&payment.Name,
 			&payment.Type,
-			&payment.Logo)
+			&payment.Logo,
+			&payment.LogoThumbnail,
+			&payment.RoundingUnit,
+			&payment.RoundingMode,
+			&payment.Provider,
+			&payment.ArchivedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -64,9 +80,10 @@ func (r repo) GetPayments(ctx context.Context,
 
 func (r repo) UpdatePayment(ctx context.Context,
 	payment model.Payment) error {
-	query := "UPDATE payments SET name=?, types=?, logo=? ,updated_at=CURRENT_TIMESTAMP() WHERE id=?"
+	query := `UPDATE payments SET name=?, types=?, logo=?, rounding_unit=?, rounding_mode=?,
+		provider=?, updated_at=CURRENT_TIMESTAMP() WHERE id=?`
 	result, err := r.db.ExecContext(ctx, query, payment.Name, payment.Type, payment.Logo,
-		payment.PaymentId)
+		payment.RoundingUnit, payment.RoundingMode, payment.Provider, payment.PaymentId)
 	if err != nil {
 		return err
 	}
@@ -83,13 +100,14 @@ func (r repo) UpdatePayment(ctx context.Context,
 func (r repo) CreatePayment(ctx context.Context, payment model.Payment) (model.Payment, error) {
 	var paymentRequest model.Payment
 	insertQuery := `INSERT INTO 
-		payments (name, types, logo) 
-	VALUES (?,?,?);`
+		payments (name, types, logo, rounding_unit, rounding_mode, provider) 
+	VALUES (?,?,?,?,?,?);`
 	stmt, err := r.db.PrepareContext(ctx, insertQuery)
 	if err != nil {
 		return paymentRequest, err
 	}
-	res, err := stmt.Exec(payment.Name, payment.Type, payment.Logo)
+	res, err := stmt.Exec(payment.Name, payment.Type, payment.Logo,
+		payment.RoundingUnit, payment.RoundingMode, payment.Provider)
 	if err != nil {
 		return paymentRequest, err
 	}
@@ -101,6 +119,9 @@ func (r repo) CreatePayment(ctx context.Context, payment model.Payment) (model.P
 					name,
 					types,
 					logo, 
+					rounding_unit,
+					rounding_mode,
+					provider,
 					updated_at, 
 					created_at
 					FROM payments 
@@ -112,14 +133,53 @@ func (r repo) CreatePayment(ctx context.Context, payment model.Payment) (model.P
 		&paymentRequest.Name,
 		&paymentRequest.Type,
 		&paymentRequest.Logo,
+		&paymentRequest.RoundingUnit,
+		&paymentRequest.RoundingMode,
+		&paymentRequest.Provider,
 		&paymentRequest.UpdatedAt,
 		&paymentRequest.CreatedAt)
 	return paymentRequest, err
 
 }
 
+// DeletePayment archives the payment type, unless orders paid with it are
+// still waiting for their payment.
 func (r repo) DeletePayment(ctx context.Context, id int) error {
-	query := "DELETE FROM payments WHERE id=?"
-	_, err := r.db.ExecContext(ctx, query, id)
+	payment, err := r.GetPaymentByID(ctx, int64(id))
+	if err != nil {
+		return err
+	}
+	if payment.ArchivedAt != nil {
+		return nil
+	}
+
+	var pendingOrders int
+	countQuery := "SELECT COUNT(*) FROM orders WHERE payment_type_id=? AND status=?"
+	err = r.db.QueryRowContext(ctx, countQuery, id, model.OrderPendingPayment).Scan(&pendingOrders)
+	if err != nil {
+		return err
+	}
+	if pendingOrders > 0 {
+		return ErrReferenced
+	}
+
+	query := "UPDATE payments SET archived_at=CURRENT_TIMESTAMP() WHERE id=? AND archived_at IS NULL"
+	_, err = r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+func (r repo) RestorePayment(ctx context.Context, id int64) error {
+	_, err := r.GetPaymentByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	query := "UPDATE payments SET archived_at=NULL WHERE id=?"
+	_, err = r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+func (r repo) UpdatePaymentLogo(ctx context.Context, id int64, logo, thumbnail string) error {
+	query := "UPDATE payments SET logo=?, logo_thumbnail=?, updated_at=CURRENT_TIMESTAMP() WHERE id=?"
+	_, err := r.db.ExecContext(ctx, query, logo, thumbnail, id)
 	return err
 }
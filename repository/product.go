@@ -3,22 +3,32 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"strings"
 	"time"
 
 	"github.com/saptaka/pos/model"
-	"github.com/saptaka/pos/utils"
 )
 
+// ErrOutOfStock is returned when a product ordered sold out by the time its
+// order is stored.
+var ErrOutOfStock = errors.New("product is out of stock")
+
 type ProductRepo interface {
 	GetProductByID(ctx context.Context, id int64) (model.Product, error)
 	GetProducts(ctx context.Context, limit, skip int, product model.Product) ([]model.Product, error)
 	UpdateProduct(ctx context.Context, product model.Product) error
 	CreateProduct(ctx context.Context, product model.ProductCreateRequest) (model.Product, error)
+	UpdateProductImage(ctx context.Context, id int64, image, thumbnail string) error
+	CreateGoodsReceipt(ctx context.Context, receipt model.GoodsReceipt) (model.GoodsReceipt, error)
 	DeleteProduct(ctx context.Context, id int64) error
+	RestoreProduct(ctx context.Context, id int64) error
 	GetProductsByIds(ctx context.Context, ids []int64) ([]model.Product, error)
+	GetProductsBySKU(ctx context.Context, skus []string) (map[string]model.Product, error)
+	ImportProducts(ctx context.Context, imports []model.ProductImport) (int, int, error)
 }
 
 func (r repo) GetProductByID(ctx context.Context, id int64) (model.Product, error) {
@@ -29,10 +39,16 @@ func (r repo) GetProductByID(ctx context.Context, id int64) (model.Product, erro
 				name,
 				stock,
 				price,
+				cost_price,
+				unit,
+				barcode,
 				image,
+				thumbnail,
 				category_id,
+				tax_class_id,
 				sku,
-				discount_id
+				discount_id,
+				archived_at
 			FROM products 
 			WHERE id=?`
 	row := r.db.QueryRowContext(ctx, query, id)
@@ -41,10 +57,16 @@ func (r repo) GetProductByID(ctx context.Context, id int64) (model.Product, erro
 		&product.Name,
 		&product.Stock,
 		&product.Price,
+		&product.CostPrice,
+		&product.Unit,
+		&product.Barcode,
 		&product.Image,
+		&product.Thumbnail,
 		&product.CategoryId,
+		&product.TaxClassId,
 		&product.SKU,
 		&product.DiscountId,
+		&product.ArchivedAt,
 	)
 	if err != nil {
 		return product, err
@@ -57,18 +79,7 @@ func (r repo) GetProductByID(ctx context.Context, id int64) (model.Product, erro
 			log.Println("error get product ", err)
 			return product, err
 		}
-
-		if discount.Type == model.BuyN {
-			discount.StringFormat = fmt.Sprintf("Buy %d only Rp. %s",
-				discount.Qty, utils.FormatCommas(discount.Result))
-		} else {
-			discountResult := fmt.Sprint(discount.Result, "%")
-			discountPrice := product.Price - (product.Price * discount.Result / 100)
-
-			discount.StringFormat = fmt.Sprintf("Discount %s Rp. %s",
-				discountResult, utils.FormatCommas(discountPrice))
-		}
-
+		formatDiscount(&discount, product.Price)
 		discountById = &discount
 	}
 
@@ -79,14 +90,6 @@ func (r repo) GetProductByID(ctx context.Context, id int64) (model.Product, erro
 		if err != nil {
 			return product, err
 		}
-		if product.DiscountId != nil {
-			discount, err := r.GetDiscountByID(ctx, *product.DiscountId)
-			if err != nil {
-				return product, err
-			}
-			product.Discount = &discount
-		}
-
 		product.Category = &category
 	}
 
@@ -103,20 +106,25 @@ func (r repo) GetProducts(ctx context.Context,
 				name,
 				stock,
 				price,
+				cost_price,
+				unit,
+				barcode,
 				image,
+				thumbnail,
 				category_id ,
+				tax_class_id,
 				sku,
 				discount_id
 			FROM products 
 			%s 
 			`
-		var withQuery string
+		withQuery := " WHERE archived_at IS NULL"
 		values := make([]interface{}, 0)
 		if product.Name != "" {
-			withQuery = " WHERE name LIKE CONCAT('%',?,'%')"
+			withQuery += " AND name LIKE CONCAT('%',?,'%')"
 			values = append(values, product.Name)
 		} else if product.CategoryId != nil {
-			withQuery = " WHERE category_id=?"
+			withQuery += " AND category_id=?"
 			values = append(values, *product.CategoryId)
 		}
 		querySelect = fmt.Sprintf(querySelect, withQuery)
@@ -152,8 +160,13 @@ func (r repo) GetProducts(ctx context.Context,
 				&product.Name,
 				&product.Stock,
 				&product.Price,
+				&product.CostPrice,
+				&product.Unit,
+				&product.Barcode,
 				&product.Image,
+				&product.Thumbnail,
 				&product.CategoryId,
+				&product.TaxClassId,
 				&product.SKU,
 				&product.DiscountId,
 			)
@@ -169,16 +182,7 @@ func (r repo) GetProducts(ctx context.Context,
 					return
 				}
 
-				if discount.Type == model.BuyN {
-					discount.StringFormat = fmt.Sprintf("Buy %d only Rp. %s",
-						discount.Qty, utils.FormatCommas(discount.Result))
-				} else {
-					discountResult := fmt.Sprint(discount.Result, "%")
-					discountPrice := product.Price - (product.Price * discount.Result / 100)
-					discount.StringFormat = fmt.Sprintf("Discount %s Rp. %s",
-						discountResult, utils.FormatCommas(discountPrice))
-				}
-
+				formatDiscount(&discount, product.Price)
 				product.Discount = &discount
 			}
 
@@ -190,7 +194,7 @@ func (r repo) GetProducts(ctx context.Context,
 
 	categoryChan := make(chan []model.Category)
 	go func(categoryChanData chan []model.Category) {
-		categories, err := r.GetCategories(ctx, 0, 0)
+		categories, err := r.getCategories(ctx, 0, 0, true)
 		if err != nil {
 			log.Println("error get categories ", err)
 			categoryChanData <- make([]model.Category, 0)
@@ -242,6 +246,18 @@ func (r repo) UpdateProduct(ctx context.Context,
 		query += " price=?,"
 		values = append(values, Product.Price)
 	}
+	if Product.CostPrice != 0 {
+		query += " cost_price=?,"
+		values = append(values, Product.CostPrice)
+	}
+	if Product.Unit != "" {
+		query += " unit=?,"
+		values = append(values, Product.Unit)
+	}
+	if Product.Barcode != "" {
+		query += " barcode=?,"
+		values = append(values, Product.Barcode)
+	}
 	if Product.CategoryId != nil && *Product.CategoryId != 0 {
 		query += " category_id=?,"
 		values = append(values, Product.CategoryId)
@@ -266,10 +282,19 @@ func (r repo) CreateProduct(ctx context.Context, product model.ProductCreateRequ
 
 	var productDetail model.Product
 
+	var discountId *int64
+	if product.Discount != nil {
+		discount, err := r.CreateDiscount(ctx, *product.Discount)
+		if err != nil {
+			return productDetail, err
+		}
+		discountId = &discount.DiscountID
+	}
+
 	insertQuery := `INSERT INTO 
-		products (name,image, price, stock, category_id,
-			 updated_at, created_at) 
-	VALUES (?,?,?,?,?,?,?);`
+		products (name,image, price, stock, unit, barcode, category_id,
+			 discount_id, updated_at, created_at) 
+	VALUES (?,?,?,?,?,?,?,?,?,?);`
 
 	stmt, err := r.db.PrepareContext(ctx, insertQuery)
 	if err != nil {
@@ -283,7 +308,10 @@ func (r repo) CreateProduct(ctx context.Context, product model.ProductCreateRequ
 		product.Image,
 		product.Price,
 		product.Stock,
+		product.Unit,
+		product.Barcode,
 		product.CategoryId,
+		discountId,
 		now,
 		now,
 	)
@@ -296,53 +324,232 @@ func (r repo) CreateProduct(ctx context.Context, product model.ProductCreateRequ
 		return productDetail, err
 	}
 
-	go func(repoInside repo, id int64, discount *model.Discount) {
-		var discountId *int64
-		if discount != nil {
-			discountIDResult, err := repoInside.CreateDiscount(ctx, *discount)
-			if err != nil {
-				log.Println(err)
-				return
-			}
-			discountId = &discountIDResult
+	updateQuery := `UPDATE products SET sku=? WHERE id=?`
+	_, err = r.db.ExecContext(ctx, updateQuery, fmt.Sprintf("ID%03d", id), id)
+	if err != nil {
+		return productDetail, err
+	}
+
+	productDetail = model.Product{
+
+		ProductId:  id,
+		Name:       product.Name,
+		Stock:      product.Stock,
+		SKU:        fmt.Sprintf("ID%03d", id),
+		Price:      product.Price,
+		Unit:       product.Unit,
+		Barcode:    product.Barcode,
+		Image:      product.Image,
+		UpdatedAt:  &now,
+		CreatedAt:  &now,
+		DiscountId: discountId,
+
+		CategoryId: product.CategoryId,
+	}
+
+	return productDetail, err
+
+}
+
+func (r repo) UpdateProductImage(ctx context.Context, id int64, image, thumbnail string) error {
+	query := `UPDATE products 
+		SET image=?, thumbnail=?, updated_at=CURRENT_TIMESTAMP() 
+		WHERE id=?`
+	_, err := r.db.ExecContext(ctx, query, image, thumbnail, id)
+	return err
+}
+
+// takeStock takes the quantities ordered off the products' stock in place,
+// so it neither overwrites a goods receipt booked meanwhile nor touches the
+// cost price. A product sold out in the meantime fails with ErrOutOfStock.
+func takeStock(ctx context.Context, tx *sql.Tx, products []model.OrderedProductDetail) error {
+	query := `UPDATE products 
+		SET stock=stock-?, updated_at=CURRENT_TIMESTAMP() 
+		WHERE id=? AND stock>=?`
+	for _, product := range products {
+		res, err := tx.ExecContext(ctx, query, product.Qty, product.ProductId, product.Qty)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrOutOfStock
 		}
+	}
+	return nil
+}
 
-		updateQuery := `UPDATE products 
-				SET discount_id=?, sku=?
-				WHERE id=?`
+// restoreStock puts the quantities an order took back on the products'
+// stock and books each as a returned stock movement.
+func restoreStock(ctx context.Context, tx *sql.Tx, orderId int64) error {
+	products, err := orderedStock(ctx, tx, orderId)
+	if err != nil {
+		return err
+	}
+	for _, product := range products {
+		_, err := tx.ExecContext(ctx, `UPDATE products
+			SET stock=stock+?, updated_at=CURRENT_TIMESTAMP() WHERE id=?`, product.Qty, product.ProductId)
+		if err != nil {
+			return err
+		}
+		err = stockMovement(ctx, tx, product, orderId, model.StockReturned)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-		_, err = repoInside.db.ExecContext(ctx, updateQuery, discountId, fmt.Sprintf("ID%03d", id), id)
+// retakeStock takes the quantities of an order off the products' stock
+// again after restoreStock gave them back, and books each as a reinstated
+// stock movement. A product sold out meanwhile fails with ErrOutOfStock.
+func retakeStock(ctx context.Context, tx *sql.Tx, orderId int64) error {
+	products, err := orderedStock(ctx, tx, orderId)
+	if err != nil {
+		return err
+	}
+	for _, product := range products {
+		res, err := tx.ExecContext(ctx, `UPDATE products
+			SET stock=stock-?, updated_at=CURRENT_TIMESTAMP() WHERE id=? AND stock>=?`,
+			product.Qty, product.ProductId, product.Qty)
 		if err != nil {
-			log.Println(err)
-			return
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrOutOfStock
+		}
+		err = stockMovement(ctx, tx, product, orderId, model.StockReinstated)
+		if err != nil {
+			return err
 		}
-	}(r, id, product.Discount)
+	}
+	return nil
+}
 
-	productDetail = model.Product{
+// orderedStock lists the quantity of each product an order took.
+func orderedStock(ctx context.Context, tx *sql.Tx, orderId int64) ([]model.OrderedProduct, error) {
+	rows, err := tx.QueryContext(ctx,
+		"SELECT product_id, qty FROM ordered_products WHERE order_id=?", orderId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-		ProductId: id,
-		Name:      product.Name,
-		Stock:     product.Stock,
-		SKU:       fmt.Sprintf("ID%03d", id),
-		Price:     product.Price,
-		Image:     product.Image,
-		UpdatedAt: &now,
-		CreatedAt: &now,
+	var products []model.OrderedProduct
+	for rows.Next() {
+		var product model.OrderedProduct
+		err := rows.Scan(&product.ProductId, &product.Qty)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+	return products, rows.Err()
+}
 
-		CategoryId: product.CategoryId,
+func stockMovement(ctx context.Context, tx *sql.Tx, product model.OrderedProduct,
+	orderId int64, movementType string) error {
+	_, err := tx.ExecContext(ctx, `INSERT INTO stock_movements (product_id, order_id, type, qty)
+		VALUES (?,?,?,?)`, product.ProductId, orderId, movementType, product.Qty)
+	return err
+}
+
+// CreateGoodsReceipt books received stock and recalculates the product's
+// cost price as the weighted average of the stock on hand and the receipt.
+func (r repo) CreateGoodsReceipt(ctx context.Context,
+	receipt model.GoodsReceipt) (model.GoodsReceipt, error) {
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return receipt, err
 	}
+	defer tx.Rollback()
 
-	return productDetail, err
+	receipt, err = bookGoodsReceipt(ctx, tx, receipt)
+	if err != nil {
+		return receipt, err
+	}
+	return receipt, tx.Commit()
+}
 
+// bookGoodsReceipt does the work of CreateGoodsReceipt within a transaction.
+func bookGoodsReceipt(ctx context.Context, tx *sql.Tx,
+	receipt model.GoodsReceipt) (model.GoodsReceipt, error) {
+
+	var stock float64
+	var costPrice model.Money
+	query := "SELECT IFNULL(stock, 0), cost_price FROM products WHERE id=? FOR UPDATE"
+	err := tx.QueryRowContext(ctx, query, receipt.ProductId).Scan(&stock, &costPrice)
+	if err != nil {
+		return receipt, err
+	}
+
+	if stock < 0 {
+		stock = 0
+	}
+	newStock := stock + receipt.Qty
+	receipt.CostPrice = model.Money(math.Round((stock*float64(costPrice) +
+		receipt.Qty*float64(receipt.UnitCost)) / newStock))
+	receipt.Stock = newStock
+
+	updateQuery := `UPDATE products 
+		SET stock=?, cost_price=?, updated_at=CURRENT_TIMESTAMP() 
+		WHERE id=?`
+	_, err = tx.ExecContext(ctx, updateQuery, receipt.Stock, receipt.CostPrice,
+		receipt.ProductId)
+	if err != nil {
+		return receipt, err
+	}
+
+	now, _ := time.Parse(model.RFC3339MilliZ, time.Now().UTC().Format(model.RFC3339MilliZ))
+	insertQuery := `INSERT INTO 
+		goods_receipts (product_id, qty, unit_cost, created_at) 
+	VALUES (?,?,?,?);`
+	result, err := tx.ExecContext(ctx, insertQuery, receipt.ProductId, receipt.Qty,
+		receipt.UnitCost, now)
+	if err != nil {
+		return receipt, err
+	}
+	receipt.GoodsReceiptId, err = result.LastInsertId()
+	if err != nil {
+		return receipt, err
+	}
+	receipt.CreatedAt = &now
+	return receipt, nil
 }
 
+// DeleteProduct archives the product; archiving it again does nothing, as
+// for categories.
 func (r repo) DeleteProduct(ctx context.Context, id int64) error {
-	query := "DELETE FROM products WHERE id=?"
-	_, err := r.db.ExecContext(ctx, query, id)
+	product, err := r.GetProductByID(ctx, id)
 	if err != nil {
 		return err
 	}
+	if product.ArchivedAt != nil {
+		return nil
+	}
+	query := "UPDATE products SET archived_at=CURRENT_TIMESTAMP() WHERE id=? AND archived_at IS NULL"
+	_, err = r.db.ExecContext(ctx, query, id)
+	return err
+}
 
+func (r repo) RestoreProduct(ctx context.Context, id int64) error {
+	product, err := r.GetProductByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if product.Category != nil && product.Category.ArchivedAt != nil {
+		return ErrReferenced
+	}
+	query := "UPDATE products SET archived_at=NULL WHERE id=?"
+	_, err = r.db.ExecContext(ctx, query, id)
 	return err
 }
 
@@ -354,6 +561,7 @@ func (r repo) GetProductsByIds(ctx context.Context, ids []int64) ([]model.Produc
 				id,
 				name,
 				price,
+				unit,
 				discount_id  
 			FROM products 
 			WHERE id IN (%s) ORDER BY id ASC`
@@ -379,6 +587,7 @@ func (r repo) GetProductsByIds(ctx context.Context, ids []int64) ([]model.Produc
 			&product.ProductId,
 			&product.Name,
 			&product.Price,
+			&product.Unit,
 			&product.DiscountId,
 		)
 		if err != nil {
@@ -397,70 +606,3 @@ func (r repo) GetProductsByIds(ctx context.Context, ids []int64) ([]model.Produc
 
 	return products, nil
 }
-
-func (r repo) CreateDiscount(ctx context.Context, discount model.Discount) (int64, error) {
-
-	var unixExpiredAt int64
-	if discount.ExpiratedAt != nil {
-		unixExpiredAt = int64(discount.ExpiratedAt.(float64))
-	}
-
-	expiredAt := time.Unix(unixExpiredAt, 0)
-	expiredAtFormat := expiredAt.Format("02 Jan 2006")
-	query := `INSERT INTO 
-	discounts (
-		qty,
-		types,
-		result, 
-		expired_at,
-		expired_at_format) 
-	VALUES (?,?,?,?,?);`
-
-	stmt, err := r.db.PrepareContext(ctx, query)
-	if err != nil {
-		return 0, err
-	}
-
-	result, err := stmt.Exec(
-		discount.Qty,
-		discount.Type,
-		discount.Result,
-		expiredAt,
-		expiredAtFormat,
-	)
-	if err != nil {
-		return 0, err
-	}
-
-	id, err := result.LastInsertId()
-	if err != nil {
-		return id, err
-	}
-
-	return id, err
-}
-
-func (r repo) GetDiscountByID(ctx context.Context, id int64) (model.Discount, error) {
-	var discount model.Discount
-	query := `SELECT 
-			id,
-			qty,
-			types,
-			result,
-			expired_at,
-			expired_at_format,
-			string_format
-			FROM discounts  
-			WHERE id=?
-			`
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&discount.DiscountID,
-		&discount.Qty,
-		&discount.Type,
-		&discount.Result,
-		&discount.ExpiratedAt,
-		&discount.ExpiredAtFormat,
-		&discount.StringFormat,
-	)
-	return discount, err
-}
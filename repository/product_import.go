@@ -0,0 +1,252 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/saptaka/pos/model"
+)
+
+// GetProductsBySKU looks up the products holding the given SKUs, archived
+// ones included, with only their id, SKU and archive time filled in.
+func (r repo) GetProductsBySKU(ctx context.Context, skus []string) (map[string]model.Product, error) {
+	products := make(map[string]model.Product)
+	if len(skus) == 0 {
+		return products, nil
+	}
+	querySelect := `SELECT id, sku, archived_at FROM products WHERE sku IN (%s)`
+	values := make([]interface{}, 0, len(skus))
+	for _, sku := range skus {
+		values = append(values, sku)
+	}
+	template := "?" + strings.Repeat(",?", len(skus)-1)
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(querySelect, template), values...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var product model.Product
+		if err := rows.Scan(&product.ProductId, &product.SKU, &product.ArchivedAt); err != nil {
+			return nil, err
+		}
+		products[product.SKU] = product
+	}
+	return products, rows.Err()
+}
+
+// ImportProducts upserts the imported rows by SKU in a single transaction.
+// Categories referenced by name are created when they do not exist yet, and
+// an existing product keeps what it has stored for columns the file lacks.
+// Rows for an archived product are skipped.
+func (r repo) ImportProducts(ctx context.Context,
+	imports []model.ProductImport) (int, int, error) {
+
+	var created, updated int
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return created, updated, err
+	}
+	defer tx.Rollback()
+
+	categoryIds := make(map[string]int64)
+	rows, err := tx.QueryContext(ctx, "SELECT id, name FROM categories")
+	if err != nil {
+		return created, updated, err
+	}
+	for rows.Next() {
+		var id int64
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			rows.Close()
+			return created, updated, err
+		}
+		categoryIds[strings.ToLower(name)] = id
+	}
+	rows.Close()
+
+	now, _ := time.Parse(model.RFC3339MilliZ, time.Now().UTC().Format(model.RFC3339MilliZ))
+	for _, item := range imports {
+		product := item.Product
+
+		if item.CategoryName != "" {
+			categoryId, ok := categoryIds[strings.ToLower(item.CategoryName)]
+			if !ok {
+				result, err := tx.ExecContext(ctx,
+					"INSERT INTO categories (name) VALUES (?)", item.CategoryName)
+				if err != nil {
+					return created, updated, err
+				}
+				categoryId, err = result.LastInsertId()
+				if err != nil {
+					return created, updated, err
+				}
+				categoryIds[strings.ToLower(item.CategoryName)] = categoryId
+			}
+			product.CategoryId = &categoryId
+		}
+
+		var productId int64
+		var currentDiscountId *int64
+		var currentStock float64
+		var archivedAt *time.Time
+		if product.SKU != "" {
+			err := tx.QueryRowContext(ctx,
+				"SELECT id, discount_id, IFNULL(stock, 0), archived_at FROM products WHERE sku=? LIMIT 1 FOR UPDATE",
+				product.SKU).Scan(&productId, &currentDiscountId, &currentStock, &archivedAt)
+			if err != nil && err != sql.ErrNoRows {
+				return created, updated, err
+			}
+		}
+		if archivedAt != nil {
+			continue
+		}
+
+		discountId, err := r.importDiscount(ctx, tx, currentDiscountId, product.Discount)
+		if err != nil {
+			return created, updated, err
+		}
+
+		if productId != 0 {
+			// Only the columns in the file are updated, and stock only
+			// through a goods receipt or a correction of the difference.
+			updateQuery := "UPDATE products SET name=?, price=?,"
+			values := []interface{}{product.Name, product.Price}
+			if item.Columns["unit"] {
+				updateQuery += " unit=?,"
+				values = append(values, product.Unit)
+			}
+			if item.Columns["barcode"] {
+				updateQuery += " barcode=?,"
+				values = append(values, product.Barcode)
+			}
+			if item.Columns["category"] {
+				updateQuery += " category_id=?,"
+				values = append(values, product.CategoryId)
+			}
+			if item.Columns["discount_type"] {
+				updateQuery += " discount_id=?,"
+				values = append(values, discountId)
+			}
+			updateQuery += " updated_at=CURRENT_TIMESTAMP() WHERE id=?"
+			values = append(values, productId)
+			_, err = tx.ExecContext(ctx, updateQuery, values...)
+			if err != nil {
+				return created, updated, err
+			}
+			if item.Columns["stock"] {
+				err = importStock(ctx, tx, productId, currentStock, product.Stock, item.UnitCost)
+				if err != nil {
+					return created, updated, err
+				}
+			}
+			updated++
+			continue
+		}
+
+		insertQuery := `INSERT INTO
+			products (name, sku, price, stock, unit, barcode, category_id,
+				discount_id, updated_at, created_at)
+		VALUES (?,?,?,0,?,?,?,?,?,?);`
+		result, err := tx.ExecContext(ctx, insertQuery, product.Name, product.SKU,
+			product.Price, product.Unit, product.Barcode,
+			product.CategoryId, discountId, now, now)
+		if err != nil {
+			return created, updated, err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return created, updated, err
+		}
+		if product.SKU == "" {
+			_, err = tx.ExecContext(ctx, "UPDATE products SET sku=? WHERE id=?",
+				fmt.Sprintf("ID%03d", id), id)
+			if err != nil {
+				return created, updated, err
+			}
+		}
+		err = importStock(ctx, tx, id, 0, product.Stock, item.UnitCost)
+		if err != nil {
+			return created, updated, err
+		}
+		created++
+	}
+
+	return created, updated, tx.Commit()
+}
+
+// importStock brings a product's stock to the imported count. Stock added
+// is booked as a goods receipt at the imported unit cost, or at the cost
+// price when the file has none, so the cost price stays a weighted
+// average; stock taken away leaves the cost price as it is.
+func importStock(ctx context.Context, tx *sql.Tx, productId int64,
+	current, stock float64, unitCost model.Money) error {
+
+	onHand := current
+	if onHand < 0 {
+		onHand = 0
+	}
+	switch {
+	case stock > onHand:
+		if unitCost == 0 {
+			err := tx.QueryRowContext(ctx, "SELECT cost_price FROM products WHERE id=?",
+				productId).Scan(&unitCost)
+			if err != nil {
+				return err
+			}
+		}
+		_, err := bookGoodsReceipt(ctx, tx, model.GoodsReceipt{
+			ProductId: productId,
+			Qty:       stock - onHand,
+			UnitCost:  unitCost,
+		})
+		return err
+	case stock != current:
+		_, err := tx.ExecContext(ctx,
+			"UPDATE products SET stock=?, updated_at=CURRENT_TIMESTAMP() WHERE id=?",
+			stock, productId)
+		return err
+	}
+	return nil
+}
+
+// importDiscount keeps the current discount when the imported one is
+// identical, so re-importing an export does not pile up discount rows.
+func (r repo) importDiscount(ctx context.Context, tx *sql.Tx,
+	currentId *int64, discount *model.Discount) (*int64, error) {
+
+	if discount == nil {
+		return nil, nil
+	}
+	if currentId != nil {
+		var current model.Discount
+		err := tx.QueryRowContext(ctx,
+			"SELECT qty, types, amount, result FROM discounts WHERE id=?",
+			*currentId).Scan(&current.Qty, &current.Type, &current.Amount, &current.Percent)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		if err == nil && current.Qty == discount.Qty && current.Type == discount.Type &&
+			current.Amount == discount.Amount && current.Percent == discount.Percent {
+			return currentId, nil
+		}
+	}
+
+	formatDiscount(discount, 0)
+	result, err := tx.ExecContext(ctx,
+		"INSERT INTO discounts (qty, types, amount, result, expired_at_format, string_format) VALUES (?,?,?,?,?,?)",
+		discount.Qty, discount.Type, discount.Amount, discount.Percent,
+		discount.ExpiredAtFormat, discount.StringFormat)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
@@ -0,0 +1,297 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/saptaka/pos/model"
+)
+
+type PromotionRepo interface {
+	GetPromotionByID(ctx context.Context, id int64) (model.Promotion, error)
+	GetPromotions(ctx context.Context, limit, skip int) ([]model.Promotion, error)
+	CreatePromotion(ctx context.Context, promotion model.Promotion) (model.Promotion, error)
+	UpdatePromotion(ctx context.Context, promotion model.Promotion) error
+	DeletePromotion(ctx context.Context, id int64) error
+	GetOrderPromotions(ctx context.Context, orderId int64) ([]model.AppliedPromotion, error)
+}
+
+const promotionColumns = `
+			id,
+			name,
+			types,
+			priority,
+			is_stackable,
+			is_exclusive,
+			coupon_only,
+			product_id,
+			category_id,
+			get_product_id,
+			buy_qty,
+			get_qty,
+			min_spend,
+			value_type,
+			amount,
+			value,
+			tiers,
+			windows,
+			started_at,
+			expired_at,
+			updated_at,
+			created_at,
+			archived_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPromotion(row rowScanner) (model.Promotion, error) {
+	var promotion model.Promotion
+	var tiers string
+	var windows sql.NullString
+	err := row.Scan(
+		&promotion.PromotionId,
+		&promotion.Name,
+		&promotion.Type,
+		&promotion.Priority,
+		&promotion.Stackable,
+		&promotion.Exclusive,
+		&promotion.CouponOnly,
+		&promotion.ProductId,
+		&promotion.CategoryId,
+		&promotion.GetProductId,
+		&promotion.BuyQty,
+		&promotion.GetQty,
+		&promotion.MinSpend,
+		&promotion.ValueType,
+		&promotion.Amount,
+		&promotion.Percent,
+		&tiers,
+		&windows,
+		&promotion.StartedAt,
+		&promotion.ExpiredAt,
+		&promotion.UpdatedAt,
+		&promotion.CreatedAt,
+		&promotion.ArchivedAt,
+	)
+	if err != nil {
+		return promotion, err
+	}
+	if tiers != "" {
+		err = json.Unmarshal([]byte(tiers), &promotion.Tiers)
+		if err != nil {
+			return promotion, err
+		}
+	}
+	if windows.String != "" {
+		err = json.Unmarshal([]byte(windows.String), &promotion.Windows)
+	}
+	return promotion, err
+}
+
+func (r repo) GetPromotionByID(ctx context.Context, id int64) (model.Promotion, error) {
+	query := fmt.Sprintf("SELECT %s FROM promotions WHERE id=?", promotionColumns)
+	return scanPromotion(r.db.QueryRowContext(ctx, query, id))
+}
+
+func (r repo) GetPromotions(ctx context.Context, limit, skip int) ([]model.Promotion, error) {
+	query := fmt.Sprintf(`SELECT %s FROM promotions
+		WHERE archived_at IS NULL
+		ORDER BY priority DESC, id ASC`, promotionColumns)
+	var rows *sql.Rows
+	var err error
+	if limit > 0 {
+		query += " limit ? offset ?;"
+		rows, err = r.db.QueryContext(ctx, query, limit, skip)
+	} else {
+		rows, err = r.db.QueryContext(ctx, query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	promotions := make([]model.Promotion, 0)
+	for rows.Next() {
+		promotion, err := scanPromotion(rows)
+		if err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, promotion)
+	}
+	return promotions, rows.Err()
+}
+
+func (r repo) CreatePromotion(ctx context.Context, promotion model.Promotion) (model.Promotion, error) {
+	tiers, err := json.Marshal(promotion.Tiers)
+	if err != nil {
+		return promotion, err
+	}
+	windows, err := json.Marshal(promotion.Windows)
+	if err != nil {
+		return promotion, err
+	}
+	query := `INSERT INTO promotions (
+			name, types, priority, is_stackable, is_exclusive, coupon_only,
+			product_id, category_id, get_product_id, buy_qty, get_qty,
+			min_spend, value_type, amount, value, tiers, windows, started_at, expired_at)
+		VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?);`
+	result, err := r.db.ExecContext(ctx, query,
+		promotion.Name,
+		promotion.Type,
+		promotion.Priority,
+		promotion.Stackable,
+		promotion.Exclusive,
+		promotion.CouponOnly,
+		promotion.ProductId,
+		promotion.CategoryId,
+		promotion.GetProductId,
+		promotion.BuyQty,
+		promotion.GetQty,
+		promotion.MinSpend,
+		promotion.ValueType,
+		promotion.Amount,
+		promotion.Percent,
+		string(tiers),
+		string(windows),
+		promotion.StartedAt,
+		promotion.ExpiredAt,
+	)
+	if err != nil {
+		return promotion, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return promotion, err
+	}
+	return r.GetPromotionByID(ctx, id)
+}
+
+func (r repo) UpdatePromotion(ctx context.Context, promotion model.Promotion) error {
+	tiers, err := json.Marshal(promotion.Tiers)
+	if err != nil {
+		return err
+	}
+	windows, err := json.Marshal(promotion.Windows)
+	if err != nil {
+		return err
+	}
+	query := `UPDATE promotions
+		SET name=?, types=?, priority=?, is_stackable=?, is_exclusive=?, coupon_only=?,
+			product_id=?, category_id=?, get_product_id=?, buy_qty=?, get_qty=?,
+			min_spend=?, value_type=?, amount=?, value=?, tiers=?, windows=?, started_at=?, expired_at=?,
+			updated_at=CURRENT_TIMESTAMP()
+		WHERE id=? AND archived_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query,
+		promotion.Name,
+		promotion.Type,
+		promotion.Priority,
+		promotion.Stackable,
+		promotion.Exclusive,
+		promotion.CouponOnly,
+		promotion.ProductId,
+		promotion.CategoryId,
+		promotion.GetProductId,
+		promotion.BuyQty,
+		promotion.GetQty,
+		promotion.MinSpend,
+		promotion.ValueType,
+		promotion.Amount,
+		promotion.Percent,
+		string(tiers),
+		string(windows),
+		promotion.StartedAt,
+		promotion.ExpiredAt,
+		promotion.PromotionId,
+	)
+	if err != nil {
+		return err
+	}
+	rowAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r repo) DeletePromotion(ctx context.Context, id int64) error {
+	query := "UPDATE promotions SET archived_at=CURRENT_TIMESTAMP() WHERE id=? AND archived_at IS NULL"
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	rowAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func createOrderPromotions(ctx context.Context, tx *sql.Tx, orderId int64,
+	applied []model.AppliedPromotion) error {
+	if len(applied) == 0 {
+		return nil
+	}
+	query := `INSERT INTO order_promotions(
+		order_id,
+		promotion_id,
+		name,
+		product_id,
+		amount)
+		VALUES %s;`
+	var values []interface{}
+	for _, item := range applied {
+		values = append(values,
+			orderId,
+			item.PromotionId,
+			item.Name,
+			item.ProductId,
+			item.Amount,
+		)
+	}
+	template := "(?,?,?,?,?)" + strings.Repeat(",(?,?,?,?,?)", len(applied)-1)
+	_, err := tx.ExecContext(ctx, fmt.Sprintf(query, template), values...)
+	return err
+}
+
+func (r repo) GetOrderPromotions(ctx context.Context, orderId int64) ([]model.AppliedPromotion, error) {
+	query := `
+	SELECT promotion_id,
+		name,
+		product_id,
+		amount
+	FROM order_promotions
+	WHERE order_id=?
+	ORDER BY id ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query, orderId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make([]model.AppliedPromotion, 0)
+	for rows.Next() {
+		var item model.AppliedPromotion
+		err := rows.Scan(
+			&item.PromotionId,
+			&item.Name,
+			&item.ProductId,
+			&item.Amount,
+		)
+		if err != nil {
+			return nil, err
+		}
+		applied = append(applied, item)
+	}
+	return applied, rows.Err()
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/saptaka/pos/model"
+)
+
+type QRISRepo interface {
+	CreateQRISCode(ctx context.Context, code model.QRISCode) (model.QRISCode, error)
+	GetQRISCode(ctx context.Context, reference string) (model.QRISCode, error)
+	GetQRISCodeByOrder(ctx context.Context, orderReference string) (model.QRISCode, error)
+	UpdateQRISCodeStatus(ctx context.Context, reference, from, to string) error
+}
+
+const qrisCodeColumns = "id, reference, order_reference, amount, payload, status, expires_at, paid_at, created_at"
+
+func scanQRISCode(row rowScanner) (model.QRISCode, error) {
+	var code model.QRISCode
+	err := row.Scan(
+		&code.QRISCodeId,
+		&code.Reference,
+		&code.OrderReference,
+		&code.Amount,
+		&code.Payload,
+		&code.Status,
+		&code.ExpiresAt,
+		&code.PaidAt,
+		&code.CreatedAt,
+	)
+	return code, err
+}
+
+func (r repo) CreateQRISCode(ctx context.Context, code model.QRISCode) (model.QRISCode, error) {
+	query := `INSERT INTO qris_codes (reference, order_reference, amount, payload, status, expires_at)
+		VALUES (?,?,?,?,?,?)`
+	result, err := r.db.ExecContext(ctx, query, code.Reference, code.OrderReference,
+		code.Amount, code.Payload, code.Status, code.ExpiresAt)
+	if err != nil {
+		return code, err
+	}
+	code.QRISCodeId, err = result.LastInsertId()
+	return code, err
+}
+
+func (r repo) GetQRISCode(ctx context.Context, reference string) (model.QRISCode, error) {
+	query := "SELECT " + qrisCodeColumns + " FROM qris_codes WHERE reference=?"
+	return scanQRISCode(r.db.QueryRowContext(ctx, query, reference))
+}
+
+// GetQRISCodeByOrder is the latest code issued for an order.
+func (r repo) GetQRISCodeByOrder(ctx context.Context, orderReference string) (model.QRISCode, error) {
+	query := "SELECT " + qrisCodeColumns + " FROM qris_codes WHERE order_reference=? ORDER BY id DESC LIMIT 1"
+	return scanQRISCode(r.db.QueryRowContext(ctx, query, orderReference))
+}
+
+// UpdateQRISCodeStatus moves a code in state from to state to, stamping
+// the time it was paid. It returns sql.ErrNoRows when the code is not in
+// state from.
+func (r repo) UpdateQRISCodeStatus(ctx context.Context, reference, from, to string) error {
+	query := `UPDATE qris_codes
+		SET status=?, paid_at=IF(?='PAID', CURRENT_TIMESTAMP(), paid_at), updated_at=CURRENT_TIMESTAMP()
+		WHERE reference=? AND status=?`
+	result, err := r.db.ExecContext(ctx, query, to, to, reference, from)
+	if err != nil {
+		return err
+	}
+	rowAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/saptaka/pos/model"
+)
+
+type ReceiptRepo interface {
+	GetReceiptMessageByID(ctx context.Context, id int64) (model.ReceiptMessage, error)
+	GetReceiptMessages(ctx context.Context, limit, skip int, orderId int64, status string) ([]model.ReceiptMessage, error)
+	GetDueReceiptMessages(ctx context.Context, now time.Time, limit int) ([]model.ReceiptMessage, error)
+	CreateReceiptMessages(ctx context.Context, messages []model.ReceiptMessage) ([]model.ReceiptMessage, error)
+	UpdateReceiptMessage(ctx context.Context, message model.ReceiptMessage) error
+	RetryReceiptMessage(ctx context.Context, id int64, now time.Time) error
+}
+
+const receiptMessageColumns = `id, order_id, channel, recipient, subject, body, status, attempts,
+	last_error, next_attempt_at, sent_at, updated_at, created_at`
+
+func scanReceiptMessage(row rowScanner) (model.ReceiptMessage, error) {
+	var message model.ReceiptMessage
+	err := row.Scan(
+		&message.ReceiptMessageId,
+		&message.OrderId,
+		&message.Channel,
+		&message.Recipient,
+		&message.Subject,
+		&message.Body,
+		&message.Status,
+		&message.Attempts,
+		&message.LastError,
+		&message.NextAttemptAt,
+		&message.SentAt,
+		&message.UpdatedAt,
+		&message.CreatedAt,
+	)
+	return message, err
+}
+
+func (r repo) GetReceiptMessageByID(ctx context.Context, id int64) (model.ReceiptMessage, error) {
+	query := "SELECT " + receiptMessageColumns + " FROM receipt_outbox WHERE id=?"
+	return scanReceiptMessage(r.db.QueryRowContext(ctx, query, id))
+}
+
+// GetReceiptMessages lists the outbox newest first, narrowed to an order
+// and a status when they are given.
+func (r repo) GetReceiptMessages(ctx context.Context, limit, skip int,
+	orderId int64, status string) ([]model.ReceiptMessage, error) {
+
+	query := "SELECT " + receiptMessageColumns + " FROM receipt_outbox WHERE 1=1"
+	var args []interface{}
+	if orderId != 0 {
+		query += " AND order_id=?"
+		args = append(args, orderId)
+	}
+	if status != "" {
+		query += " AND status=?"
+		args = append(args, status)
+	}
+	query += " ORDER BY id DESC"
+	if limit > 0 {
+		query += " limit ? offset ?;"
+		args = append(args, limit, skip)
+	}
+	return r.queryReceiptMessages(ctx, query, args...)
+}
+
+// GetDueReceiptMessages lists the pending messages whose next attempt is
+// due by now, those waiting longest first.
+func (r repo) GetDueReceiptMessages(ctx context.Context, now time.Time, limit int) ([]model.ReceiptMessage, error) {
+	query := "SELECT " + receiptMessageColumns + ` FROM receipt_outbox
+		WHERE status='PENDING' AND next_attempt_at <= ?
+		ORDER BY next_attempt_at ASC, id ASC limit ?`
+	return r.queryReceiptMessages(ctx, query, now, limit)
+}
+
+func (r repo) queryReceiptMessages(ctx context.Context, query string,
+	args ...interface{}) ([]model.ReceiptMessage, error) {
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := make([]model.ReceiptMessage, 0)
+	for rows.Next() {
+		message, err := scanReceiptMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+	return messages, rows.Err()
+}
+
+// CreateReceiptMessages queues messages together, each due at its
+// NextAttemptAt.
+func (r repo) CreateReceiptMessages(ctx context.Context,
+	messages []model.ReceiptMessage) ([]model.ReceiptMessage, error) {
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ids := make([]int64, 0, len(messages))
+	for _, message := range messages {
+		result, err := tx.ExecContext(ctx, `INSERT INTO receipt_outbox
+			(order_id, channel, recipient, subject, body, status, next_attempt_at)
+			VALUES (?,?,?,?,?,?,?)`,
+			message.OrderId, message.Channel, message.Recipient, message.Subject,
+			message.Body, model.ReceiptPending, message.NextAttemptAt)
+		if err != nil {
+			return nil, err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	created := make([]model.ReceiptMessage, 0, len(ids))
+	for _, id := range ids {
+		message, err := r.GetReceiptMessageByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		created = append(created, message)
+	}
+	return created, nil
+}
+
+// UpdateReceiptMessage records how an attempt to send a pending message
+// went. It returns sql.ErrNoRows when the message is no longer pending.
+func (r repo) UpdateReceiptMessage(ctx context.Context, message model.ReceiptMessage) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE receipt_outbox
+		SET status=?, attempts=?, last_error=?, next_attempt_at=?, sent_at=?,
+			updated_at=CURRENT_TIMESTAMP()
+		WHERE id=? AND status='PENDING'`,
+		message.Status, message.Attempts, message.LastError, message.NextAttemptAt,
+		message.SentAt, message.ReceiptMessageId)
+	if err != nil {
+		return err
+	}
+	rowAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// RetryReceiptMessage queues a failed message again with a fresh set of
+// attempts. It returns sql.ErrNoRows when the message has not failed.
+func (r repo) RetryReceiptMessage(ctx context.Context, id int64, now time.Time) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE receipt_outbox
+		SET status='PENDING', attempts=0, next_attempt_at=?, updated_at=CURRENT_TIMESTAMP()
+		WHERE id=? AND status='FAILED'`, now, id)
+	if err != nil {
+		return err
+	}
+	rowAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
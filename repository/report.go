@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"math"
 
 	"github.com/saptaka/pos/model"
 )
@@ -9,6 +10,8 @@ import (
 type ReportRepo interface {
 	GetRevenues(ctx context.Context) (model.Revenue, error)
 	GetSolds(ctx context.Context) (model.Solds, error)
+	GetMargins(ctx context.Context, groupBy string) (model.Margins, error)
+	GetInventoryValuation(ctx context.Context) (model.InventoryValuation, error)
 }
 
 func (r repo) GetRevenues(ctx context.Context) (model.Revenue, error) {
@@ -18,12 +21,14 @@ func (r repo) GetRevenues(ctx context.Context) (model.Revenue, error) {
 		payments.logo,
 		payments.name,
 		payments.types,
-		orders.total_paid
+		orders.total_paid,
+		orders.rounding
 	FROM
 		payments
 		JOIN orders
 	WHERE
 		payments.id = orders.payment_type_id
+		AND orders.status = 'PAID'
 	`
 	var revenue model.Revenue
 	rows, err := r.db.QueryContext(ctx, query)
@@ -31,7 +36,7 @@ func (r repo) GetRevenues(ctx context.Context) (model.Revenue, error) {
 		return revenue, err
 	}
 
-	var totalRevenue int
+	var totalRevenue model.Money
 	for rows.Next() {
 		var payment model.PaymentTypeItem
 		err := rows.Scan(
@@ -40,11 +45,13 @@ func (r repo) GetRevenues(ctx context.Context) (model.Revenue, error) {
 			&payment.Name,
 			&payment.Type,
 			&payment.TotalAmount,
+			&payment.TotalRounding,
 		)
 		if err != nil {
 			return revenue, nil
 		}
 		totalRevenue += payment.TotalAmount
+		revenue.TotalRounding += payment.TotalRounding
 		revenue.PaymentType = append(revenue.PaymentType, payment)
 	}
 	revenue.TotalRevenue = totalRevenue
@@ -60,6 +67,7 @@ func (r repo) GetSolds(ctx context.Context) (model.Solds, error) {
 		SUM(total_normal_price) as totalAmount
 	FROM
 		ordered_products
+		JOIN orders ON orders.id = ordered_products.order_id AND orders.status = 'PAID'
 		JOIN products ON ordered_products.product_id = products.id
 		GROUP BY ordered_products.product_id
 	`
@@ -84,3 +92,112 @@ func (r repo) GetSolds(ctx context.Context) (model.Solds, error) {
 	}
 	return sold, nil
 }
+
+func (r repo) GetMargins(ctx context.Context, groupBy string) (model.Margins, error) {
+	query := `
+		SELECT
+		products.id,
+		products.name,
+		SUM(ordered_products.qty) as totalQty,
+		SUM(ordered_products.total_final_price - IF(orders.tax_inclusive, ordered_products.tax_amount, 0)) as totalRevenue,
+		SUM(ordered_products.total_cost_price) as totalCost
+	FROM
+		ordered_products
+		JOIN orders ON orders.id = ordered_products.order_id AND orders.status = 'PAID'
+		JOIN products ON ordered_products.product_id = products.id
+		GROUP BY products.id, products.name
+	`
+	if groupBy == model.MarginByCategory {
+		query = `
+		SELECT
+		IFNULL(categories.id, 0),
+		IFNULL(categories.name, ''),
+		SUM(ordered_products.qty) as totalQty,
+		SUM(ordered_products.total_final_price - IF(orders.tax_inclusive, ordered_products.tax_amount, 0)) as totalRevenue,
+		SUM(ordered_products.total_cost_price) as totalCost
+	FROM
+		ordered_products
+		JOIN orders ON orders.id = ordered_products.order_id AND orders.status = 'PAID'
+		JOIN products ON ordered_products.product_id = products.id
+		LEFT JOIN categories ON products.category_id = categories.id
+		GROUP BY categories.id, categories.name
+	`
+	}
+
+	var margins model.Margins
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return margins, err
+	}
+	defer rows.Close()
+
+	margins.Items = make([]model.MarginItem, 0)
+	for rows.Next() {
+		var item model.MarginItem
+		err := rows.Scan(
+			&item.Id,
+			&item.Name,
+			&item.TotalQty,
+			&item.TotalRevenue,
+			&item.TotalCost,
+		)
+		if err != nil {
+			return margins, err
+		}
+		item.TotalMargin = item.TotalRevenue - item.TotalCost
+		item.MarginPercent = marginPercent(item.TotalMargin, item.TotalRevenue)
+		margins.TotalRevenue += item.TotalRevenue
+		margins.TotalCost += item.TotalCost
+		margins.Items = append(margins.Items, item)
+	}
+	margins.TotalMargin = margins.TotalRevenue - margins.TotalCost
+	margins.MarginPercent = marginPercent(margins.TotalMargin, margins.TotalRevenue)
+	return margins, rows.Err()
+}
+
+func (r repo) GetInventoryValuation(ctx context.Context) (model.InventoryValuation, error) {
+	query := `
+		SELECT
+		id,
+		name,
+		unit,
+		IFNULL(stock, 0),
+		cost_price
+	FROM
+		products
+	WHERE
+		archived_at IS NULL
+	`
+	var valuation model.InventoryValuation
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return valuation, err
+	}
+	defer rows.Close()
+
+	valuation.Products = make([]model.InventoryProduct, 0)
+	for rows.Next() {
+		var product model.InventoryProduct
+		err := rows.Scan(
+			&product.ProductId,
+			&product.Name,
+			&product.Unit,
+			&product.Stock,
+			&product.CostPrice,
+		)
+		if err != nil {
+			return valuation, err
+		}
+		product.Value = product.CostPrice.Mul(product.Stock)
+		valuation.TotalValue += product.Value
+		valuation.Products = append(valuation.Products, product)
+	}
+	return valuation, rows.Err()
+}
+
+func marginPercent(margin, revenue model.Money) float64 {
+	if revenue == 0 {
+		return 0
+	}
+	return math.Round(float64(margin)*10000/float64(revenue)) / 100
+}
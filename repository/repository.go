@@ -2,6 +2,10 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/saptaka/pos/config"
@@ -11,12 +15,35 @@ type Repo interface {
 	CashierRepo
 	CategoryRepo
 	ProductRepo
+	DiscountRepo
+	PromotionRepo
+	CouponRepo
+	OrderDiscountRepo
+	TaxRepo
+	TipRepo
+	CurrencyRepo
+	QRISRepo
+	WebhookRepo
+	ShiftRepo
+	SalesReportRepo
+	CustomerRepo
+	LoyaltyRepo
+	GiftCardRepo
+	ReceiptRepo
 	PaymentRepo
 	OrderRepo
 	ReportRepo
 	SetupTableStructure()
 }
 
+// ErrReferenced is returned when an entity cannot be archived or restored
+// because active records still depend on it.
+var ErrReferenced = errors.New("entity is referenced by active records")
+
+// ErrCouponUnavailable is returned when a coupon can no longer be redeemed
+// by the time its order is stored.
+var ErrCouponUnavailable = errors.New("coupon is no longer available")
+
 type repo struct {
 	db DB
 }
@@ -33,8 +60,10 @@ func (r repo) SetupTableStructure() {
 		id bigint unsigned NOT NULL AUTO_INCREMENT,
 		name varchar(255) CHARACTER SET utf8mb4  NOT NULL DEFAULT 'DEFAULT',
 		passcode varchar(255) CHARACTER SET utf8mb4  NOT NULL,
+		role varchar(16) CHARACTER SET utf8mb4  NOT NULL DEFAULT 'CASHIER',
 		updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		archived_at timestamp NULL DEFAULT NULL,
 		UNIQUE KEY id (id)
 	  ) ENGINE=InnoDB AUTO_INCREMENT=16 DEFAULT CHARSET=utf8mb4;`
 
@@ -42,8 +71,10 @@ func (r repo) SetupTableStructure() {
 	CREATE TABLE IF NOT EXISTS categories (
 		id bigint unsigned NOT NULL AUTO_INCREMENT,
 		name varchar(255) CHARACTER SET utf8mb4  NOT NULL,
+		tax_class_id bigint unsigned DEFAULT NULL,
 		updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		archived_at timestamp NULL DEFAULT NULL,
 		UNIQUE KEY id (id)
 	  ) ENGINE=InnoDB AUTO_INCREMENT=8 DEFAULT CHARSET=utf8mb4 ;
 	`
@@ -53,10 +84,15 @@ func (r repo) SetupTableStructure() {
 		id bigint unsigned NOT NULL AUTO_INCREMENT,
 		qty int NOT NULL DEFAULT '0',
 		types varchar(255) CHARACTER SET utf8mb4  DEFAULT NULL,
+		amount bigint NOT NULL DEFAULT '0',
 		result int DEFAULT NULL,
+		started_at timestamp NULL DEFAULT NULL,
 		expired_at timestamp NULL DEFAULT NULL,
 		expired_at_format varchar(255) CHARACTER SET utf8mb4  NOT NULL DEFAULT 'DEFAULT',
 		string_format varchar(255) CHARACTER SET utf8mb4  DEFAULT 'DEFAULT',
+		updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		archived_at timestamp NULL DEFAULT NULL,
 		UNIQUE KEY id (id)
 	  ) ENGINE=InnoDB AUTO_INCREMENT=13 DEFAULT CHARSET=utf8mb4 ;`
 
@@ -68,13 +104,37 @@ func (r repo) SetupTableStructure() {
 		cashier_id bigint unsigned DEFAULT NULL,
 		payment_type_id bigint unsigned DEFAULT NULL,
 		receipt_id varchar(255) CHARACTER SET utf8mb4  NOT NULL DEFAULT '',
-		total_price int NOT NULL DEFAULT '0',
-		total_paid int NOT NULL DEFAULT '0',
-		total_return int NOT NULL DEFAULT '0',
+		total_price bigint NOT NULL DEFAULT '0',
+		total_discount bigint NOT NULL DEFAULT '0',
+		manual_discount bigint NOT NULL DEFAULT '0',
+		total_tax bigint NOT NULL DEFAULT '0',
+		tax_inclusive tinyint NOT NULL DEFAULT '0',
+		service_charge bigint NOT NULL DEFAULT '0',
+		tip bigint NOT NULL DEFAULT '0',
+		rounding bigint NOT NULL DEFAULT '0',
+		tender_currency varchar(3) CHARACTER SET utf8mb4  NOT NULL DEFAULT '',
+		tender_amount varchar(32) CHARACTER SET utf8mb4  NOT NULL DEFAULT '',
+		exchange_rate decimal(18,6) NOT NULL DEFAULT '0.000000',
+		status varchar(20) CHARACTER SET utf8mb4  NOT NULL DEFAULT 'PAID',
+		payment_provider varchar(32) CHARACTER SET utf8mb4  NOT NULL DEFAULT '',
+		payment_reference varchar(64) CHARACTER SET utf8mb4  NOT NULL DEFAULT '',
+		approval_code varchar(16) CHARACTER SET utf8mb4  NOT NULL DEFAULT '',
+		masked_pan varchar(32) CHARACTER SET utf8mb4  NOT NULL DEFAULT '',
+		terminal_id varchar(32) CHARACTER SET utf8mb4  NOT NULL DEFAULT '',
+		shift_id bigint unsigned DEFAULT NULL,
+		customer_id bigint unsigned DEFAULT NULL,
+		points_earned bigint NOT NULL DEFAULT '0',
+		points_redeemed bigint NOT NULL DEFAULT '0',
+		points_amount bigint NOT NULL DEFAULT '0',
+		gift_card_amount bigint NOT NULL DEFAULT '0',
+		total_paid bigint NOT NULL DEFAULT '0',
+		total_return bigint NOT NULL DEFAULT '0',
 		receipt_file_path varchar(255) CHARACTER SET utf8mb4  NOT NULL DEFAULT '',
 		is_downloaded tinyint NOT NULL DEFAULT '0',
 		UNIQUE KEY id (id),
-		INDEX(receipt_id)
+		INDEX(receipt_id),
+		INDEX(shift_id),
+		INDEX(customer_id)
 	  ) ENGINE=InnoDB AUTO_INCREMENT=2 DEFAULT CHARSET=utf8mb4 ; 
 	  `
 
@@ -83,9 +143,14 @@ func (r repo) SetupTableStructure() {
 		id bigint unsigned NOT NULL AUTO_INCREMENT,
 		types varchar(255) CHARACTER SET utf8mb4  DEFAULT NULL,
 		logo varchar(255) CHARACTER SET utf8mb4  NOT NULL DEFAULT 'DEFAULT',
+		logo_thumbnail varchar(255) CHARACTER SET utf8mb4  NOT NULL DEFAULT '',
 		name varchar(255) CHARACTER SET utf8mb4  NOT NULL DEFAULT 'DEFAULT',
+		rounding_unit bigint NOT NULL DEFAULT '0',
+		rounding_mode varchar(8) CHARACTER SET utf8mb4 NOT NULL DEFAULT 'NEAREST',
+		provider varchar(32) CHARACTER SET utf8mb4  NOT NULL DEFAULT '',
 		updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		archived_at timestamp NULL DEFAULT NULL,
 		UNIQUE KEY id (id)
 	  ) ENGINE=InnoDB AUTO_INCREMENT=13 DEFAULT CHARSET=utf8mb4 ; 
 	  `
@@ -94,16 +159,23 @@ func (r repo) SetupTableStructure() {
 	CREATE TABLE  IF NOT EXISTS products (
 		id bigint unsigned NOT NULL AUTO_INCREMENT,
 		name varchar(255) NOT NULL,
-		sku varchar(5) CHARACTER SET utf8mb4  NOT NULL DEFAULT '' COMMENT '',
-		stock int DEFAULT NULL,
-		price int DEFAULT NULL,
+		sku varchar(32) CHARACTER SET utf8mb4  NOT NULL DEFAULT '' COMMENT '',
+		barcode varchar(64) CHARACTER SET utf8mb4  NOT NULL DEFAULT '',
+		stock decimal(12,3) DEFAULT NULL,
+		price bigint DEFAULT NULL,
+		cost_price bigint NOT NULL DEFAULT '0',
+		unit varchar(8) CHARACTER SET utf8mb4  NOT NULL DEFAULT 'pcs',
 		image varchar(255) CHARACTER SET utf8mb4  NOT NULL DEFAULT '',
+		thumbnail varchar(255) CHARACTER SET utf8mb4  NOT NULL DEFAULT '',
 		discount_id bigint unsigned DEFAULT NULL,
 		category_id bigint unsigned DEFAULT NULL,
+		tax_class_id bigint unsigned DEFAULT NULL,
 		updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		archived_at timestamp NULL DEFAULT NULL,
 		UNIQUE KEY id (id),
-		INDEX(category_id)
+		INDEX(category_id),
+		INDEX(sku)
 	  ) ENGINE=InnoDB AUTO_INCREMENT=23 DEFAULT CHARSET=utf8mb4;
 	  `
 
@@ -112,17 +184,385 @@ func (r repo) SetupTableStructure() {
 		id bigint unsigned NOT NULL AUTO_INCREMENT,
 		product_id bigint unsigned NOT NULL,
 		order_id bigint unsigned NOT NULL,
-		qty int DEFAULT NULL,
-		total_normal_price int DEFAULT NULL,
-		total_final_price int DEFAULT NULL,
+		qty decimal(12,3) DEFAULT NULL,
+		unit_product varchar(8) CHARACTER SET utf8mb4  NOT NULL DEFAULT 'pcs',
+		total_normal_price bigint DEFAULT NULL,
+		total_final_price bigint DEFAULT NULL,
+		cost_price bigint NOT NULL DEFAULT '0',
+		total_cost_price bigint NOT NULL DEFAULT '0',
 		discount_id bigint unsigned DEFAULT NULL,
-		price_product int DEFAULT NULL,
+		tax_class_id bigint unsigned DEFAULT NULL,
+		tax_rate decimal(5,2) NOT NULL DEFAULT '0.00',
+		taxable_amount bigint NOT NULL DEFAULT '0',
+		tax_amount bigint NOT NULL DEFAULT '0',
+		price_product bigint DEFAULT NULL,
 		name_product varchar(255) CHARACTER SET utf8mb4 NOT NULL DEFAULT '',
 		UNIQUE KEY id (id),
 		INDEX (order_id)
 	  ) ENGINE=InnoDB AUTO_INCREMENT=4 DEFAULT CHARSET=utf8mb4 ; 
 	  `
 
+	goodsReceiptsTable := `
+	  CREATE TABLE  IF NOT EXISTS goods_receipts (
+		id bigint unsigned NOT NULL AUTO_INCREMENT,
+		product_id bigint unsigned NOT NULL,
+		qty decimal(12,3) NOT NULL,
+		unit_cost bigint NOT NULL,
+		created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE KEY id (id),
+		INDEX (product_id)
+	  ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 ;
+	  `
+
+	stockMovementsTable := `
+	  CREATE TABLE  IF NOT EXISTS stock_movements (
+		id bigint unsigned NOT NULL AUTO_INCREMENT,
+		product_id bigint unsigned NOT NULL,
+		order_id bigint unsigned DEFAULT NULL,
+		type varchar(16) CHARACTER SET utf8mb4  NOT NULL,
+		qty decimal(12,3) NOT NULL,
+		created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE KEY id (id),
+		INDEX (product_id),
+		INDEX (order_id)
+	  ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 ;
+	  `
+
+	promotionsTable := `
+	  CREATE TABLE  IF NOT EXISTS promotions (
+		id bigint unsigned NOT NULL AUTO_INCREMENT,
+		name varchar(255) CHARACTER SET utf8mb4  NOT NULL,
+		types varchar(32) CHARACTER SET utf8mb4  NOT NULL,
+		priority int NOT NULL DEFAULT '0',
+		is_stackable tinyint NOT NULL DEFAULT '0',
+		is_exclusive tinyint NOT NULL DEFAULT '0',
+		coupon_only tinyint NOT NULL DEFAULT '0',
+		product_id bigint unsigned DEFAULT NULL,
+		category_id bigint unsigned DEFAULT NULL,
+		get_product_id bigint unsigned DEFAULT NULL,
+		buy_qty int NOT NULL DEFAULT '0',
+		get_qty int NOT NULL DEFAULT '0',
+		min_spend bigint NOT NULL DEFAULT '0',
+		value_type varchar(16) CHARACTER SET utf8mb4  NOT NULL DEFAULT '',
+		amount bigint NOT NULL DEFAULT '0',
+		value int NOT NULL DEFAULT '0',
+		tiers text CHARACTER SET utf8mb4  NOT NULL,
+		windows text CHARACTER SET utf8mb4,
+		started_at timestamp NULL DEFAULT NULL,
+		expired_at timestamp NULL DEFAULT NULL,
+		updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		archived_at timestamp NULL DEFAULT NULL,
+		UNIQUE KEY id (id)
+	  ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 ;
+	  `
+
+	orderPromotionsTable := `
+	  CREATE TABLE  IF NOT EXISTS order_promotions (
+		id bigint unsigned NOT NULL AUTO_INCREMENT,
+		order_id bigint unsigned NOT NULL,
+		promotion_id bigint unsigned NOT NULL,
+		name varchar(255) CHARACTER SET utf8mb4  NOT NULL DEFAULT '',
+		product_id bigint unsigned DEFAULT NULL,
+		amount bigint NOT NULL DEFAULT '0',
+		UNIQUE KEY id (id),
+		INDEX (order_id)
+	  ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 ;
+	  `
+
+	couponsTable := `
+	  CREATE TABLE  IF NOT EXISTS coupons (
+		id bigint unsigned NOT NULL AUTO_INCREMENT,
+		code varchar(32) CHARACTER SET utf8mb4  NOT NULL,
+		promotion_id bigint unsigned NOT NULL,
+		usage_limit int NOT NULL DEFAULT '0',
+		per_customer_limit int NOT NULL DEFAULT '0',
+		redeemed int NOT NULL DEFAULT '0',
+		started_at timestamp NULL DEFAULT NULL,
+		expired_at timestamp NULL DEFAULT NULL,
+		updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		archived_at timestamp NULL DEFAULT NULL,
+		UNIQUE KEY id (id),
+		UNIQUE KEY code (code)
+	  ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 ;
+	  `
+
+	couponRedemptionsTable := `
+	  CREATE TABLE  IF NOT EXISTS coupon_redemptions (
+		id bigint unsigned NOT NULL AUTO_INCREMENT,
+		coupon_id bigint unsigned NOT NULL,
+		promotion_id bigint unsigned NOT NULL,
+		order_id bigint unsigned NOT NULL,
+		customer_id bigint unsigned DEFAULT NULL,
+		amount bigint NOT NULL DEFAULT '0',
+		created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		released_at timestamp NULL DEFAULT NULL,
+		UNIQUE KEY id (id),
+		INDEX (coupon_id, customer_id),
+		INDEX (order_id)
+	  ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 ;
+	  `
+
+	orderDiscountsTable := `
+	  CREATE TABLE  IF NOT EXISTS order_discounts (
+		id bigint unsigned NOT NULL AUTO_INCREMENT,
+		order_id bigint unsigned NOT NULL,
+		product_id bigint unsigned DEFAULT NULL,
+		types varchar(16) CHARACTER SET utf8mb4  NOT NULL,
+		value int NOT NULL,
+		amount bigint NOT NULL,
+		reason varchar(255) CHARACTER SET utf8mb4  NOT NULL DEFAULT '',
+		approved_by bigint unsigned DEFAULT NULL,
+		created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE KEY id (id),
+		INDEX (order_id),
+		INDEX (created_at)
+	  ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 ;
+	  `
+
+	taxClassesTable := `
+	  CREATE TABLE  IF NOT EXISTS tax_classes (
+		id bigint unsigned NOT NULL AUTO_INCREMENT,
+		name varchar(255) CHARACTER SET utf8mb4  NOT NULL,
+		rate decimal(5,2) NOT NULL DEFAULT '0.00',
+		updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		archived_at timestamp NULL DEFAULT NULL,
+		UNIQUE KEY id (id)
+	  ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 ;
+	  `
+
+	currenciesTable := `
+	  CREATE TABLE  IF NOT EXISTS currencies (
+		id bigint unsigned NOT NULL AUTO_INCREMENT,
+		code varchar(3) CHARACTER SET utf8mb4  NOT NULL,
+		name varchar(255) CHARACTER SET utf8mb4  NOT NULL DEFAULT '',
+		symbol varchar(8) CHARACTER SET utf8mb4  NOT NULL DEFAULT '',
+		minor_units tinyint NOT NULL DEFAULT '2',
+		updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		archived_at timestamp NULL DEFAULT NULL,
+		UNIQUE KEY id (id),
+		UNIQUE KEY code (code)
+	  ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 ;
+	  `
+
+	exchangeRatesTable := `
+	  CREATE TABLE  IF NOT EXISTS exchange_rates (
+		id bigint unsigned NOT NULL AUTO_INCREMENT,
+		currency_id bigint unsigned NOT NULL,
+		rate decimal(18,6) NOT NULL,
+		effective_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE KEY id (id),
+		INDEX (currency_id, effective_at)
+	  ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 ;
+	  `
+
+	qrisCodesTable := `
+	  CREATE TABLE  IF NOT EXISTS qris_codes (
+		id bigint unsigned NOT NULL AUTO_INCREMENT,
+		reference varchar(64) CHARACTER SET utf8mb4  NOT NULL,
+		order_reference varchar(255) CHARACTER SET utf8mb4  NOT NULL,
+		amount bigint NOT NULL,
+		payload text CHARACTER SET utf8mb4  NOT NULL,
+		status varchar(16) CHARACTER SET utf8mb4  NOT NULL DEFAULT 'PENDING',
+		expires_at timestamp NULL DEFAULT NULL,
+		paid_at timestamp NULL DEFAULT NULL,
+		updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE KEY id (id),
+		UNIQUE KEY reference (reference),
+		INDEX (order_reference)
+	  ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 ;
+	  `
+
+	paymentEventsTable := `
+	  CREATE TABLE  IF NOT EXISTS payment_events (
+		id bigint unsigned NOT NULL AUTO_INCREMENT,
+		provider varchar(32) CHARACTER SET utf8mb4  NOT NULL,
+		event_id varchar(128) CHARACTER SET utf8mb4  NOT NULL,
+		order_reference varchar(255) CHARACTER SET utf8mb4  NOT NULL DEFAULT '',
+		status varchar(16) CHARACTER SET utf8mb4  NOT NULL DEFAULT '',
+		payload mediumtext CHARACTER SET utf8mb4  NOT NULL,
+		signature varchar(128) CHARACTER SET utf8mb4  NOT NULL DEFAULT '',
+		result varchar(64) CHARACTER SET utf8mb4  NOT NULL DEFAULT '',
+		created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE KEY id (id),
+		UNIQUE KEY provider_event (provider, event_id),
+		INDEX (order_reference)
+	  ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 ;
+	  `
+
+	shiftsTable := `
+	  CREATE TABLE  IF NOT EXISTS shifts (
+		id bigint unsigned NOT NULL AUTO_INCREMENT,
+		cashier_id bigint unsigned NOT NULL,
+		opening_float bigint NOT NULL DEFAULT '0',
+		status varchar(16) CHARACTER SET utf8mb4  NOT NULL DEFAULT 'OPEN',
+		open_marker tinyint DEFAULT NULL,
+		closed_by bigint unsigned DEFAULT NULL,
+		expected_cash bigint NOT NULL DEFAULT '0',
+		counted_cash bigint NOT NULL DEFAULT '0',
+		opened_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		closed_at timestamp NULL DEFAULT NULL,
+		UNIQUE KEY id (id),
+		UNIQUE KEY open_marker (open_marker)
+	  ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 ;
+	  `
+
+	shiftMovementsTable := `
+	  CREATE TABLE  IF NOT EXISTS shift_movements (
+		id bigint unsigned NOT NULL AUTO_INCREMENT,
+		shift_id bigint unsigned NOT NULL,
+		cashier_id bigint unsigned NOT NULL,
+		type varchar(16) CHARACTER SET utf8mb4  NOT NULL,
+		amount bigint NOT NULL DEFAULT '0',
+		reason varchar(255) CHARACTER SET utf8mb4  NOT NULL DEFAULT '',
+		created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE KEY id (id),
+		INDEX (shift_id)
+	  ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 ;
+	  `
+
+	shiftTendersTable := `
+	  CREATE TABLE  IF NOT EXISTS shift_tenders (
+		shift_id bigint unsigned NOT NULL,
+		payment_type_id bigint unsigned NOT NULL,
+		name varchar(255) CHARACTER SET utf8mb4  NOT NULL DEFAULT '',
+		type varchar(16) CHARACTER SET utf8mb4  NOT NULL DEFAULT '',
+		total_order int NOT NULL DEFAULT '0',
+		sales bigint NOT NULL DEFAULT '0',
+		expected bigint NOT NULL DEFAULT '0',
+		counted bigint NOT NULL DEFAULT '0',
+		PRIMARY KEY (shift_id, payment_type_id)
+	  ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 ;
+	  `
+
+	shiftCurrenciesTable := `
+	  CREATE TABLE  IF NOT EXISTS shift_currencies (
+		shift_id bigint unsigned NOT NULL,
+		payment_type_id bigint unsigned NOT NULL,
+		currency varchar(3) CHARACTER SET utf8mb4  NOT NULL,
+		total_order int NOT NULL DEFAULT '0',
+		amount varchar(32) CHARACTER SET utf8mb4  NOT NULL DEFAULT '',
+		total_paid bigint NOT NULL DEFAULT '0',
+		PRIMARY KEY (shift_id, payment_type_id, currency)
+	  ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 ;
+	  `
+
+	zReportsTable := `
+	  CREATE TABLE  IF NOT EXISTS z_reports (
+		id bigint unsigned NOT NULL AUTO_INCREMENT,
+		number bigint unsigned NOT NULL,
+		to_order_id bigint unsigned NOT NULL DEFAULT '0',
+		to_shift_id bigint unsigned NOT NULL DEFAULT '0',
+		report mediumtext CHARACTER SET utf8mb4  NOT NULL,
+		generated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE KEY id (id),
+		UNIQUE KEY number (number)
+	  ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 ;
+	  `
+
+	customersTable := `
+	  CREATE TABLE  IF NOT EXISTS customers (
+		id bigint unsigned NOT NULL AUTO_INCREMENT,
+		name varchar(255) CHARACTER SET utf8mb4  NOT NULL,
+		phone varchar(32) CHARACTER SET utf8mb4  NOT NULL DEFAULT '',
+		email varchar(255) CHARACTER SET utf8mb4  NOT NULL DEFAULT '',
+		notes text CHARACTER SET utf8mb4  NOT NULL,
+		tags varchar(512) CHARACTER SET utf8mb4  NOT NULL DEFAULT '',
+		created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+		archived_at timestamp NULL DEFAULT NULL,
+		UNIQUE KEY id (id),
+		INDEX (phone)
+	  ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 ;
+	  `
+
+	loyaltyRulesTable := `
+	  CREATE TABLE  IF NOT EXISTS loyalty_rules (
+		id bigint unsigned NOT NULL AUTO_INCREMENT,
+		category_id bigint unsigned DEFAULT NULL,
+		spend bigint NOT NULL,
+		points bigint NOT NULL,
+		created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+		archived_at timestamp NULL DEFAULT NULL,
+		UNIQUE KEY id (id)
+	  ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 ;
+	  `
+
+	loyaltyLedgerTable := `
+	  CREATE TABLE  IF NOT EXISTS loyalty_ledger (
+		id bigint unsigned NOT NULL AUTO_INCREMENT,
+		customer_id bigint unsigned NOT NULL,
+		order_id bigint unsigned DEFAULT NULL,
+		type varchar(16) CHARACTER SET utf8mb4  NOT NULL,
+		points bigint NOT NULL,
+		remaining bigint NOT NULL DEFAULT '0',
+		expires_at timestamp NULL DEFAULT NULL,
+		created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE KEY id (id),
+		INDEX (customer_id, remaining),
+		INDEX (order_id)
+	  ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 ;
+	  `
+
+	giftCardsTable := `
+	  CREATE TABLE  IF NOT EXISTS gift_cards (
+		id bigint unsigned NOT NULL AUTO_INCREMENT,
+		code varchar(32) CHARACTER SET utf8mb4  NOT NULL,
+		type varchar(16) CHARACTER SET utf8mb4  NOT NULL,
+		customer_id bigint unsigned DEFAULT NULL,
+		order_id bigint unsigned DEFAULT NULL,
+		balance bigint NOT NULL DEFAULT '0',
+		expires_at timestamp NULL DEFAULT NULL,
+		created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+		UNIQUE KEY id (id),
+		UNIQUE KEY code (code),
+		INDEX (customer_id)
+	  ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 ;
+	  `
+
+	giftCardLedgerTable := `
+	  CREATE TABLE  IF NOT EXISTS gift_card_ledger (
+		id bigint unsigned NOT NULL AUTO_INCREMENT,
+		gift_card_id bigint unsigned NOT NULL,
+		order_id bigint unsigned DEFAULT NULL,
+		type varchar(16) CHARACTER SET utf8mb4  NOT NULL,
+		amount bigint NOT NULL,
+		balance bigint NOT NULL,
+		created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE KEY id (id),
+		INDEX (gift_card_id),
+		INDEX (order_id)
+	  ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 ;
+	  `
+
+	receiptOutboxTable := `
+	  CREATE TABLE  IF NOT EXISTS receipt_outbox (
+		id bigint unsigned NOT NULL AUTO_INCREMENT,
+		order_id bigint unsigned NOT NULL,
+		channel varchar(16) CHARACTER SET utf8mb4  NOT NULL,
+		recipient varchar(255) CHARACTER SET utf8mb4  NOT NULL,
+		subject varchar(255) CHARACTER SET utf8mb4  NOT NULL DEFAULT '',
+		body text CHARACTER SET utf8mb4  NOT NULL,
+		status varchar(16) CHARACTER SET utf8mb4  NOT NULL,
+		attempts int NOT NULL DEFAULT '0',
+		last_error varchar(255) CHARACTER SET utf8mb4  NOT NULL DEFAULT '',
+		next_attempt_at timestamp NULL DEFAULT NULL,
+		sent_at timestamp NULL DEFAULT NULL,
+		created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+		UNIQUE KEY id (id),
+		INDEX (status, next_attempt_at),
+		INDEX (order_id)
+	  ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 ;
+	  `
+
 	_, err := r.db.ExecContext(context.Background(), cashiersTable)
 	if err != nil {
 		panic(err)
@@ -156,4 +596,257 @@ func (r repo) SetupTableStructure() {
 	if err != nil {
 		panic(err)
 	}
+
+	_, err = r.db.ExecContext(context.Background(), goodsReceiptsTable)
+	if err != nil {
+		panic(err)
+	}
+	_, err = r.db.ExecContext(context.Background(), stockMovementsTable)
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = r.db.ExecContext(context.Background(), promotionsTable)
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = r.db.ExecContext(context.Background(), orderPromotionsTable)
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = r.db.ExecContext(context.Background(), couponsTable)
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = r.db.ExecContext(context.Background(), couponRedemptionsTable)
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = r.db.ExecContext(context.Background(), orderDiscountsTable)
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = r.db.ExecContext(context.Background(), taxClassesTable)
+	if err != nil {
+		panic(err)
+	}
+	_, err = r.db.ExecContext(context.Background(), currenciesTable)
+	if err != nil {
+		panic(err)
+	}
+	_, err = r.db.ExecContext(context.Background(), exchangeRatesTable)
+	if err != nil {
+		panic(err)
+	}
+	_, err = r.db.ExecContext(context.Background(), qrisCodesTable)
+	if err != nil {
+		panic(err)
+	}
+	_, err = r.db.ExecContext(context.Background(), paymentEventsTable)
+	if err != nil {
+		panic(err)
+	}
+	_, err = r.db.ExecContext(context.Background(), shiftsTable)
+	if err != nil {
+		panic(err)
+	}
+	_, err = r.db.ExecContext(context.Background(), shiftMovementsTable)
+	if err != nil {
+		panic(err)
+	}
+	_, err = r.db.ExecContext(context.Background(), shiftTendersTable)
+	if err != nil {
+		panic(err)
+	}
+	_, err = r.db.ExecContext(context.Background(), shiftCurrenciesTable)
+	if err != nil {
+		panic(err)
+	}
+	_, err = r.db.ExecContext(context.Background(), zReportsTable)
+	if err != nil {
+		panic(err)
+	}
+	_, err = r.db.ExecContext(context.Background(), customersTable)
+	if err != nil {
+		panic(err)
+	}
+	_, err = r.db.ExecContext(context.Background(), loyaltyRulesTable)
+	if err != nil {
+		panic(err)
+	}
+	_, err = r.db.ExecContext(context.Background(), loyaltyLedgerTable)
+	if err != nil {
+		panic(err)
+	}
+	_, err = r.db.ExecContext(context.Background(), giftCardsTable)
+	if err != nil {
+		panic(err)
+	}
+	_, err = r.db.ExecContext(context.Background(), giftCardLedgerTable)
+	if err != nil {
+		panic(err)
+	}
+	_, err = r.db.ExecContext(context.Background(), receiptOutboxTable)
+	if err != nil {
+		panic(err)
+	}
+
+	r.alterColumn("products", "stock", "decimal(12,3) DEFAULT NULL")
+	r.alterColumn("products", "unit", "varchar(8) CHARACTER SET utf8mb4 NOT NULL DEFAULT 'pcs'")
+	r.alterColumn("ordered_products", "qty", "decimal(12,3) DEFAULT NULL")
+	r.alterColumn("ordered_products", "unit_product", "varchar(8) CHARACTER SET utf8mb4 NOT NULL DEFAULT 'pcs'")
+	r.alterColumn("products", "sku", "varchar(32) CHARACTER SET utf8mb4 NOT NULL DEFAULT ''")
+	r.alterColumn("products", "barcode", "varchar(64) CHARACTER SET utf8mb4 NOT NULL DEFAULT ''")
+	r.alterColumn("products", "thumbnail", "varchar(255) CHARACTER SET utf8mb4 NOT NULL DEFAULT ''")
+	r.alterColumn("payments", "logo_thumbnail", "varchar(255) CHARACTER SET utf8mb4 NOT NULL DEFAULT ''")
+	r.alterColumn("products", "cost_price", "bigint NOT NULL DEFAULT '0'")
+	r.alterColumn("ordered_products", "cost_price", "bigint NOT NULL DEFAULT '0'")
+	r.alterColumn("ordered_products", "total_cost_price", "bigint NOT NULL DEFAULT '0'")
+	r.alterColumn("discounts", "started_at", "timestamp NULL DEFAULT NULL")
+	r.alterColumn("discounts", "updated_at", "timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP")
+	r.alterColumn("discounts", "created_at", "timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP")
+	r.alterColumn("orders", "total_discount", "bigint NOT NULL DEFAULT '0'")
+	r.alterColumn("promotions", "windows", "text CHARACTER SET utf8mb4")
+	r.alterColumn("promotions", "coupon_only", "tinyint NOT NULL DEFAULT '0'")
+	r.alterColumn("cashiers", "role", "varchar(16) CHARACTER SET utf8mb4 NOT NULL DEFAULT 'CASHIER'")
+	r.alterColumn("orders", "manual_discount", "bigint NOT NULL DEFAULT '0'")
+	r.alterColumn("orders", "total_tax", "bigint NOT NULL DEFAULT '0'")
+	r.alterColumn("orders", "tax_inclusive", "tinyint NOT NULL DEFAULT '0'")
+	r.alterColumn("orders", "service_charge", "bigint NOT NULL DEFAULT '0'")
+	r.alterColumn("orders", "tip", "bigint NOT NULL DEFAULT '0'")
+	r.alterColumn("orders", "rounding", "bigint NOT NULL DEFAULT '0'")
+	r.alterColumn("payments", "rounding_unit", "bigint NOT NULL DEFAULT '0'")
+	r.alterColumn("payments", "rounding_mode", "varchar(8) CHARACTER SET utf8mb4 NOT NULL DEFAULT 'NEAREST'")
+	r.alterColumn("products", "tax_class_id", "bigint unsigned DEFAULT NULL")
+	r.alterColumn("categories", "tax_class_id", "bigint unsigned DEFAULT NULL")
+	r.alterColumn("ordered_products", "tax_class_id", "bigint unsigned DEFAULT NULL")
+	r.alterColumn("ordered_products", "tax_rate", "decimal(5,2) NOT NULL DEFAULT '0.00'")
+	r.alterColumn("ordered_products", "taxable_amount", "bigint NOT NULL DEFAULT '0'")
+	r.alterColumn("ordered_products", "tax_amount", "bigint NOT NULL DEFAULT '0'")
+	r.alterColumn("orders", "total_price", "bigint NOT NULL DEFAULT '0'")
+	r.alterColumn("orders", "total_paid", "bigint NOT NULL DEFAULT '0'")
+	r.alterColumn("orders", "total_return", "bigint NOT NULL DEFAULT '0'")
+	r.alterColumn("products", "price", "bigint DEFAULT NULL")
+	r.alterColumn("ordered_products", "total_normal_price", "bigint DEFAULT NULL")
+	r.alterColumn("ordered_products", "total_final_price", "bigint DEFAULT NULL")
+	r.alterColumn("ordered_products", "price_product", "bigint DEFAULT NULL")
+	r.alterColumn("goods_receipts", "unit_cost", "bigint NOT NULL")
+	r.alterColumn("promotions", "min_spend", "bigint NOT NULL DEFAULT '0'")
+	r.alterColumn("order_promotions", "amount", "bigint NOT NULL DEFAULT '0'")
+	r.alterColumn("coupon_redemptions", "amount", "bigint NOT NULL DEFAULT '0'")
+	r.alterColumn("coupon_redemptions", "released_at", "timestamp NULL DEFAULT NULL")
+	r.alterColumn("order_discounts", "amount", "bigint NOT NULL")
+	r.alterColumn("orders", "tender_currency", "varchar(3) CHARACTER SET utf8mb4 NOT NULL DEFAULT ''")
+	r.alterColumn("orders", "tender_amount", "varchar(32) CHARACTER SET utf8mb4 NOT NULL DEFAULT ''")
+	r.alterColumn("orders", "exchange_rate", "decimal(18,6) NOT NULL DEFAULT '0.000000'")
+	r.alterColumn("orders", "status", "varchar(20) CHARACTER SET utf8mb4 NOT NULL DEFAULT 'PAID'")
+	r.alterColumn("orders", "payment_provider", "varchar(32) CHARACTER SET utf8mb4 NOT NULL DEFAULT ''")
+	r.alterColumn("orders", "payment_reference", "varchar(64) CHARACTER SET utf8mb4 NOT NULL DEFAULT ''")
+	r.alterColumn("payments", "provider", "varchar(32) CHARACTER SET utf8mb4 NOT NULL DEFAULT ''")
+	r.alterColumn("orders", "approval_code", "varchar(16) CHARACTER SET utf8mb4 NOT NULL DEFAULT ''")
+	r.alterColumn("orders", "masked_pan", "varchar(32) CHARACTER SET utf8mb4 NOT NULL DEFAULT ''")
+	r.alterColumn("orders", "terminal_id", "varchar(32) CHARACTER SET utf8mb4 NOT NULL DEFAULT ''")
+	r.alterColumn("orders", "shift_id", "bigint unsigned DEFAULT NULL")
+	r.alterColumn("orders", "customer_id", "bigint unsigned DEFAULT NULL")
+	r.alterColumn("orders", "points_earned", "bigint NOT NULL DEFAULT '0'")
+	r.alterColumn("orders", "points_redeemed", "bigint NOT NULL DEFAULT '0'")
+	r.alterColumn("orders", "points_amount", "bigint NOT NULL DEFAULT '0'")
+	r.alterColumn("orders", "gift_card_amount", "bigint NOT NULL DEFAULT '0'")
+	for _, table := range []string{"cashiers", "categories", "discounts", "payments", "products"} {
+		r.alterColumn(table, "archived_at", "timestamp NULL DEFAULT NULL")
+	}
+
+	// Amounts off used to be kept in minor units in the same column as
+	// percentages; they have a column of their own now, and the old one
+	// holds percentages only. Tables from before then have their amounts
+	// moved over once, when the column is added.
+	var queries []string
+	if !r.hasColumn("discounts", "amount") {
+		r.alterColumn("discounts", "amount", "bigint NOT NULL DEFAULT '0'")
+		queries = append(queries,
+			"UPDATE discounts SET amount=result, result=0 WHERE types<>'PERCENT' AND result<>0 AND amount=0",
+			"UPDATE order_discounts SET value=0 WHERE types<>'PERCENT' AND value<>0")
+	}
+	if !r.hasColumn("promotions", "amount") {
+		r.alterColumn("promotions", "amount", "bigint NOT NULL DEFAULT '0'")
+		queries = append(queries, `UPDATE promotions SET amount=value, value=0 WHERE value<>0 AND amount=0 AND
+			(types IN ('FIXED_AMOUNT','MIX_MATCH') OR (types='SPEND_THRESHOLD' AND value_type='AMOUNT'))`)
+	}
+	if len(queries) > 0 {
+		r.migrate(queries...)
+	}
+
+	// Discounts created without an expiry used to be stored as expiring at
+	// the unix epoch; they never expire.
+	_, err = r.db.ExecContext(context.Background(),
+		"UPDATE discounts SET expired_at=NULL WHERE expired_at < '1970-01-03'")
+	if err != nil {
+		panic(err)
+	}
+}
+
+// migrate runs queries that convert existing rows in one transaction, so
+// they are either all applied or none are.
+func (r repo) migrate(queries ...string) {
+	tx, err := r.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		panic(err)
+	}
+	defer tx.Rollback()
+	for _, query := range queries {
+		_, err = tx.ExecContext(context.Background(), query)
+		if err != nil {
+			panic(err)
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		panic(err)
+	}
+}
+
+// hasColumn reports whether a table has a column.
+func (r repo) hasColumn(table, column string) bool {
+	var count int
+	query := `SELECT COUNT(*) FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?`
+	err := r.db.QueryRowContext(context.Background(), query, table, column).Scan(&count)
+	if err != nil {
+		panic(err)
+	}
+	return count > 0
+}
+
+// alterColumn brings a column of a table created by an older version up to
+// date: it is added when missing and modified when its column type differs.
+func (r repo) alterColumn(table, column, definition string) {
+	var columnType string
+	query := `SELECT COLUMN_TYPE FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?`
+	err := r.db.QueryRowContext(context.Background(), query, table, column).Scan(&columnType)
+	if err == sql.ErrNoRows {
+		_, err = r.db.ExecContext(context.Background(),
+			fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+		if err != nil {
+			panic(err)
+		}
+		return
+	}
+	if err != nil {
+		panic(err)
+	}
+
+	definitionType := strings.ToLower(strings.Fields(definition)[0])
+	if !strings.HasPrefix(strings.ToLower(columnType), definitionType) {
+		_, err = r.db.ExecContext(context.Background(),
+			fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s %s", table, column, definition))
+		if err != nil {
+			panic(err)
+		}
+	}
 }
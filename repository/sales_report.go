@@ -0,0 +1,320 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/saptaka/pos/model"
+)
+
+// ErrPendingOrders is returned when a Z report is generated while orders
+// still wait for their payment.
+var ErrPendingOrders = errors.New("orders are still waiting for payment")
+
+type SalesReportRepo interface {
+	GetShiftSalesReport(ctx context.Context, shiftId int64) (model.SalesReport, error)
+	CreateZReport(ctx context.Context) (model.SalesReport, error)
+	GetZReport(ctx context.Context, number int64) (model.SalesReport, error)
+	GetZReports(ctx context.Context, limit, skip int) ([]model.SalesReport, error)
+}
+
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// salesScope picks the orders and shifts a sales report covers: the orders
+// sold and voided, the orders refunded, and the closed shifts whose cash
+// count it includes. Each is a condition on the orders or shifts table
+// with its arguments.
+type salesScope struct {
+	orders      string
+	ordersArgs  []interface{}
+	refunds     string
+	refundsArgs []interface{}
+	shifts      string
+	shiftsArgs  []interface{}
+}
+
+// GetShiftSalesReport is the X report of a shift.
+func (r repo) GetShiftSalesReport(ctx context.Context, shiftId int64) (model.SalesReport, error) {
+	scope := salesScope{
+		orders:      "orders.shift_id = ?",
+		ordersArgs:  []interface{}{shiftId},
+		refunds:     "orders.shift_id = ?",
+		refundsArgs: []interface{}{shiftId},
+		shifts:      "shifts.id = ?",
+		shiftsArgs:  []interface{}{shiftId},
+	}
+	report, err := salesReport(ctx, r.db, scope)
+	if err != nil {
+		return report, err
+	}
+	now := time.Now().UTC()
+	report.Type = model.ReportX
+	report.ShiftId = &shiftId
+	report.GeneratedAt = &now
+	return report, nil
+}
+
+// CreateZReport closes the day: it totals every order placed since the
+// previous Z report, refunds made since then and the shifts closed since
+// then, and stores the result under the next number. The day can only be
+// closed with every shift closed and no order waiting for its payment.
+func (r repo) CreateZReport(ctx context.Context) (model.SalesReport, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return model.SalesReport{}, err
+	}
+	defer tx.Rollback()
+
+	var number, lastOrderId, lastShiftId int64
+	var lastGeneratedAt *time.Time
+	err = tx.QueryRowContext(ctx, `
+		SELECT number, to_order_id, to_shift_id, generated_at
+		FROM z_reports ORDER BY number DESC LIMIT 1 FOR UPDATE`).
+		Scan(&number, &lastOrderId, &lastShiftId, &lastGeneratedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return model.SalesReport{}, err
+	}
+
+	var openShifts int
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(id) FROM shifts WHERE status='OPEN'").Scan(&openShifts)
+	if err != nil {
+		return model.SalesReport{}, err
+	}
+	if openShifts > 0 {
+		return model.SalesReport{}, ErrShiftOpen
+	}
+	var toOrderId, toShiftId int64
+	var pending int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COALESCE(MAX(id), 0), COALESCE(SUM(status = 'PENDING_PAYMENT'), 0)
+		FROM orders WHERE id > ?`, lastOrderId).Scan(&toOrderId, &pending)
+	if err != nil {
+		return model.SalesReport{}, err
+	}
+	if pending > 0 {
+		return model.SalesReport{}, ErrPendingOrders
+	}
+	if toOrderId < lastOrderId {
+		toOrderId = lastOrderId
+	}
+	err = tx.QueryRowContext(ctx, "SELECT COALESCE(MAX(id), 0) FROM shifts").Scan(&toShiftId)
+	if err != nil {
+		return model.SalesReport{}, err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	scope := salesScope{
+		orders:      "orders.id > ? AND orders.id <= ?",
+		ordersArgs:  []interface{}{lastOrderId, toOrderId},
+		refunds:     "orders.updated_at <= ?",
+		refundsArgs: []interface{}{now},
+		shifts:      "shifts.id > ? AND shifts.id <= ?",
+		shiftsArgs:  []interface{}{lastShiftId, toShiftId},
+	}
+	if lastGeneratedAt != nil {
+		scope.refunds += " AND orders.updated_at > ?"
+		scope.refundsArgs = append(scope.refundsArgs, *lastGeneratedAt)
+	}
+	report, err := salesReport(ctx, tx, scope)
+	if err != nil {
+		return report, err
+	}
+	report.Type = model.ReportZ
+	report.Number = number + 1
+	report.GeneratedAt = &now
+
+	payload, err := json.Marshal(report)
+	if err != nil {
+		return report, err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO z_reports (number, to_order_id, to_shift_id, report, generated_at)
+		VALUES (?,?,?,?,?)`, report.Number, toOrderId, toShiftId, payload, now)
+	if err != nil {
+		return report, err
+	}
+	return report, tx.Commit()
+}
+
+// GetZReport is a Z report as it was generated.
+func (r repo) GetZReport(ctx context.Context, number int64) (model.SalesReport, error) {
+	var payload []byte
+	var report model.SalesReport
+	err := r.db.QueryRowContext(ctx, "SELECT report FROM z_reports WHERE number=?", number).Scan(&payload)
+	if err != nil {
+		return report, err
+	}
+	err = json.Unmarshal(payload, &report)
+	return report, err
+}
+
+func (r repo) GetZReports(ctx context.Context, limit, skip int) ([]model.SalesReport, error) {
+	query := "SELECT report FROM z_reports ORDER BY number DESC"
+	var rows *sql.Rows
+	var err error
+	if limit > 0 {
+		query += " limit ? offset ?;"
+		rows, err = r.db.QueryContext(ctx, query, limit, skip)
+	} else {
+		rows, err = r.db.QueryContext(ctx, query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := make([]model.SalesReport, 0)
+	for rows.Next() {
+		var payload []byte
+		err := rows.Scan(&payload)
+		if err != nil {
+			return nil, err
+		}
+		var report model.SalesReport
+		err = json.Unmarshal(payload, &report)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, rows.Err()
+}
+
+// salesReport totals the orders of a scope. Orders refunded since they
+// were sold still count as sold, the refund showing under Refunds.
+func salesReport(ctx context.Context, db querier, scope salesScope) (model.SalesReport, error) {
+	var report model.SalesReport
+	query := `
+	SELECT COUNT(orders.id),
+		COALESCE(SUM(orders.total_price - IF(orders.tax_inclusive, 0, orders.total_tax)), 0),
+		COALESCE(SUM(orders.total_tax), 0),
+		COALESCE(SUM(orders.service_charge), 0),
+		COALESCE(SUM(orders.tip), 0),
+		COALESCE(SUM(orders.rounding), 0),
+		COALESCE(SUM(orders.total_paid - orders.total_return + orders.points_amount + orders.gift_card_amount), 0),
+		MIN(orders.created_at),
+		MAX(orders.created_at)
+	FROM orders
+	WHERE orders.status IN ('PAID', 'REFUNDED') AND ` + scope.orders
+	err := db.QueryRowContext(ctx, query, scope.ordersArgs...).Scan(
+		&report.TotalOrder,
+		&report.NetSales,
+		&report.Tax,
+		&report.ServiceCharge,
+		&report.Tips,
+		&report.Rounding,
+		&report.TotalCollected,
+		&report.From,
+		&report.To,
+	)
+	if err != nil {
+		return report, err
+	}
+
+	query = `
+	SELECT COALESCE(SUM(ordered_products.total_normal_price), 0)
+	FROM ordered_products
+		JOIN orders ON orders.id = ordered_products.order_id
+	WHERE orders.status IN ('PAID', 'REFUNDED') AND ` + scope.orders
+	err = db.QueryRowContext(ctx, query, scope.ordersArgs...).Scan(&report.GrossSales)
+	if err != nil {
+		return report, err
+	}
+	report.Discounts = report.GrossSales - report.NetSales
+
+	query = `
+	SELECT COUNT(orders.id), COALESCE(SUM(orders.total_paid - orders.total_return), 0)
+	FROM orders
+	WHERE orders.status = 'REFUNDED' AND ` + scope.refunds
+	err = db.QueryRowContext(ctx, query, scope.refundsArgs...).Scan(
+		&report.Refunds.TotalOrder, &report.Refunds.Amount)
+	if err != nil {
+		return report, err
+	}
+	query = `
+	SELECT COUNT(orders.id), COALESCE(SUM(orders.total_paid - orders.total_return), 0)
+	FROM orders
+	WHERE orders.status = 'VOIDED' AND ` + scope.orders
+	err = db.QueryRowContext(ctx, query, scope.ordersArgs...).Scan(
+		&report.Voids.TotalOrder, &report.Voids.Amount)
+	if err != nil {
+		return report, err
+	}
+
+	report.Tenders, err = salesTenders(ctx, db, scope)
+	if err != nil {
+		return report, err
+	}
+
+	query = `
+	SELECT COALESCE(SUM(shifts.counted_cash - shifts.expected_cash), 0)
+	FROM shifts
+	WHERE shifts.status = 'CLOSED' AND ` + scope.shifts
+	err = db.QueryRowContext(ctx, query, scope.shiftsArgs...).Scan(&report.CashOverShort)
+	return report, err
+}
+
+func salesTenders(ctx context.Context, db querier, scope salesScope) ([]model.ReportTender, error) {
+	query := `
+	SELECT payments.id, payments.name, payments.types,
+		COUNT(orders.id), COALESCE(SUM(orders.total_paid - orders.total_return), 0)
+	FROM orders
+		JOIN payments ON payments.id = orders.payment_type_id
+	WHERE orders.status IN ('PAID', 'REFUNDED') AND ` + scope.orders + `
+	GROUP BY payments.id, payments.name, payments.types
+	ORDER BY payments.id ASC`
+	rows, err := db.QueryContext(ctx, query, scope.ordersArgs...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tenders := make([]model.ReportTender, 0)
+	for rows.Next() {
+		var tender model.ReportTender
+		err := rows.Scan(
+			&tender.PaymentId,
+			&tender.Name,
+			&tender.Type,
+			&tender.TotalOrder,
+			&tender.Amount,
+		)
+		if err != nil {
+			return nil, err
+		}
+		tenders = append(tenders, tender)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Loyalty points and gift cards are tendered alongside an order's
+	// payment, so they are tenders of their own rather than payment types.
+	for _, tender := range []struct {
+		column string
+		tender model.ReportTender
+	}{
+		{"points_amount", model.ReportTender{Name: "Loyalty points", Type: "POINTS"}},
+		{"gift_card_amount", model.ReportTender{Name: "Gift cards", Type: model.GiftCardTypeGift}},
+	} {
+		query = `
+		SELECT COUNT(orders.id), COALESCE(SUM(orders.` + tender.column + `), 0)
+		FROM orders
+		WHERE orders.status IN ('PAID', 'REFUNDED') AND orders.` + tender.column + ` > 0 AND ` + scope.orders
+		err = db.QueryRowContext(ctx, query, scope.ordersArgs...).Scan(
+			&tender.tender.TotalOrder, &tender.tender.Amount)
+		if err != nil {
+			return nil, err
+		}
+		if tender.tender.Amount > 0 {
+			tenders = append(tenders, tender.tender)
+		}
+	}
+	return tenders, nil
+}
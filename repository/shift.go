@@ -0,0 +1,405 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/saptaka/pos/model"
+)
+
+var (
+	// ErrShiftOpen is returned when a shift is opened while another one
+	// still is.
+	ErrShiftOpen = errors.New("another shift is still open")
+	// ErrShiftClosed is returned when a shift is changed after it closed.
+	ErrShiftClosed = errors.New("shift is closed")
+	// ErrNoOpenShift is returned when an order is placed by a cashier
+	// with no shift open.
+	ErrNoOpenShift = errors.New("no shift is open")
+)
+
+type ShiftRepo interface {
+	OpenShift(ctx context.Context, shift model.Shift) (model.Shift, error)
+	GetShift(ctx context.Context, id int64) (model.Shift, error)
+	GetOpenShift(ctx context.Context) (model.Shift, error)
+	GetShifts(ctx context.Context, limit, skip int) ([]model.Shift, error)
+	CreateShiftMovement(ctx context.Context, movement model.ShiftMovement) (model.ShiftMovement, error)
+	GetShiftMovements(ctx context.Context, shiftId int64) ([]model.ShiftMovement, error)
+	CloseShift(ctx context.Context, id, cashierId int64, counts []model.ShiftCount) (model.Shift, error)
+	GetShiftTenders(ctx context.Context, shiftId int64) ([]model.ShiftTender, error)
+}
+
+const shiftColumns = `id, cashier_id, opening_float, status, closed_by,
+	expected_cash, counted_cash, opened_at, closed_at`
+
+func scanShift(row rowScanner) (model.Shift, error) {
+	var shift model.Shift
+	err := row.Scan(
+		&shift.ShiftId,
+		&shift.CashierId,
+		&shift.OpeningFloat,
+		&shift.Status,
+		&shift.ClosedBy,
+		&shift.ExpectedCash,
+		&shift.CountedCash,
+		&shift.OpenedAt,
+		&shift.ClosedAt,
+	)
+	shift.CashDifference = shift.CountedCash - shift.ExpectedCash
+	return shift, err
+}
+
+// OpenShift opens a shift with its opening float. open_marker is unique
+// and only set on the open shift, so of two shifts opened at once one
+// gets ErrShiftOpen.
+func (r repo) OpenShift(ctx context.Context, shift model.Shift) (model.Shift, error) {
+	query := `INSERT IGNORE INTO shifts (cashier_id, opening_float, status, open_marker)
+		VALUES (?,?,'OPEN',1)`
+	result, err := r.db.ExecContext(ctx, query, shift.CashierId, shift.OpeningFloat)
+	if err != nil {
+		return shift, err
+	}
+	rowAffected, err := result.RowsAffected()
+	if err != nil {
+		return shift, err
+	}
+	if rowAffected == 0 {
+		return shift, ErrShiftOpen
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return shift, err
+	}
+	return r.GetShift(ctx, id)
+}
+
+func (r repo) GetShift(ctx context.Context, id int64) (model.Shift, error) {
+	query := "SELECT " + shiftColumns + " FROM shifts WHERE id=?"
+	return scanShift(r.db.QueryRowContext(ctx, query, id))
+}
+
+func (r repo) GetOpenShift(ctx context.Context) (model.Shift, error) {
+	query := "SELECT " + shiftColumns + " FROM shifts WHERE status='OPEN'"
+	return scanShift(r.db.QueryRowContext(ctx, query))
+}
+
+func (r repo) GetShifts(ctx context.Context, limit, skip int) ([]model.Shift, error) {
+	query := "SELECT " + shiftColumns + " FROM shifts ORDER BY id DESC"
+	var rows *sql.Rows
+	var err error
+	if limit > 0 {
+		query += " limit ? offset ?;"
+		rows, err = r.db.QueryContext(ctx, query, limit, skip)
+	} else {
+		rows, err = r.db.QueryContext(ctx, query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shifts := make([]model.Shift, 0)
+	for rows.Next() {
+		shift, err := scanShift(rows)
+		if err != nil {
+			return nil, err
+		}
+		shifts = append(shifts, shift)
+	}
+	return shifts, rows.Err()
+}
+
+// CreateShiftMovement records cash moved in or out of the drawer. It
+// returns ErrShiftClosed when the shift closed in the meantime.
+func (r repo) CreateShiftMovement(ctx context.Context,
+	movement model.ShiftMovement) (model.ShiftMovement, error) {
+	query := `INSERT INTO shift_movements (shift_id, cashier_id, type, amount, reason)
+		SELECT id, ?, ?, ?, ? FROM shifts WHERE id=? AND status='OPEN'`
+	result, err := r.db.ExecContext(ctx, query, movement.CashierId, movement.Type,
+		movement.Amount, movement.Reason, movement.ShiftId)
+	if err != nil {
+		return movement, err
+	}
+	rowAffected, err := result.RowsAffected()
+	if err != nil {
+		return movement, err
+	}
+	if rowAffected == 0 {
+		return movement, ErrShiftClosed
+	}
+	movement.MovementId, err = result.LastInsertId()
+	return movement, err
+}
+
+func (r repo) GetShiftMovements(ctx context.Context, shiftId int64) ([]model.ShiftMovement, error) {
+	query := `SELECT id, shift_id, cashier_id, type, amount, reason, created_at
+		FROM shift_movements WHERE shift_id=? ORDER BY id ASC`
+	rows, err := r.db.QueryContext(ctx, query, shiftId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movements := make([]model.ShiftMovement, 0)
+	for rows.Next() {
+		var movement model.ShiftMovement
+		err := rows.Scan(
+			&movement.MovementId,
+			&movement.ShiftId,
+			&movement.CashierId,
+			&movement.Type,
+			&movement.Amount,
+			&movement.Reason,
+			&movement.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		movements = append(movements, movement)
+	}
+	return movements, rows.Err()
+}
+
+// CloseShift closes an open shift with the cashier's count and stores what
+// each payment type was expected to hold next to what was counted, so the
+// result no longer changes. Closing takes the shift's row before the sales
+// are totalled, so an order being placed either lands before the totals or
+// fails for want of an open shift.
+func (r repo) CloseShift(ctx context.Context, id, cashierId int64,
+	counts []model.ShiftCount) (model.Shift, error) {
+
+	payments, err := r.getPayments(ctx, 0, 0, true)
+	if err != nil {
+		return model.Shift{}, err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return model.Shift{}, err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE shifts SET status='CLOSED', open_marker=NULL, closed_by=?,
+		closed_at=CURRENT_TIMESTAMP() WHERE id=? AND status='OPEN'`
+	result, err := tx.ExecContext(ctx, query, cashierId, id)
+	if err != nil {
+		return model.Shift{}, err
+	}
+	rowAffected, err := result.RowsAffected()
+	if err != nil {
+		return model.Shift{}, err
+	}
+	if rowAffected == 0 {
+		return model.Shift{}, ErrShiftClosed
+	}
+
+	var openingFloat model.Money
+	err = tx.QueryRowContext(ctx, "SELECT opening_float FROM shifts WHERE id=?", id).Scan(&openingFloat)
+	if err != nil {
+		return model.Shift{}, err
+	}
+	sales, err := shiftSales(ctx, tx, id)
+	if err != nil {
+		return model.Shift{}, err
+	}
+	cash, err := shiftCashMovements(ctx, tx, id)
+	if err != nil {
+		return model.Shift{}, err
+	}
+	counted := make(map[int64]model.Money)
+	for _, count := range counts {
+		counted[count.PaymentId] += count.Counted
+	}
+
+	// The drawer's float and cash movements belong to the oldest cash
+	// payment type.
+	var drawerId int64
+	for _, payment := range payments {
+		if payment.Type == "CASH" && payment.ArchivedAt == nil &&
+			(drawerId == 0 || payment.PaymentId < drawerId) {
+			drawerId = payment.PaymentId
+		}
+	}
+	var expectedCash, countedCash model.Money
+	insert := `INSERT INTO shift_tenders (shift_id, payment_type_id, name, type,
+		total_order, sales, expected, counted) VALUES (?,?,?,?,?,?,?,?)`
+	for _, payment := range payments {
+		sale, sold := sales[payment.PaymentId]
+		countedAmount, isCounted := counted[payment.PaymentId]
+		drawer := payment.PaymentId == drawerId
+		if payment.ArchivedAt != nil && !sold && !isCounted && !drawer {
+			continue
+		}
+		expected := sale.Sales
+		if drawer {
+			expected += openingFloat + cash
+		}
+		if payment.Type == "CASH" {
+			// Foreign notes are not part of the store currency count;
+			// only the change given for them left the drawer.
+			for _, foreign := range sale.Foreign {
+				expected -= foreign.TotalPaid
+			}
+			expectedCash += expected
+			countedCash += countedAmount
+		}
+		_, err = tx.ExecContext(ctx, insert, id, payment.PaymentId, payment.Name, payment.Type,
+			sale.TotalOrder, sale.Sales, expected, countedAmount)
+		if err != nil {
+			return model.Shift{}, err
+		}
+		for _, foreign := range sale.Foreign {
+			_, err = tx.ExecContext(ctx, `INSERT INTO shift_currencies (shift_id,
+				payment_type_id, currency, total_order, amount, total_paid) VALUES (?,?,?,?,?,?)`,
+				id, payment.PaymentId, foreign.Currency, foreign.TotalOrder,
+				foreign.Amount, foreign.TotalPaid)
+			if err != nil {
+				return model.Shift{}, err
+			}
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE shifts SET expected_cash=?, counted_cash=? WHERE id=?",
+		expectedCash, countedCash, id)
+	if err != nil {
+		return model.Shift{}, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return model.Shift{}, err
+	}
+	return r.GetShift(ctx, id)
+}
+
+// shiftOrders picks the orders whose takings stayed in the till: the paid
+// ones, and those refunded to store credit.
+const shiftOrders = `shift_id = ? AND payment_type_id IS NOT NULL AND (status = 'PAID' OR
+	(status = 'REFUNDED' AND EXISTS (SELECT 1 FROM gift_cards WHERE gift_cards.order_id = orders.id)))`
+
+// shiftSales totals the orders of a shift per payment type in the store
+// currency, net of the change given back, with what was tendered in each
+// foreign currency listed apart.
+func shiftSales(ctx context.Context, tx *sql.Tx, shiftId int64) (map[int64]model.ShiftTender, error) {
+	query := `
+	SELECT payment_type_id, COUNT(id), COALESCE(SUM(total_paid - total_return), 0)
+	FROM orders
+	WHERE ` + shiftOrders + `
+	GROUP BY payment_type_id`
+	rows, err := tx.QueryContext(ctx, query, shiftId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sales := make(map[int64]model.ShiftTender)
+	for rows.Next() {
+		var tender model.ShiftTender
+		err := rows.Scan(&tender.PaymentId, &tender.TotalOrder, &tender.Sales)
+		if err != nil {
+			return nil, err
+		}
+		sales[tender.PaymentId] = tender
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	query = `
+	SELECT payment_type_id, tender_currency, COUNT(id),
+		CAST(SUM(CAST(tender_amount AS DECIMAL(18,4))) AS CHAR),
+		SUM(total_paid)
+	FROM orders
+	WHERE tender_currency <> '' AND ` + shiftOrders + `
+	GROUP BY payment_type_id, tender_currency
+	ORDER BY tender_currency ASC`
+	rows, err = tx.QueryContext(ctx, query, shiftId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var paymentId int64
+		var foreign model.TenderTotal
+		err := rows.Scan(&paymentId, &foreign.Currency, &foreign.TotalOrder,
+			&foreign.Amount, &foreign.TotalPaid)
+		if err != nil {
+			return nil, err
+		}
+		tender := sales[paymentId]
+		tender.Foreign = append(tender.Foreign, foreign)
+		sales[paymentId] = tender
+	}
+	return sales, rows.Err()
+}
+
+// shiftCashMovements is the net cash moved into the drawer by pay-ins,
+// pay-outs and drops.
+func shiftCashMovements(ctx context.Context, tx *sql.Tx, shiftId int64) (model.Money, error) {
+	query := `
+	SELECT COALESCE(SUM(CASE WHEN type = 'PAY_IN' THEN amount ELSE -amount END), 0)
+	FROM shift_movements
+	WHERE shift_id = ?`
+	var net model.Money
+	err := tx.QueryRowContext(ctx, query, shiftId).Scan(&net)
+	return net, err
+}
+
+func (r repo) GetShiftTenders(ctx context.Context, shiftId int64) ([]model.ShiftTender, error) {
+	query := `SELECT payment_type_id, name, type, total_order, sales, expected, counted
+		FROM shift_tenders WHERE shift_id=? ORDER BY payment_type_id ASC`
+	rows, err := r.db.QueryContext(ctx, query, shiftId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tenders := make([]model.ShiftTender, 0)
+	for rows.Next() {
+		var tender model.ShiftTender
+		err := rows.Scan(
+			&tender.PaymentId,
+			&tender.Name,
+			&tender.Type,
+			&tender.TotalOrder,
+			&tender.Sales,
+			&tender.Expected,
+			&tender.Counted,
+		)
+		if err != nil {
+			return nil, err
+		}
+		tender.Difference = tender.Counted - tender.Expected
+		tenders = append(tenders, tender)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	query = `SELECT payment_type_id, currency, total_order, amount, total_paid
+		FROM shift_currencies WHERE shift_id=? ORDER BY currency ASC`
+	rows, err = r.db.QueryContext(ctx, query, shiftId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var paymentId int64
+		var foreign model.TenderTotal
+		err := rows.Scan(&paymentId, &foreign.Currency, &foreign.TotalOrder,
+			&foreign.Amount, &foreign.TotalPaid)
+		if err != nil {
+			return nil, err
+		}
+		for index := range tenders {
+			if tenders[index].PaymentId == paymentId {
+				tenders[index].Foreign = append(tenders[index].Foreign, foreign)
+			}
+		}
+	}
+	return tenders, rows.Err()
+}
@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/saptaka/pos/model"
+)
+
+type TaxRepo interface {
+	GetTaxClassByID(ctx context.Context, id int64) (model.TaxClass, error)
+	GetTaxClasses(ctx context.Context, limit, skip int, includeArchived bool) ([]model.TaxClass, error)
+	CreateTaxClass(ctx context.Context, taxClass model.TaxClass) (model.TaxClass, error)
+	UpdateTaxClass(ctx context.Context, taxClass model.TaxClass) error
+	DeleteTaxClass(ctx context.Context, id int64) error
+	SetProductTaxClass(ctx context.Context, productId int64, taxClassId *int64) error
+	SetCategoryTaxClass(ctx context.Context, categoryId int64, taxClassId *int64) error
+	GetTaxSummary(ctx context.Context, from, to *time.Time) ([]model.OrderTax, error)
+}
+
+func (r repo) GetTaxClassByID(ctx context.Context, id int64) (model.TaxClass, error) {
+	var taxClass model.TaxClass
+	query := `SELECT id, name, rate, updated_at, created_at, archived_at
+		FROM tax_classes WHERE id=?`
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&taxClass.TaxClassId,
+		&taxClass.Name,
+		&taxClass.Rate,
+		&taxClass.UpdatedAt,
+		&taxClass.CreatedAt,
+		&taxClass.ArchivedAt,
+	)
+	return taxClass, err
+}
+
+func (r repo) GetTaxClasses(ctx context.Context, limit, skip int,
+	includeArchived bool) ([]model.TaxClass, error) {
+
+	query := "SELECT id, name, rate, updated_at, created_at, archived_at FROM tax_classes "
+	if !includeArchived {
+		query += " WHERE archived_at IS NULL "
+	}
+	query += " ORDER BY id ASC"
+	var rows *sql.Rows
+	var err error
+	if limit > 0 {
+		query += " limit ? offset ?;"
+		rows, err = r.db.QueryContext(ctx, query, limit, skip)
+	} else {
+		rows, err = r.db.QueryContext(ctx, query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	taxClasses := make([]model.TaxClass, 0)
+	for rows.Next() {
+		var taxClass model.TaxClass
+		err := rows.Scan(
+			&taxClass.TaxClassId,
+			&taxClass.Name,
+			&taxClass.Rate,
+			&taxClass.UpdatedAt,
+			&taxClass.CreatedAt,
+			&taxClass.ArchivedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		taxClasses = append(taxClasses, taxClass)
+	}
+	return taxClasses, rows.Err()
+}
+
+func (r repo) CreateTaxClass(ctx context.Context, taxClass model.TaxClass) (model.TaxClass, error) {
+	result, err := r.db.ExecContext(ctx,
+		"INSERT INTO tax_classes (name, rate) VALUES (?,?)",
+		taxClass.Name, taxClass.Rate)
+	if err != nil {
+		return taxClass, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return taxClass, err
+	}
+	return r.GetTaxClassByID(ctx, id)
+}
+
+func (r repo) UpdateTaxClass(ctx context.Context, taxClass model.TaxClass) error {
+	query := `UPDATE tax_classes
+		SET name=?, rate=?, updated_at=CURRENT_TIMESTAMP()
+		WHERE id=? AND archived_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, taxClass.Name, taxClass.Rate, taxClass.TaxClassId)
+	if err != nil {
+		return err
+	}
+	rowAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteTaxClass archives the tax class. It fails with ErrReferenced while
+// active products or categories are still assigned to it.
+func (r repo) DeleteTaxClass(ctx context.Context, id int64) error {
+	_, err := r.GetTaxClassByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	var count int
+	countQuery := `SELECT
+		(SELECT COUNT(*) FROM products WHERE tax_class_id=? AND archived_at IS NULL) +
+		(SELECT COUNT(*) FROM categories WHERE tax_class_id=? AND archived_at IS NULL)`
+	err = r.db.QueryRowContext(ctx, countQuery, id, id).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrReferenced
+	}
+
+	query := "UPDATE tax_classes SET archived_at=CURRENT_TIMESTAMP() WHERE id=? AND archived_at IS NULL"
+	_, err = r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+func (r repo) SetProductTaxClass(ctx context.Context, productId int64, taxClassId *int64) error {
+	if err := r.activeTaxClass(ctx, taxClassId); err != nil {
+		return err
+	}
+	query := `UPDATE products
+		SET tax_class_id=?, updated_at=CURRENT_TIMESTAMP()
+		WHERE id=? AND archived_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, taxClassId, productId)
+	if err != nil {
+		return err
+	}
+	rowAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r repo) SetCategoryTaxClass(ctx context.Context, categoryId int64, taxClassId *int64) error {
+	if err := r.activeTaxClass(ctx, taxClassId); err != nil {
+		return err
+	}
+	query := `UPDATE categories
+		SET tax_class_id=?, updated_at=CURRENT_TIMESTAMP()
+		WHERE id=? AND archived_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, taxClassId, categoryId)
+	if err != nil {
+		return err
+	}
+	rowAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r repo) activeTaxClass(ctx context.Context, taxClassId *int64) error {
+	if taxClassId == nil {
+		return nil
+	}
+	taxClass, err := r.GetTaxClassByID(ctx, *taxClassId)
+	if err != nil {
+		return err
+	}
+	if taxClass.ArchivedAt != nil {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetTaxSummary totals the tax of the ordered products per tax class and
+// rate over orders placed between from and to, either of which may be left
+// open.
+func (r repo) GetTaxSummary(ctx context.Context, from, to *time.Time) ([]model.OrderTax, error) {
+	query := `
+	SELECT ordered_products.tax_class_id,
+		COALESCE(tax_classes.name, ''),
+		ordered_products.tax_rate,
+		COALESCE(SUM(ordered_products.taxable_amount), 0),
+		COALESCE(SUM(ordered_products.tax_amount), 0)
+	FROM ordered_products
+	JOIN orders ON orders.id = ordered_products.order_id
+	LEFT JOIN tax_classes ON tax_classes.id = ordered_products.tax_class_id
+	WHERE orders.status = 'PAID'`
+	var args []interface{}
+	if from != nil {
+		query += " AND orders.created_at >= ?"
+		args = append(args, *from)
+	}
+	if to != nil {
+		query += " AND orders.created_at < ?"
+		args = append(args, *to)
+	}
+	query += `
+	GROUP BY ordered_products.tax_class_id, tax_classes.name, ordered_products.tax_rate
+	ORDER BY ordered_products.tax_rate DESC, ordered_products.tax_class_id ASC`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	taxes := make([]model.OrderTax, 0)
+	for rows.Next() {
+		var orderTax model.OrderTax
+		err := rows.Scan(
+			&orderTax.TaxClassId,
+			&orderTax.Name,
+			&orderTax.Rate,
+			&orderTax.TaxableAmount,
+			&orderTax.TaxAmount,
+		)
+		if err != nil {
+			return nil, err
+		}
+		taxes = append(taxes, orderTax)
+	}
+	return taxes, rows.Err()
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/saptaka/pos/model"
+)
+
+type TipRepo interface {
+	GetTipReport(ctx context.Context, from, to *time.Time) ([]model.CashierTips, error)
+}
+
+// GetTipReport totals the service charge and tips per cashier over orders
+// placed between from and to, either of which may be left open.
+func (r repo) GetTipReport(ctx context.Context, from, to *time.Time) ([]model.CashierTips, error) {
+	query := `
+	SELECT orders.cashier_id,
+		COALESCE(cashiers.name, ''),
+		COUNT(orders.id),
+		COALESCE(SUM(orders.service_charge), 0),
+		COALESCE(SUM(orders.tip), 0)
+	FROM orders
+	LEFT JOIN cashiers ON cashiers.id = orders.cashier_id
+	WHERE orders.status = 'PAID'`
+	var args []interface{}
+	if from != nil {
+		query += " AND orders.created_at >= ?"
+		args = append(args, *from)
+	}
+	if to != nil {
+		query += " AND orders.created_at < ?"
+		args = append(args, *to)
+	}
+	query += `
+	GROUP BY orders.cashier_id, cashiers.name
+	ORDER BY orders.cashier_id ASC`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cashiers := make([]model.CashierTips, 0)
+	for rows.Next() {
+		var tips model.CashierTips
+		err := rows.Scan(
+			&tips.CashierId,
+			&tips.Name,
+			&tips.TotalOrder,
+			&tips.ServiceCharge,
+			&tips.Tip,
+		)
+		if err != nil {
+			return nil, err
+		}
+		cashiers = append(cashiers, tips)
+	}
+	return cashiers, rows.Err()
+}
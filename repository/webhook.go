@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/saptaka/pos/model"
+)
+
+// ErrDuplicateEvent is returned when a provider delivers an event it has
+// delivered before.
+var ErrDuplicateEvent = errors.New("payment event already received")
+
+type WebhookRepo interface {
+	CreatePaymentEvent(ctx context.Context, event model.PaymentEventRecord) (model.PaymentEventRecord, error)
+	GetPaymentEvent(ctx context.Context, provider, eventId string) (model.PaymentEventRecord, error)
+	GetPaymentEvents(ctx context.Context, orderReference string, limit, skip int) ([]model.PaymentEventRecord, error)
+	UpdatePaymentEventResult(ctx context.Context, id int64, result string) error
+}
+
+const paymentEventColumns = "id, provider, event_id, order_reference, status, payload, signature, result, created_at"
+
+func scanPaymentEvent(row rowScanner) (model.PaymentEventRecord, error) {
+	var event model.PaymentEventRecord
+	err := row.Scan(
+		&event.PaymentEventId,
+		&event.Provider,
+		&event.EventId,
+		&event.OrderReference,
+		&event.Status,
+		&event.Payload,
+		&event.Signature,
+		&event.Result,
+		&event.CreatedAt,
+	)
+	return event, err
+}
+
+// CreatePaymentEvent stores a received event, returning ErrDuplicateEvent
+// when the provider's event ID was stored before.
+func (r repo) CreatePaymentEvent(ctx context.Context,
+	event model.PaymentEventRecord) (model.PaymentEventRecord, error) {
+
+	query := `INSERT IGNORE INTO payment_events
+		(provider, event_id, order_reference, status, payload, signature, result)
+		VALUES (?,?,?,?,?,?,?)`
+	result, err := r.db.ExecContext(ctx, query, event.Provider, event.EventId,
+		event.OrderReference, event.Status, event.Payload, event.Signature, event.Result)
+	if err != nil {
+		return event, err
+	}
+	rowAffected, err := result.RowsAffected()
+	if err != nil {
+		return event, err
+	}
+	if rowAffected == 0 {
+		return event, ErrDuplicateEvent
+	}
+	event.PaymentEventId, err = result.LastInsertId()
+	return event, err
+}
+
+func (r repo) GetPaymentEvent(ctx context.Context, provider, eventId string) (model.PaymentEventRecord, error) {
+	query := "SELECT " + paymentEventColumns + " FROM payment_events WHERE provider=? AND event_id=?"
+	return scanPaymentEvent(r.db.QueryRowContext(ctx, query, provider, eventId))
+}
+
+// GetPaymentEvents lists the events received, the latest first, for one
+// order when orderReference is given.
+func (r repo) GetPaymentEvents(ctx context.Context, orderReference string,
+	limit, skip int) ([]model.PaymentEventRecord, error) {
+
+	query := "SELECT " + paymentEventColumns + " FROM payment_events"
+	var args []interface{}
+	if orderReference != "" {
+		query += " WHERE order_reference=?"
+		args = append(args, orderReference)
+	}
+	query += " ORDER BY id DESC"
+	if limit > 0 {
+		query += " limit ? offset ?;"
+		args = append(args, limit, skip)
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]model.PaymentEventRecord, 0)
+	for rows.Next() {
+		event, err := scanPaymentEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+func (r repo) UpdatePaymentEventResult(ctx context.Context, id int64, result string) error {
+	query := "UPDATE payment_events SET result=? WHERE id=?"
+	_, err := r.db.ExecContext(ctx, query, result, id)
+	return err
+}
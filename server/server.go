@@ -12,7 +12,13 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/saptaka/pos/api"
 	"github.com/saptaka/pos/config"
+	"github.com/saptaka/pos/edc"
+	"github.com/saptaka/pos/gateway"
+	"github.com/saptaka/pos/model"
+	"github.com/saptaka/pos/notify"
+	"github.com/saptaka/pos/qris"
 	"github.com/saptaka/pos/repository"
+	"github.com/saptaka/pos/storage"
 )
 
 type ApiServer interface {
@@ -43,8 +49,45 @@ func (s *server) Listen(port int) {
 
 func NewServer(cfg *config.Config, repo repository.Repo) ApiServer {
 
+	fileStorage, err := storage.NewLocalStorage(cfg.Store.StorageDir)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	providers := []gateway.Provider{
+		gateway.NewMock(gateway.Outcome(cfg.Store.MockPaymentOutcome), 0),
+	}
+	if merchant := cfg.Store.QRIS; merchant.MerchantID != "" {
+		providers = append(providers, gateway.NewQRIS(qris.Merchant{
+			Name:       merchant.MerchantName,
+			City:       merchant.MerchantCity,
+			PostalCode: merchant.PostalCode,
+			ID:         merchant.MerchantID,
+			Acquirer:   merchant.Acquirer,
+			PAN:        merchant.MerchantPAN,
+			Category:   merchant.Category,
+			Criteria:   merchant.Criteria,
+		}, time.Duration(merchant.Expiry)*time.Second, repo))
+	}
+	if cfg.Store.EDCAddress != "" {
+		providers = append(providers, gateway.NewEDC(edc.NewTCPDriver(cfg.Store.EDCAddress)))
+	}
+	gateways := gateway.NewRegistry(providers...)
+
+	notifier := make(notify.Notifier)
+	if cfg.Store.SMTP.Address != "" {
+		notifier[model.ReceiptEmail] = notify.NewSMTP(cfg.Store.SMTP.Address, cfg.Store.SMTP.From,
+			cfg.Store.SMTP.Username, cfg.Store.SMTP.Password)
+	}
+	switch cfg.Store.SMS.Gateway {
+	case notify.SMSGatewayLog:
+		notifier[model.ReceiptSMS] = notify.LogSMS{}
+	case notify.SMSGatewayHTTP:
+		notifier[model.ReceiptSMS] = notify.NewHTTPSMS(cfg.Store.SMS.URL, cfg.Store.SMS.Token, cfg.Store.SMS.Sender)
+	}
+
 	muxRouter := mux.NewRouter()
-	apiHandler := api.NewAPI(context.Background(), muxRouter, repo)
+	apiHandler := api.NewAPI(context.Background(), muxRouter, repo, cfg, fileStorage, gateways, notifier)
 	apiHandler.Route()
 	return &server{muxRouter}
 }
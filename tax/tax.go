@@ -0,0 +1,63 @@
+package tax
+
+import "github.com/saptaka/pos/model"
+
+// Line is a priced order line with the tax rate, in percent, it falls under.
+type Line struct {
+	Rate   float64
+	Amount model.Money
+}
+
+// LineTax is the tax of a line. Taxable is the amount excluding tax.
+type LineTax struct {
+	Taxable model.Money
+	Tax     model.Money
+}
+
+type Result struct {
+	Lines []LineTax
+	Tax   model.Money
+}
+
+// Compute taxes every line on its own. Order level discounts are first
+// spread over the lines in proportion to their amounts, the last line
+// taking the rounding remainder, since they lower what is taxed. Tax is
+// rounded per line with the store currency's rounding mode. With inclusive
+// pricing the tax is contained in the line amount, otherwise it is added
+// on top of it.
+func Compute(lines []Line, orderDiscount model.Money, inclusive bool) Result {
+	result := Result{Lines: make([]LineTax, len(lines))}
+
+	var total model.Money
+	for _, line := range lines {
+		total += line.Amount
+	}
+	if orderDiscount > total {
+		orderDiscount = total
+	}
+
+	remaining := orderDiscount
+	for index, line := range lines {
+		var allocated model.Money
+		if total > 0 {
+			allocated = orderDiscount.Scale(float64(line.Amount), float64(total))
+		}
+		if index == len(lines)-1 || allocated > remaining {
+			allocated = remaining
+		}
+		remaining -= allocated
+
+		net := line.Amount - allocated
+		var lineTax LineTax
+		if inclusive {
+			lineTax.Tax = net.Scale(line.Rate, 100+line.Rate)
+			lineTax.Taxable = net - lineTax.Tax
+		} else {
+			lineTax.Tax = net.Percent(line.Rate)
+			lineTax.Taxable = net
+		}
+		result.Lines[index] = lineTax
+		result.Tax += lineTax.Tax
+	}
+	return result
+}
@@ -0,0 +1,60 @@
+package tax
+
+import (
+	"testing"
+
+	"github.com/saptaka/pos/model"
+)
+
+func TestCompute(t *testing.T) {
+	tests := []struct {
+		name      string
+		lines     []Line
+		discount  model.Money
+		inclusive bool
+		want      []LineTax
+		wantTax   model.Money
+	}{
+		{"exclusive",
+			[]Line{{11, 10000}}, 0, false,
+			[]LineTax{{10000, 1100}}, 1100},
+		{"inclusive",
+			[]Line{{11, 11100}}, 0, true,
+			[]LineTax{{10000, 1100}}, 1100},
+		{"inclusive rounded",
+			[]Line{{11, 10000}}, 0, true,
+			[]LineTax{{9009, 991}}, 991},
+		{"exclusive rounded half up",
+			[]Line{{11, 1005}}, 0, false,
+			[]LineTax{{1005, 111}}, 111},
+		{"exempt line",
+			[]Line{{11, 10000}, {0, 5000}}, 0, false,
+			[]LineTax{{10000, 1100}, {5000, 0}}, 1100},
+		{"discount spread by amount",
+			[]Line{{10, 6000}, {0, 4000}}, 1000, false,
+			[]LineTax{{5400, 540}, {3600, 0}}, 540},
+		{"discount remainder on the last line",
+			[]Line{{10, 1000}, {10, 1000}, {10, 1000}}, 100, false,
+			[]LineTax{{967, 97}, {967, 97}, {966, 97}}, 291},
+		{"discount above the total",
+			[]Line{{10, 5000}}, 8000, true,
+			[]LineTax{{0, 0}}, 0},
+		{"no lines", nil, 0, false, []LineTax{}, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Compute(test.lines, test.discount, test.inclusive)
+			if got.Tax != test.wantTax {
+				t.Errorf("Tax = %d, want %d", got.Tax, test.wantTax)
+			}
+			if len(got.Lines) != len(test.want) {
+				t.Fatalf("got %d lines, want %d", len(got.Lines), len(test.want))
+			}
+			for index, line := range got.Lines {
+				if line != test.want[index] {
+					t.Errorf("line %d = %+v, want %+v", index, line, test.want[index])
+				}
+			}
+		})
+	}
+}
@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"regexp"
+	"strconv"
 
 	"github.com/saptaka/pos/model"
 )
@@ -50,12 +50,10 @@ func ResponseWrapper(statusCode int, data interface{}) ([]byte, int) {
 	return jsonData, statusCode
 }
 
-func FormatCommas(num int) string {
-	str := fmt.Sprintf("%d", num)
-	re := regexp.MustCompile(`(\d+)(\d{3})`)
-	for n := ""; n != str; {
-		n = str
-		str = re.ReplaceAllString(str, "$1,$2")
-	}
-	return str
+func FormatQty(qty float64) string {
+	return strconv.FormatFloat(qty, 'f', -1, 64)
+}
+
+func FormatUnitPrice(qty float64, unit string, price model.Money) string {
+	return fmt.Sprintf("%s %s x %s", FormatQty(qty), unit, price)
 }
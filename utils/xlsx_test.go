@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// workbook builds an XLSX file whose first sheet is sheetData, with
+// sharedStrings as its shared string table when not empty.
+func workbook(t *testing.T, sheetData, sharedStrings string) []byte {
+	t.Helper()
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Products" sheetId="1" r:id="rId3"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships>` +
+			`<Relationship Id="rId3" Target="worksheets/products.xml"/></Relationships>`,
+		"xl/worksheets/products.xml": `<worksheet><sheetData>` + sheetData + `</sheetData></worksheet>`,
+	}
+	if sharedStrings != "" {
+		parts["xl/sharedStrings.xml"] = `<sst>` + sharedStrings + `</sst>`
+	}
+	var file bytes.Buffer
+	archive := zip.NewWriter(&file)
+	for name, content := range parts {
+		part, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write([]byte(content))
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return file.Bytes()
+}
+
+func TestReadXLSX(t *testing.T) {
+	tests := []struct {
+		name          string
+		sheetData     string
+		sharedStrings string
+		want          [][]string
+		err           bool
+	}{
+		{"shared strings",
+			`<row><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>` +
+				`<row><c r="A2" t="s"><v>2</v></c><c r="B2"><v>12500</v></c></row>`,
+			`<si><t>name</t></si><si><t>price</t></si><si><r><t>Kopi </t></r><r><t>Susu</t></r></si>`,
+			[][]string{{"name", "price"}, {"Kopi Susu", "12500"}}, false},
+		{"inline strings",
+			`<row><c r="A1" t="inlineStr"><is><t>sku</t></is></c></row>`,
+			"",
+			[][]string{{"sku"}}, false},
+		{"skipped cells",
+			`<row><c r="B1"><v>1</v></c><c r="D1"><v>2</v></c></row>`,
+			"",
+			[][]string{{"", "1", "", "2"}}, false},
+		{"cells without references",
+			`<row><c><v>1</v></c><c><v>2</v></c></row>`,
+			"",
+			[][]string{{"1", "2"}}, false},
+		{"shared string out of range",
+			`<row><c r="A1" t="s"><v>3</v></c></row>`,
+			`<si><t>name</t></si>`,
+			nil, true},
+		{"lower case reference",
+			`<row><c r="a1"><v>1</v></c></row>`,
+			"",
+			nil, true},
+		{"reference past column XFD",
+			`<row><c r="XFE1"><v>1</v></c></row>`,
+			"",
+			nil, true},
+		{"reference without a row",
+			`<row><c r="A"><v>1</v></c></row>`,
+			"",
+			nil, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ReadXLSX(workbook(t, test.sheetData, test.sharedStrings))
+			if (err != nil) != test.err {
+				t.Fatalf("ReadXLSX error = %v, want error %v", err, test.err)
+			}
+			if !sameRows(got, test.want) {
+				t.Errorf("ReadXLSX = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReadXLSXNotZip(t *testing.T) {
+	if _, err := ReadXLSX([]byte("name,price\n")); err == nil {
+		t.Error("ReadXLSX of a CSV file succeeded")
+	}
+}
+
+// TestWriteXLSX reads back a file written by WriteXLSX.
+func TestWriteXLSX(t *testing.T) {
+	rows := [][]string{
+		{"sku", "name", "price"},
+		{"KP-01", "Kopi <Susu> & Gula", "12500"},
+		{"TH-01", "", "0.5"},
+	}
+	var file bytes.Buffer
+	if err := WriteXLSX(&file, rows); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadXLSX(file.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameRows(got, rows) {
+		t.Errorf("ReadXLSX = %q, want %q", got, rows)
+	}
+}
+
+func TestXLSXColumn(t *testing.T) {
+	tests := []struct {
+		ref   string
+		index int
+	}{
+		{"A1", 0},
+		{"Z9", 25},
+		{"AA10", 26},
+		{"AZ1", 51},
+		{"XFD1048576", 16383},
+	}
+	for _, test := range tests {
+		index, err := xlsxColumnIndex(test.ref)
+		if err != nil || index != test.index {
+			t.Errorf("xlsxColumnIndex(%q) = %d, %v, want %d", test.ref, index, err, test.index)
+		}
+		name := strings.TrimRight(test.ref, "0123456789")
+		if got := xlsxColumnName(test.index); got != name {
+			t.Errorf("xlsxColumnName(%d) = %q, want %q", test.index, got, name)
+		}
+	}
+}
+
+func sameRows(got, want [][]string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for index := range got {
+		if strings.Join(got[index], "\x00") != strings.Join(want[index], "\x00") ||
+			len(got[index]) != len(want[index]) {
+			return false
+		}
+	}
+	return true
+}